@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// TTL is an in-memory cache whose entries expire after a fixed TTL. With a
+// max size, the least recently used entry is evicted when full. Expired
+// entries are dropped lazily on access, and periodically when a cleanup
+// interval is set.
+type TTL[K comparable, V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	ll         *list.List
+	items      map[K]*list.Element
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+type ttlEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type config struct {
+	maxEntries      int
+	cleanupInterval time.Duration
+}
+
+// Option configures a TTL cache
+type Option func(*config)
+
+// WithMaxEntries bounds the cache, evicting the least recently used entry
+// when full. Zero or negative means unbounded.
+func WithMaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// WithCleanupInterval removes expired entries in the background every d.
+// Call Close to stop the cleanup goroutine.
+func WithCleanupInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.cleanupInterval = d
+	}
+}
+
+// NewTTL creates a cache whose entries expire ttl after they are set.
+// A zero or negative ttl never expires entries.
+func NewTTL[K comparable, V any](ttl time.Duration, opts ...Option) *TTL[K, V] {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c := &TTL[K, V]{
+		ttl:        ttl,
+		maxEntries: cfg.maxEntries,
+		ll:         list.New(),
+		items:      make(map[K]*list.Element),
+		stopCh:     make(chan struct{}),
+	}
+	if cfg.cleanupInterval > 0 {
+		go c.cleanupLoop(cfg.cleanupInterval)
+	}
+	return c
+}
+
+// Get returns the value for key if it is cached and not expired
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*ttlEntry[K, V])
+	if c.expired(entry, time.Now()) {
+		c.removeElement(el)
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set caches value for key, resetting its TTL and evicting the least
+// recently used entry if the cache is full
+func (c *TTL[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = time.Now().Add(c.ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*ttlEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&ttlEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Delete removes key from the cache
+func (c *TTL[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// Len returns the number of cached entries, including expired entries not yet cleaned up
+func (c *TTL[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Flush removes all entries
+func (c *TTL[K, V]) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[K]*list.Element)
+}
+
+// Close stops the background cleanup. The cache stays usable with lazy expiry.
+func (c *TTL[K, V]) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
+
+func (c *TTL[K, V]) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.removeExpired()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+func (c *TTL[K, V]) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for el := c.ll.Back(); el != nil; {
+		prev := el.Prev()
+		if c.expired(el.Value.(*ttlEntry[K, V]), now) {
+			c.removeElement(el)
+		}
+		el = prev
+	}
+}
+
+func (c *TTL[K, V]) expired(entry *ttlEntry[K, V], now time.Time) bool {
+	return !entry.expiresAt.IsZero() && now.After(entry.expiresAt)
+}
+
+func (c *TTL[K, V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*ttlEntry[K, V]).key)
+}
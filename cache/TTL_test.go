@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTTL_GetSetDelete(t *testing.T) {
+	c := NewTTL[string, int](time.Hour)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	c.Set("a", 1)
+	c.Set("a", 2)
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Fatalf("expected overwritten value 2, got %d (found=%v)", v, ok)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", c.Len())
+	}
+
+	c.Delete("a")
+	c.Delete("missing")
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected deleted key to be gone")
+	}
+
+	c.Set("b", 1)
+	c.Flush()
+	if c.Len() != 0 {
+		t.Fatalf("expected empty cache after Flush, got %d", c.Len())
+	}
+}
+
+func TestTTL_LazyExpiry(t *testing.T) {
+	c := NewTTL[string, string](20 * time.Millisecond)
+	c.Set("a", "x")
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected expired entry to be dropped")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected expired entry to be removed on access, got %d", c.Len())
+	}
+}
+
+func TestTTL_SetResetsExpiry(t *testing.T) {
+	c := NewTTL[string, string](40 * time.Millisecond)
+	c.Set("a", "x")
+	time.Sleep(25 * time.Millisecond)
+	c.Set("a", "y")
+	time.Sleep(25 * time.Millisecond)
+
+	if v, ok := c.Get("a"); !ok || v != "y" {
+		t.Fatalf("expected refreshed entry to survive, got %q (found=%v)", v, ok)
+	}
+}
+
+func TestTTL_NoExpiry(t *testing.T) {
+	c := NewTTL[string, int](0)
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected entries without a TTL to persist")
+	}
+}
+
+func TestTTL_BackgroundCleanup(t *testing.T) {
+	c := NewTTL[string, int](10*time.Millisecond, WithCleanupInterval(5*time.Millisecond))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected background cleanup to remove expired entries, %d left", c.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Close is idempotent and leaves the cache usable
+	c.Close()
+	c.Set("c", 3)
+	if _, ok := c.Get("c"); !ok {
+		t.Fatal("expected cache to work after Close")
+	}
+}
+
+func TestTTL_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewTTL[string, int](time.Hour, WithMaxEntries(2))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// Touch a so b becomes the oldest
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set("c", 3)
+
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expected %s to stay cached", k)
+		}
+	}
+}
+
+func TestTTL_ConcurrentAccess(t *testing.T) {
+	c := NewTTL[int, string](time.Millisecond, WithMaxEntries(50), WithCleanupInterval(time.Millisecond))
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (g*1000 + i) % 100
+				switch i % 4 {
+				case 0:
+					c.Set(key, strconv.Itoa(i))
+				case 1:
+					c.Get(key)
+				case 2:
+					c.Delete(key)
+				default:
+					c.Len()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if n := c.Len(); n > 50 {
+		t.Fatalf("expected at most 50 entries, got %d", n)
+	}
+}
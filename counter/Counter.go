@@ -2,7 +2,9 @@ package counter
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -22,59 +24,209 @@ type CounterWorker struct {
 	flushEvery time.Duration
 	redis      redis.UniversalClient
 	stopCh     chan struct{}
+	stopOnce   sync.Once
+	doneCh     chan struct{}
+	started    atomic.Bool
+	prefixes   map[string]prefixConfig
+	now        func() time.Time
+	onFlush    func(FlushStats)
 }
 
-func NewCounterWorker(redis redis.UniversalClient, flushEvery time.Duration, threshold float64, bufferSize int) *CounterWorker {
-	return &CounterWorker{
+// FlushStats describes one prefix's flush to Redis
+type FlushStats struct {
+	Prefix string
+	// Keys is the number of keys written
+	Keys int
+	// Total is the sum of the flushed deltas
+	Total float64
+	// Err is set when the flush failed and the counts were kept for a retry
+	Err error
+}
+
+// prefixConfig holds the expiry settings for one prefix
+type prefixConfig struct {
+	ttl    time.Duration
+	window time.Duration
+}
+
+// Option configures a CounterWorker
+type Option func(*CounterWorker)
+
+// WithPrefixTTL expires the prefix's keys ttl after their first write, so each
+// key counts over a fixed window and then starts again from zero. The expiry is
+// set with EXPIRE NX in the flush pipeline, which requires Redis 7.
+func WithPrefixTTL(prefix string, ttl time.Duration) Option {
+	return func(cw *CounterWorker) {
+		pc := cw.prefixes[prefix]
+		pc.ttl = ttl
+		cw.prefixes[prefix] = pc
+	}
+}
+
+// WithPrefixWindow counts the prefix's keys in time buckets of the given size
+// by suffixing each key with its bucket start, e.g. usage:tenant-a:2024-06-01T10
+// for hourly windows. Unless WithPrefixTTL is also set, bucket keys expire after
+// two windows.
+func WithPrefixWindow(prefix string, window time.Duration) Option {
+	return func(cw *CounterWorker) {
+		pc := cw.prefixes[prefix]
+		pc.window = window
+		cw.prefixes[prefix] = pc
+	}
+}
+
+// WithFlushCallback calls fn after each prefix flush, including failed ones.
+// fn runs on the flushing goroutine, so it should return quickly.
+func WithFlushCallback(fn func(FlushStats)) Option {
+	return func(cw *CounterWorker) {
+		cw.onFlush = fn
+	}
+}
+
+func NewCounterWorker(redis redis.UniversalClient, flushEvery time.Duration, threshold float64, bufferSize int, opts ...Option) *CounterWorker {
+	cw := &CounterWorker{
 		counts:     make(map[string]map[string]float64),
 		events:     make(chan CounterEvent, bufferSize),
 		threshold:  threshold,
 		flushEvery: flushEvery,
 		redis:      redis,
 		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		prefixes:   make(map[string]prefixConfig),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(cw)
+	}
+	for prefix, pc := range cw.prefixes {
+		if pc.window > 0 && pc.ttl <= 0 {
+			pc.ttl = 2 * pc.window
+			cw.prefixes[prefix] = pc
+		}
+	}
+	return cw
+}
+
+// bucketKey suffixes key with the start of its window, formatted only as
+// finely as the window size needs
+func bucketKey(key string, window time.Duration, now time.Time) string {
+	layout := "2006-01-02T15:04:05"
+	switch {
+	case window >= 24*time.Hour && window%(24*time.Hour) == 0:
+		layout = "2006-01-02"
+	case window >= time.Hour && window%time.Hour == 0:
+		layout = "2006-01-02T15"
+	case window >= time.Minute && window%time.Minute == 0:
+		layout = "2006-01-02T15:04"
 	}
+	return key + ":" + now.UTC().Truncate(window).Format(layout)
 }
 
 func (cw *CounterWorker) Start(ctx context.Context) {
+	cw.started.Store(true)
+	defer close(cw.doneCh)
+
 	ticker := time.NewTicker(cw.flushEvery)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case ev := <-cw.events:
-			cw.mu.Lock()
-			if _, ok := cw.counts[ev.Prefix]; !ok {
-				cw.counts[ev.Prefix] = make(map[string]float64)
-			}
-			cw.counts[ev.Prefix][ev.Key] += ev.Delta
-			val := cw.counts[ev.Prefix][ev.Key]
-			cw.mu.Unlock()
-
-			if val >= cw.threshold {
+			if val := cw.add(ev); val >= cw.threshold {
 				_ = cw.flushToRedis(ctx, ev.Prefix)
 			}
 
 		case <-ticker.C:
-
-			for prefix := range cw.counts {
-				_ = cw.flushToRedis(ctx, prefix)
-			}
+			_ = cw.flushAll(ctx)
 
 		case <-cw.stopCh:
-			for prefix := range cw.counts {
-				_ = cw.flushToRedis(ctx, prefix)
-			}
+			cw.drainEvents()
+			_ = cw.flushAll(ctx)
 			return
 		}
 	}
 }
 
+// add applies an event to the pending counts and returns the key's new total
+func (cw *CounterWorker) add(ev CounterEvent) float64 {
+	key := ev.Key
+	if window := cw.prefixes[ev.Prefix].window; window > 0 {
+		// Bucket by arrival time so a late flush still counts in the right window
+		key = bucketKey(key, window, cw.now())
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.addLocked(ev.Prefix, key, ev.Delta)
+}
+
+// addLocked is add for callers already holding cw.mu
+func (cw *CounterWorker) addLocked(prefix, key string, delta float64) float64 {
+	if _, ok := cw.counts[prefix]; !ok {
+		cw.counts[prefix] = make(map[string]float64)
+	}
+	cw.counts[prefix][key] += delta
+	return cw.counts[prefix][key]
+}
+
+// drainEvents moves buffered events into the pending counts without blocking
+func (cw *CounterWorker) drainEvents() {
+	for {
+		select {
+		case ev := <-cw.events:
+			cw.add(ev)
+		default:
+			return
+		}
+	}
+}
+
+func (cw *CounterWorker) flushAll(ctx context.Context) error {
+	cw.mu.Lock()
+	prefixes := make([]string, 0, len(cw.counts))
+	for prefix := range cw.counts {
+		prefixes = append(prefixes, prefix)
+	}
+	cw.mu.Unlock()
+
+	var errs []error
+	for _, prefix := range prefixes {
+		if err := cw.flushToRedis(ctx, prefix); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (cw *CounterWorker) GetInterval() time.Duration {
 	return cw.flushEvery
 }
 
+// Stop signals the worker loop to do a final flush and exit without waiting
+// for it. It is safe to call more than once; use Shutdown to wait for the
+// final flush and learn whether it succeeded.
 func (cw *CounterWorker) Stop() {
-	close(cw.stopCh)
+	cw.stopOnce.Do(func() { close(cw.stopCh) })
+}
+
+// Shutdown stops the worker loop and flushes all pending counts, giving up
+// when ctx is done. It returns any flush error, so counts are not lost silently.
+// Register it with the server using a closure, e.g.
+//
+//	server.WithShutdownFunc(func() { _ = worker.Shutdown(ctx) })
+func (cw *CounterWorker) Shutdown(ctx context.Context) error {
+	cw.Stop()
+	if cw.started.Load() {
+		select {
+		case <-cw.doneCh:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Whatever the loop could not flush (or everything, if it never ran)
+	cw.drainEvents()
+	return cw.flushAll(ctx)
 }
 
 func (cw *CounterWorker) Increment(prefix, key string, delta float64) {
@@ -85,29 +237,75 @@ func (cw *CounterWorker) Increment(prefix, key string, delta float64) {
 	}
 }
 
+// flushToRedis takes the prefix's pending counts and writes them to Redis.
+// The lock is only held while swapping the map out, so increments and other
+// flushes (e.g. FlushNow from another goroutine) never see a batch twice.
 func (cw *CounterWorker) flushToRedis(ctx context.Context, prefix string) error {
 	cw.mu.Lock()
-	defer cw.mu.Unlock()
-
 	data := cw.counts[prefix]
-
 	if len(data) == 0 || cw.redis == nil {
+		cw.mu.Unlock()
 		return nil
 	}
+	delete(cw.counts, prefix)
+	cw.mu.Unlock()
 
+	ttl := cw.prefixes[prefix].ttl
+	stats := FlushStats{Prefix: prefix, Keys: len(data)}
 	pipe := cw.redis.Pipeline()
 	for k, v := range data {
+		stats.Total += v
 		pipe.IncrByFloat(ctx, prefix+":"+k, v)
+		if ttl > 0 {
+			// NX leaves the expiry from the window's first write in place
+			pipe.ExpireNX(ctx, prefix+":"+k, ttl)
+		}
 	}
 	_, err := pipe.Exec(ctx)
-
-	if err == nil {
-		cw.counts[prefix] = make(map[string]float64)
+	if err != nil {
+		// Merge the batch back so the next flush retries it
+		cw.mu.Lock()
+		for k, v := range data {
+			cw.addLocked(prefix, k, v)
+		}
+		cw.mu.Unlock()
 	}
 
+	if cw.onFlush != nil {
+		stats.Err = err
+		cw.onFlush(stats)
+	}
 	return err
 }
 
+// Snapshot returns a copy of the counts not yet flushed, keyed by prefix then key
+func (cw *CounterWorker) Snapshot() map[string]map[string]float64 {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	snapshot := make(map[string]map[string]float64, len(cw.counts))
+	for prefix, keys := range cw.counts {
+		copied := make(map[string]float64, len(keys))
+		for k, v := range keys {
+			copied[k] = v
+		}
+		snapshot[prefix] = copied
+	}
+	return snapshot
+}
+
+// PendingTotal returns the sum of the prefix's counts not yet flushed
+func (cw *CounterWorker) PendingTotal(prefix string) float64 {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	var total float64
+	for _, v := range cw.counts[prefix] {
+		total += v
+	}
+	return total
+}
+
 func (cw *CounterWorker) FlushNow(prefix string, ctx context.Context) error {
 	return cw.flushToRedis(ctx, prefix)
 }
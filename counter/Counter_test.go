@@ -0,0 +1,376 @@
+package counter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryHook serves pipelined INCRBYFLOAT commands from memory so no
+// Redis server is needed
+type memoryHook struct {
+	mu    sync.Mutex
+	store map[string]float64
+	ttls  map[string]time.Duration
+	delay time.Duration
+}
+
+func (h *memoryHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(context.Context, string, string) (net.Conn, error) {
+		return nil, errors.New("memoryHook: dialing is not supported")
+	}
+}
+
+func (h *memoryHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (h *memoryHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if h.delay > 0 {
+			select {
+			case <-time.After(h.delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, cmd := range cmds {
+			args := cmd.Args()
+			key := args[1].(string)
+			switch cmd.Name() {
+			case "incrbyfloat":
+				h.store[key] += args[2].(float64)
+				cmd.(*redis.FloatCmd).SetVal(h.store[key])
+			case "expire": // expire key seconds nx
+				_, set := h.ttls[key]
+				if !set {
+					h.ttls[key] = time.Duration(args[2].(int64)) * time.Second
+				}
+				cmd.(*redis.BoolCmd).SetVal(!set)
+			}
+		}
+		return nil
+	}
+}
+
+func (h *memoryHook) get(key string) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.store[key]
+}
+
+func (h *memoryHook) ttl(key string) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ttls[key]
+}
+
+func newTestRedis(delay time.Duration) (*redis.Client, *memoryHook) {
+	hook := &memoryHook{
+		store: make(map[string]float64),
+		ttls:  make(map[string]time.Duration),
+		delay: delay,
+	}
+	client := redis.NewClient(&redis.Options{Addr: "memory:0"})
+	client.AddHook(hook)
+	return client, hook
+}
+
+func TestShutdown_FlushesPendingCounts(t *testing.T) {
+	client, store := newTestRedis(0)
+	// Long interval and high threshold so only Shutdown flushes
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	go cw.Start(context.Background())
+
+	cw.Increment("usage", "tenant-a", 2)
+	cw.Increment("usage", "tenant-a", 3)
+	cw.Increment("requests", "tenant-b", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := cw.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := store.get("usage:tenant-a"); got != 5 {
+		t.Fatalf("expected usage:tenant-a = 5, got %v", got)
+	}
+	if got := store.get("requests:tenant-b"); got != 1 {
+		t.Fatalf("expected requests:tenant-b = 1, got %v", got)
+	}
+}
+
+func TestShutdown_WithoutStart(t *testing.T) {
+	client, store := newTestRedis(0)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	cw.Increment("usage", "tenant-a", 4)
+
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.get("usage:tenant-a"); got != 4 {
+		t.Fatalf("expected buffered event to be flushed, got %v", got)
+	}
+	// A second call is a no-op
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error on second shutdown: %v", err)
+	}
+}
+
+func TestShutdown_ReturnsErrorWhenDeadlineExceeded(t *testing.T) {
+	client, _ := newTestRedis(time.Second)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	cw.Increment("usage", "tenant-a", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := cw.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("Shutdown did not respect the context deadline")
+	}
+}
+
+func TestCounter_ConcurrentIncrementAndFlushNow(t *testing.T) {
+	client, store := newTestRedis(0)
+	// Low threshold so the loop also flushes while FlushNow runs
+	cw := NewCounterWorker(client, time.Millisecond, 5, 64)
+	go cw.Start(context.Background())
+
+	const (
+		writers   = 8
+		perWriter = 500
+	)
+	stop := make(chan struct{})
+	var flushers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		flushers.Add(1)
+		go func() {
+			defer flushers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = cw.FlushNow("usage", context.Background())
+				}
+			}
+		}()
+	}
+
+	var writersWG sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		writersWG.Add(1)
+		go func() {
+			defer writersWG.Done()
+			for j := 0; j < perWriter; j++ {
+				cw.Increment("usage", "tenant-a", 1)
+			}
+		}()
+	}
+	writersWG.Wait()
+	close(stop)
+	flushers.Wait()
+
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := store.get("usage:tenant-a"), float64(writers*perWriter); got != want {
+		t.Fatalf("expected usage:tenant-a = %v, got %v", want, got)
+	}
+}
+
+func TestCounter_FailedFlushIsRetried(t *testing.T) {
+	client, store := newTestRedis(time.Second)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	cw.Increment("usage", "tenant-a", 3)
+	cw.drainEvents()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := cw.FlushNow("usage", ctx); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+
+	store.mu.Lock()
+	store.delay = 0
+	store.mu.Unlock()
+	if err := cw.FlushNow("usage", context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.get("usage:tenant-a"); got != 3 {
+		t.Fatalf("expected the failed batch to be retried once, got %v", got)
+	}
+}
+
+func TestCounter_PrefixTTL(t *testing.T) {
+	client, store := newTestRedis(0)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10, WithPrefixTTL("rate", time.Minute))
+
+	cw.Increment("rate", "tenant-a", 1)
+	cw.Increment("usage", "tenant-a", 1)
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := store.ttl("rate:tenant-a"); got != time.Minute {
+		t.Fatalf("expected rate:tenant-a to expire after 1m, got %v", got)
+	}
+	if got := store.ttl("usage:tenant-a"); got != 0 {
+		t.Fatalf("expected usage:tenant-a to have no expiry, got %v", got)
+	}
+}
+
+func TestCounter_PrefixWindowRollsOver(t *testing.T) {
+	client, store := newTestRedis(0)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10, WithPrefixWindow("usage", time.Hour))
+	now := time.Date(2024, 6, 1, 10, 59, 0, 0, time.UTC)
+	cw.now = func() time.Time { return now }
+
+	// Applied directly so each event is bucketed at a known time
+	cw.add(CounterEvent{Prefix: "usage", Key: "tenant-a", Delta: 2})
+	cw.add(CounterEvent{Prefix: "usage", Key: "tenant-a", Delta: 3})
+	now = now.Add(2 * time.Minute)
+	cw.add(CounterEvent{Prefix: "usage", Key: "tenant-a", Delta: 4})
+
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := store.get("usage:tenant-a:2024-06-01T10"); got != 5 {
+		t.Fatalf("expected 5 in the 10:00 bucket, got %v", got)
+	}
+	if got := store.get("usage:tenant-a:2024-06-01T11"); got != 4 {
+		t.Fatalf("expected 4 in the 11:00 bucket, got %v", got)
+	}
+	if got := store.ttl("usage:tenant-a:2024-06-01T11"); got != 2*time.Hour {
+		t.Fatalf("expected bucket keys to expire after two windows, got %v", got)
+	}
+}
+
+func TestBucketKey(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 42, 17, 0, time.UTC)
+	tests := []struct {
+		window time.Duration
+		want   string
+	}{
+		{24 * time.Hour, "k:2024-06-01"},
+		{time.Hour, "k:2024-06-01T10"},
+		{15 * time.Minute, "k:2024-06-01T10:30"},
+		{10 * time.Second, "k:2024-06-01T10:42:10"},
+	}
+	for _, tt := range tests {
+		if got := bucketKey("k", tt.window, now); got != tt.want {
+			t.Errorf("bucketKey(%v) = %q, want %q", tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestCounter_SnapshotIsACopyOfPendingCounts(t *testing.T) {
+	client, _ := newTestRedis(0)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	go cw.Start(context.Background())
+	defer cw.Shutdown(context.Background())
+
+	cw.Increment("usage", "tenant-a", 2)
+	cw.Increment("usage", "tenant-b", 3)
+
+	deadline := time.Now().Add(time.Second)
+	for cw.PendingTotal("usage") != 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := cw.PendingTotal("usage"); got != 5 {
+		t.Fatalf("expected pending total 5, got %v", got)
+	}
+
+	snapshot := cw.Snapshot()
+	if snapshot["usage"]["tenant-a"] != 2 || snapshot["usage"]["tenant-b"] != 3 {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+
+	snapshot["usage"]["tenant-a"] = 100
+	delete(snapshot, "usage")
+	if got := cw.Snapshot()["usage"]["tenant-a"]; got != 2 {
+		t.Fatalf("mutating the snapshot changed the worker: got %v", got)
+	}
+}
+
+func TestCounter_FlushCallback(t *testing.T) {
+	client, _ := newTestRedis(0)
+	var stats []FlushStats
+	cw := NewCounterWorker(client, time.Hour, 1000, 10, WithFlushCallback(func(s FlushStats) {
+		stats = append(stats, s)
+	}))
+
+	cw.Increment("usage", "tenant-a", 2)
+	cw.Increment("usage", "tenant-b", 3)
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stats) != 1 {
+		t.Fatalf("expected one flush, got %+v", stats)
+	}
+	if s := stats[0]; s.Prefix != "usage" || s.Keys != 2 || s.Total != 5 || s.Err != nil {
+		t.Fatalf("unexpected flush stats %+v", s)
+	}
+	if got := cw.PendingTotal("usage"); got != 0 {
+		t.Fatalf("expected nothing pending after flush, got %v", got)
+	}
+}
+
+func TestStop_IsIdempotent(t *testing.T) {
+	client, store := newTestRedis(0)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	go cw.Start(context.Background())
+	cw.Increment("usage", "tenant-a", 1)
+
+	cw.Stop()
+	cw.Stop()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- cw.Shutdown(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := store.get("usage:tenant-a"); got != 1 {
+		t.Fatalf("expected usage:tenant-a = 1 exactly once, got %v", got)
+	}
+}
+
+func TestShutdown_ReturnsAfterFinalFlushPersisted(t *testing.T) {
+	client, store := newTestRedis(50 * time.Millisecond)
+	cw := NewCounterWorker(client, time.Hour, 1000, 10)
+	go cw.Start(context.Background())
+	cw.Increment("usage", "tenant-a", 7)
+
+	if err := cw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// No waiting: the write must already be visible
+	if got := store.get("usage:tenant-a"); got != 7 {
+		t.Fatalf("expected usage:tenant-a = 7 when Shutdown returns, got %v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/bignyap/go-utilities/crypto/api"
+)
+
+// WrappedDEKVersion is the envelope format written by ExportWrappedDEK
+const WrappedDEKVersion = 1
+
+// ErrUnsupportedVersion is returned when importing an envelope of an unknown format version
+var ErrUnsupportedVersion = errors.New("crypto: unsupported wrapped DEK envelope version")
+
+// WrappedDEKEnvelope is the backup format of a wrapped DEK. Byte fields are
+// base64 encoded in JSON.
+type WrappedDEKEnvelope struct {
+	Version    int    `json:"version"`
+	KeyID      string `json:"key_id"`
+	Algorithm  string `json:"algorithm"`
+	WrappedDEK []byte `json:"wrapped_dek"`
+}
+
+// ReEncrypt moves data to newService's KEK: the DEK is unwrapped with this
+// service's provider and re-wrapped with newService's. The ciphertext is
+// not decrypted or changed, so it is shared with the returned data.
+func (s *Service) ReEncrypt(ctx context.Context, data *api.EncryptedData, newService *Service) (*api.EncryptedData, error) {
+	dek, err := s.kmsProvider.UnwrapDEK(ctx, data.WrappedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap DEK: %w", err)
+	}
+	defer clear(dek)
+
+	wrapped, err := newService.kmsProvider.WrapDEK(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-wrap DEK: %w", err)
+	}
+
+	return &api.EncryptedData{
+		Ciphertext:         data.Ciphertext,
+		WrappedDEK:         wrapped,
+		KeyID:              newService.GetKeyID(),
+		Algorithm:          data.Algorithm,
+		AdditionalMetadata: maps.Clone(data.AdditionalMetadata),
+	}, nil
+}
+
+// ExportWrappedDEK encodes data's wrapped DEK and key ID for backup. The DEK
+// stays wrapped, so the backup is only as sensitive as the KEK's access.
+func ExportWrappedDEK(data *api.EncryptedData) ([]byte, error) {
+	if len(data.WrappedDEK) == 0 {
+		return nil, fmt.Errorf("no wrapped DEK to export")
+	}
+	return json.Marshal(WrappedDEKEnvelope{
+		Version:    WrappedDEKVersion,
+		KeyID:      data.KeyID,
+		Algorithm:  data.Algorithm,
+		WrappedDEK: data.WrappedDEK,
+	})
+}
+
+// ImportWrappedDEK restores a wrapped DEK exported by ExportWrappedDEK onto
+// data, replacing its wrapped DEK and key ID
+func ImportWrappedDEK(envelope []byte, data *api.EncryptedData) error {
+	var env WrappedDEKEnvelope
+	if err := json.Unmarshal(envelope, &env); err != nil {
+		return fmt.Errorf("failed to parse wrapped DEK envelope: %w", err)
+	}
+	if env.Version != WrappedDEKVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, env.Version)
+	}
+	if len(env.WrappedDEK) == 0 {
+		return fmt.Errorf("wrapped DEK envelope has no key")
+	}
+	if data.Algorithm != "" && env.Algorithm != data.Algorithm {
+		return fmt.Errorf("wrapped DEK is for %s, data uses %s", env.Algorithm, data.Algorithm)
+	}
+
+	data.WrappedDEK = env.WrappedDEK
+	data.KeyID = env.KeyID
+	if data.Algorithm == "" {
+		data.Algorithm = env.Algorithm
+	}
+	return nil
+}
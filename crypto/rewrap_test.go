@@ -0,0 +1,81 @@
+package crypto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bignyap/go-utilities/crypto"
+	"github.com/bignyap/go-utilities/crypto/api"
+)
+
+func TestService_ReEncryptMigratesToNewProvider(t *testing.T) {
+	ctx := context.Background()
+	oldSvc := crypto.NewService(newLocalProvider(t, "old-kek"))
+	newSvc := crypto.NewService(newLocalProvider(t, "new-kek"))
+
+	data, err := oldSvc.EncryptMessage(ctx, []byte("migrate me"), "msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := oldSvc.ReEncrypt(ctx, data, newSvc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(migrated.Ciphertext, data.Ciphertext) {
+		t.Fatal("expected the ciphertext to be unchanged")
+	}
+	if bytes.Equal(migrated.WrappedDEK, data.WrappedDEK) || migrated.KeyID != "new-kek:v1" {
+		t.Fatalf("expected the DEK re-wrapped under new-kek, got key %s", migrated.KeyID)
+	}
+
+	plaintext, err := newSvc.DecryptMessage(ctx, migrated, "msg-1")
+	if err != nil || string(plaintext) != "migrate me" {
+		t.Fatalf("new service decrypt: %q, %v", plaintext, err)
+	}
+	if _, err := oldSvc.DecryptMessage(ctx, migrated, "msg-1"); err == nil {
+		t.Fatal("expected the old KEK not to unwrap the migrated DEK")
+	}
+	if _, err := newSvc.DecryptMessage(ctx, data, "msg-1"); err == nil {
+		t.Fatal("expected the new KEK not to unwrap the original DEK")
+	}
+}
+
+func TestWrappedDEK_ExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	svc := crypto.NewService(newLocalProvider(t, "kek"))
+
+	data, err := svc.EncryptMessage(ctx, []byte("backed up"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := crypto.ExportWrappedDEK(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lose the key material, then restore it from the backup
+	restored := &api.EncryptedData{
+		Ciphertext:         data.Ciphertext,
+		Algorithm:          data.Algorithm,
+		AdditionalMetadata: data.AdditionalMetadata,
+	}
+	if err := crypto.ImportWrappedDEK(backup, restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.KeyID != data.KeyID {
+		t.Fatalf("expected key %s, got %s", data.KeyID, restored.KeyID)
+	}
+	if plaintext, err := svc.DecryptMessage(ctx, restored, ""); err != nil || string(plaintext) != "backed up" {
+		t.Fatalf("decrypt restored data: %q, %v", plaintext, err)
+	}
+}
+
+func TestWrappedDEK_ImportRejectsUnknownVersion(t *testing.T) {
+	err := crypto.ImportWrappedDEK([]byte(`{"version":2,"key_id":"k","wrapped_dek":"AAEC"}`), &api.EncryptedData{})
+	if !errors.Is(err, crypto.ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
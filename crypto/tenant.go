@@ -0,0 +1,163 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/bignyap/go-utilities/crypto/api"
+)
+
+// TenantMetadataKey is the EncryptedData.AdditionalMetadata key holding the tenant ID
+const TenantMetadataKey = "tenant_id"
+
+var (
+	// ErrNoTenant is returned when no tenant ID is given or found in the context
+	ErrNoTenant = errors.New("crypto: no tenant ID")
+	// ErrTenantMismatch is returned when data encrypted for one tenant is decrypted for another
+	ErrTenantMismatch = errors.New("crypto: data belongs to a different tenant")
+)
+
+// ProviderResolver returns the KMS provider holding a tenant's KEK
+type ProviderResolver func(tenantID string) (api.KMSProvider, error)
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context carrying tenantID for TenantService
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID set by WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// TenantService implements envelope encryption with a separate KEK per
+// tenant. Providers are resolved on first use and cached.
+type TenantService struct {
+	resolve  ProviderResolver
+	mu       sync.Mutex
+	services map[string]*Service
+}
+
+// NewTenantService creates a service that looks up each tenant's provider with resolve
+func NewTenantService(resolve ProviderResolver) *TenantService {
+	return &TenantService{
+		resolve:  resolve,
+		services: make(map[string]*Service),
+	}
+}
+
+// NewTenantServiceFromMap creates a service over a fixed set of tenant providers
+func NewTenantServiceFromMap(providers map[string]api.KMSProvider) *TenantService {
+	return NewTenantService(func(tenantID string) (api.KMSProvider, error) {
+		provider, ok := providers[tenantID]
+		if !ok {
+			return nil, fmt.Errorf("no KMS provider for tenant %q", tenantID)
+		}
+		return provider, nil
+	})
+}
+
+// serviceFor returns the cached service for tenantID, resolving its provider if needed
+func (t *TenantService) serviceFor(tenantID string) (*Service, error) {
+	if tenantID == "" {
+		return nil, ErrNoTenant
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if svc, ok := t.services[tenantID]; ok {
+		return svc, nil
+	}
+	provider, err := t.resolve(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve KMS provider for tenant %q: %w", tenantID, err)
+	}
+	svc := NewService(provider)
+	t.services[tenantID] = svc
+	return svc, nil
+}
+
+// tenantAAD binds tenantID into the GCM additional data, so ciphertext only
+// decrypts for its tenant even when tenants share a KEK or the metadata is
+// rewritten. The tenant ID is quoted to keep the encoding unambiguous.
+func tenantAAD(tenantID, associatedData string) string {
+	return strconv.Quote(tenantID) + associatedData
+}
+
+// EncryptForTenant encrypts a message with tenantID's KEK and records the
+// tenant in the result's metadata. The tenant ID is also authenticated with
+// associatedData.
+func (t *TenantService) EncryptForTenant(ctx context.Context, tenantID string, plaintext []byte, associatedData string) (*api.EncryptedData, error) {
+	svc, err := t.serviceFor(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := svc.EncryptMessage(ctx, plaintext, tenantAAD(tenantID, associatedData))
+	if err != nil {
+		return nil, err
+	}
+	data.AdditionalMetadata[TenantMetadataKey] = tenantID
+	return data, nil
+}
+
+// DecryptForTenant decrypts a message with tenantID's KEK. Data recorded as
+// belonging to another tenant is rejected with ErrTenantMismatch.
+func (t *TenantService) DecryptForTenant(ctx context.Context, tenantID string, data *api.EncryptedData, associatedData string) ([]byte, error) {
+	if owner, ok := data.AdditionalMetadata[TenantMetadataKey]; ok && owner != tenantID {
+		return nil, ErrTenantMismatch
+	}
+	svc, err := t.serviceFor(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return svc.DecryptMessage(ctx, data, tenantAAD(tenantID, associatedData))
+}
+
+// EncryptMessage encrypts a message for the tenant set on ctx with WithTenant
+func (t *TenantService) EncryptMessage(ctx context.Context, plaintext []byte, associatedData string) (*api.EncryptedData, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return t.EncryptForTenant(ctx, tenantID, plaintext, associatedData)
+}
+
+// DecryptMessage decrypts a message for the tenant set on ctx with WithTenant
+func (t *TenantService) DecryptMessage(ctx context.Context, data *api.EncryptedData, associatedData string) ([]byte, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return t.DecryptForTenant(ctx, tenantID, data, associatedData)
+}
+
+// GetKeyID returns the current key identifier for tenantID
+func (t *TenantService) GetKeyID(tenantID string) (string, error) {
+	svc, err := t.serviceFor(tenantID)
+	if err != nil {
+		return "", err
+	}
+	return svc.GetKeyID(), nil
+}
+
+// Close releases every resolved provider
+func (t *TenantService) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for tenantID, svc := range t.services {
+		if err := svc.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %q: %w", tenantID, err))
+		}
+	}
+	t.services = make(map[string]*Service)
+	return errors.Join(errs...)
+}
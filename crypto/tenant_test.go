@@ -0,0 +1,145 @@
+package crypto_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bignyap/go-utilities/crypto"
+	"github.com/bignyap/go-utilities/crypto/adapters/local"
+	"github.com/bignyap/go-utilities/crypto/api"
+	"github.com/bignyap/go-utilities/crypto/config"
+)
+
+func newLocalProvider(t *testing.T, keyName string) api.KMSProvider {
+	t.Helper()
+	provider, err := local.NewLocalKMSProvider(config.LocalConfig{KeyName: keyName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func newTenantService(t *testing.T) *crypto.TenantService {
+	t.Helper()
+	svc := crypto.NewTenantServiceFromMap(map[string]api.KMSProvider{
+		"tenant-a": newLocalProvider(t, "kek-a"),
+		"tenant-b": newLocalProvider(t, "kek-b"),
+	})
+	t.Cleanup(func() { _ = svc.Close() })
+	return svc
+}
+
+func TestTenantService_RoundTripAndKeyID(t *testing.T) {
+	ctx := context.Background()
+	svc := newTenantService(t)
+
+	for tenantID, keyID := range map[string]string{"tenant-a": "kek-a:v1", "tenant-b": "kek-b:v1"} {
+		data, err := svc.EncryptForTenant(ctx, tenantID, []byte("hello "+tenantID), "msg-1")
+		if err != nil {
+			t.Fatalf("encrypt for %s: %v", tenantID, err)
+		}
+		if data.KeyID != keyID || data.AdditionalMetadata[crypto.TenantMetadataKey] != tenantID {
+			t.Fatalf("expected key %s for %s, got %s (metadata %v)", keyID, tenantID, data.KeyID, data.AdditionalMetadata)
+		}
+
+		plaintext, err := svc.DecryptForTenant(ctx, tenantID, data, "msg-1")
+		if err != nil || string(plaintext) != "hello "+tenantID {
+			t.Fatalf("decrypt for %s: %q, %v", tenantID, plaintext, err)
+		}
+	}
+}
+
+func TestTenantService_OtherTenantCannotDecrypt(t *testing.T) {
+	ctx := context.Background()
+	svc := newTenantService(t)
+
+	data, err := svc.EncryptForTenant(ctx, "tenant-a", []byte("secret"), "msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.DecryptForTenant(ctx, "tenant-b", data, "msg-1"); !errors.Is(err, crypto.ErrTenantMismatch) {
+		t.Fatalf("expected ErrTenantMismatch, got %v", err)
+	}
+
+	// Even without the tenant marker, tenant B's KEK cannot unwrap tenant A's DEK
+	delete(data.AdditionalMetadata, crypto.TenantMetadataKey)
+	if _, err := svc.DecryptForTenant(ctx, "tenant-b", data, "msg-1"); err == nil {
+		t.Fatal("expected tenant B's provider to fail to decrypt tenant A's data")
+	}
+}
+
+func TestTenantService_TenantBoundWithSharedKEK(t *testing.T) {
+	ctx := context.Background()
+	shared := newLocalProvider(t, "kek-shared")
+	svc := crypto.NewTenantServiceFromMap(map[string]api.KMSProvider{
+		"tenant-a": shared,
+		"tenant-b": shared,
+	})
+
+	data, err := svc.EncryptForTenant(ctx, "tenant-a", []byte("secret"), "msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewriting the tenant marker doesn't help: the tenant is in the AAD
+	data.AdditionalMetadata[crypto.TenantMetadataKey] = "tenant-b"
+	if _, err := svc.DecryptForTenant(ctx, "tenant-b", data, "msg-1"); err == nil {
+		t.Fatal("expected tenant A's data not to decrypt for tenant B")
+	}
+
+	data.AdditionalMetadata[crypto.TenantMetadataKey] = "tenant-a"
+	if plaintext, err := svc.DecryptForTenant(ctx, "tenant-a", data, "msg-1"); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("decrypt for tenant-a: %q, %v", plaintext, err)
+	}
+}
+
+func TestTenantService_TenantFromContext(t *testing.T) {
+	svc := newTenantService(t)
+
+	if _, err := svc.EncryptMessage(context.Background(), []byte("x"), ""); !errors.Is(err, crypto.ErrNoTenant) {
+		t.Fatalf("expected ErrNoTenant without a tenant, got %v", err)
+	}
+
+	ctx := crypto.WithTenant(context.Background(), "tenant-b")
+	data, err := svc.EncryptMessage(ctx, []byte("from context"), "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.KeyID != "kek-b:v1" {
+		t.Fatalf("expected tenant-b's key, got %s", data.KeyID)
+	}
+	if plaintext, err := svc.DecryptMessage(ctx, data, "aad"); err != nil || string(plaintext) != "from context" {
+		t.Fatalf("decrypt: %q, %v", plaintext, err)
+	}
+}
+
+func TestTenantService_CachesResolvedProviders(t *testing.T) {
+	resolved := map[string]int{}
+	svc := crypto.NewTenantService(func(tenantID string) (api.KMSProvider, error) {
+		resolved[tenantID]++
+		if tenantID == "unknown" {
+			return nil, errors.New("no such tenant")
+		}
+		return newLocalProvider(t, tenantID), nil
+	})
+	defer svc.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := svc.EncryptForTenant(ctx, "tenant-a", []byte("x"), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resolved["tenant-a"] != 1 {
+		t.Fatalf("expected tenant-a to be resolved once, got %d", resolved["tenant-a"])
+	}
+
+	if _, err := svc.EncryptForTenant(ctx, "unknown", []byte("x"), ""); err == nil {
+		t.Fatal("expected a resolver error")
+	}
+	if _, err := svc.EncryptForTenant(ctx, "", []byte("x"), ""); !errors.Is(err, crypto.ErrNoTenant) {
+		t.Fatalf("expected ErrNoTenant, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestConnectionString_URLEscapesCredentials(t *testing.T) {
+	cs := NewConnectionString("db.internal", "5432", "app user", "p@ss/w rd:%", "orders", nil)
+
+	u, err := url.Parse(cs.URL())
+	if err != nil {
+		t.Fatalf("URL did not parse: %v", err)
+	}
+	password, _ := u.User.Password()
+	if u.User.Username() != "app user" || password != "p@ss/w rd:%" {
+		t.Fatalf("credentials did not round-trip: user=%q password=%q", u.User.Username(), password)
+	}
+	if u.Host != "db.internal:5432" || u.Path != "/orders" {
+		t.Fatalf("unexpected host %q or path %q", u.Host, u.Path)
+	}
+
+	// pgx must read back the same credentials
+	cfg, err := pgxpool.ParseConfig(cs.DSN(PostgresDriver))
+	if err != nil {
+		t.Fatalf("pgx could not parse DSN: %v", err)
+	}
+	if cfg.ConnConfig.User != "app user" || cfg.ConnConfig.Password != "p@ss/w rd:%" || cfg.ConnConfig.Database != "orders" {
+		t.Fatalf("pgx parsed user=%q password=%q db=%q", cfg.ConnConfig.User, cfg.ConnConfig.Password, cfg.ConnConfig.Database)
+	}
+}
+
+func TestConnectionString_URLSSLOptions(t *testing.T) {
+	cs := NewConnectionString("db.internal", "5432", "app", "secret", "orders", map[string]string{
+		"application_name": "billing",
+		"sslmode":          "disable",
+	})
+	cs.SSLMode = "verify-full"
+	cs.SSLRootCert = "/etc/ssl/ca.pem"
+
+	u, err := url.Parse(cs.URL())
+	if err != nil {
+		t.Fatalf("URL did not parse: %v", err)
+	}
+	q := u.Query()
+	if q.Get("sslmode") != "verify-full" {
+		t.Fatalf("SSLMode should override Options, got %q", q.Get("sslmode"))
+	}
+	if q.Get("sslrootcert") != "/etc/ssl/ca.pem" || q.Get("application_name") != "billing" {
+		t.Fatalf("unexpected query %v", q)
+	}
+	if q.Has("sslcert") || q.Has("sslkey") {
+		t.Fatalf("unset SSL fields should be omitted: %v", q)
+	}
+}
+
+func TestConnectionString_URLWithoutPasswordOrPort(t *testing.T) {
+	cs := NewConnectionString("localhost", "", "app", "", "orders", nil)
+	if got := cs.URL(); got != "postgres://app@localhost/orders" {
+		t.Fatalf("unexpected URL %q", got)
+	}
+	if strings.Contains(cs.URL(), ":@") {
+		t.Fatal("an empty password must not be rendered")
+	}
+}
+
+// flakyDriver fails to open connections until failures reaches zero
+type flakyDriver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (d *flakyDriver) Open(string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.attempts++
+	if d.failures != 0 {
+		d.failures--
+		return nil, errors.New("connection refused")
+	}
+	return flakyConn{}, nil
+}
+
+type flakyConn struct{}
+
+func (flakyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (flakyConn) Close() error                        { return nil }
+func (flakyConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+var driverSeq atomic.Int64
+
+// newFlakyConnection registers a fresh driver so tests do not share state
+func newFlakyConnection(t *testing.T, failures int) (*Connection, *flakyDriver) {
+	t.Helper()
+	d := &flakyDriver{failures: failures}
+	name := fmt.Sprintf("flaky-%d", driverSeq.Add(1))
+	sql.Register(name, d)
+
+	pool := DefaultPoolConfig()
+	pool.RetryInitialBackoff = time.Millisecond
+	pool.RetryMaxBackoff = 5 * time.Millisecond
+	conn, err := NewConnection(PostgresDriver, NewConnectionString("localhost", "5432", "app", "", "orders", nil), pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.DriverName = name
+	return conn, d
+}
+
+func TestConnection_ConnectContextRetriesUntilPingSucceeds(t *testing.T) {
+	conn, d := newFlakyConnection(t, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := conn.ConnectContext(ctx); err != nil {
+		t.Fatalf("ConnectContext: %v", err)
+	}
+	defer conn.Close()
+
+	if d.attempts != 3 {
+		t.Fatalf("expected 3 connection attempts, got %d", d.attempts)
+	}
+	if err := conn.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	if stats := conn.Stats(); stats.OpenConnections == 0 {
+		t.Fatalf("expected an open connection in %+v", stats)
+	}
+}
+
+func TestConnection_ConnectContextGivesUpAtDeadline(t *testing.T) {
+	conn, _ := newFlakyConnection(t, -1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := conn.ConnectContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("ConnectContext did not respect the deadline")
+	}
+	if conn.GetSQLDB() != nil {
+		t.Fatal("a failed connect must not leave a DB behind")
+	}
+	if err := conn.HealthCheck(context.Background()); err == nil {
+		t.Fatal("expected HealthCheck to fail when not connected")
+	}
+}
@@ -5,6 +5,8 @@ type DatabaseConfig struct {
 	Driver               Driver
 	ConnectionString     *ConnectionString
 	ConnectionPoolConfig *ConnectionPoolConfig
+	// DriverName overrides the database/sql driver to open, see Connection.DriverName
+	DriverName string
 }
 
 type Database struct {
@@ -18,6 +20,7 @@ func NewDatabase(config *DatabaseConfig) (*Database, error) {
 	if err != nil {
 		return nil, err
 	}
+	conn.DriverName = config.DriverName
 	return &Database{
 		Config:     config,
 		Connection: conn,
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// MigrationsTable records the versions applied by RunMigrations
+const MigrationsTable = "schema_migrations"
+
+const (
+	// migrationLockID is the Postgres advisory lock key held while migrating
+	migrationLockID int64 = 0x6d6967726174696f
+	// migrationLockName is the MySQL named lock held while migrating
+	migrationLockName = "go-utilities." + MigrationsTable
+)
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+// RunMigrations applies the NNN_name.up.sql files in dir that are not yet
+// recorded in schema_migrations, in version order, each in its own
+// transaction. Files may hold several statements; MySQL needs
+// multiStatements=true in the DSN for that.
+//
+// Runs from several processes are serialized with pg_advisory_lock on
+// Postgres and GET_LOCK on MySQL, so replicas can migrate on startup. db must
+// be a database/sql pool: for Postgres set Connection.DriverName, e.g. "pgx",
+// as the default connection only opens Connection.PgxPool and leaves DB nil.
+func RunMigrations(ctx context.Context, db *sql.DB, fsys fs.FS, dir string) (applied int, err error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if done[m.version] || m.up == "" {
+				continue
+			}
+			if err := applyMigration(ctx, conn, fsys, m.up, fmt.Sprintf(
+				"INSERT INTO %s (version) VALUES (%d)", MigrationsTable, m.version,
+			)); err != nil {
+				return WrapError(fmt.Sprintf("migration %d_%s", m.version, m.name), err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Rollback reverts the last steps applied migrations, newest first, using
+// their .down.sql files. It takes the same lock as RunMigrations.
+func Rollback(ctx context.Context, db *sql.DB, fsys fs.FS, dir string, steps int) (rolledBack int, err error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+			m := migrations[i]
+			if !done[m.version] {
+				continue
+			}
+			if m.down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", m.version, m.name)
+			}
+			if err := applyMigration(ctx, conn, fsys, m.down, fmt.Sprintf(
+				"DELETE FROM %s WHERE version = %d", MigrationsTable, m.version,
+			)); err != nil {
+				return WrapError(fmt.Sprintf("rollback %d_%s", m.version, m.name), err)
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// withMigrationLock runs fn on a single connection holding the migration
+// lock. Advisory locks belong to a session, so the lock, the migrations and
+// the unlock all use that connection.
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	if db == nil {
+		return fmt.Errorf("no database/sql pool: connect first or set DriverName for Postgres")
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return WrapError("migration connection", err)
+	}
+	defer conn.Close()
+
+	var unlock string
+	var args []any
+	switch migrationDialect(db) {
+	case PostgresDriver:
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return WrapError("acquire migration lock", err)
+		}
+		unlock, args = "SELECT pg_advisory_unlock($1)", []any{migrationLockID}
+	case MySQLDriver:
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", migrationLockName).Scan(&got); err != nil {
+			return WrapError("acquire migration lock", err)
+		}
+		if !got.Valid || got.Int64 != 1 {
+			return fmt.Errorf("acquire migration lock: GET_LOCK did not return 1")
+		}
+		unlock, args = "SELECT RELEASE_LOCK(?)", []any{migrationLockName}
+	}
+
+	err = fn(conn)
+	if unlock != "" {
+		// ctx may be done, and a lock left on a pooled connection would block
+		// every later run, so unlock regardless and drop the connection if
+		// that fails
+		if _, uerr := conn.ExecContext(context.Background(), unlock, args...); uerr != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			err = errors.Join(err, WrapError("release migration lock", uerr))
+		}
+	}
+	return err
+}
+
+// migrationDialect identifies the database behind db from its driver, or
+// returns "" for databases without a migration lock such as SQLite
+func migrationDialect(db *sql.DB) Driver {
+	t := reflect.TypeOf(db.Driver())
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.PkgPath() {
+	case "github.com/lib/pq", "github.com/jackc/pgx/v5/stdlib":
+		return PostgresDriver
+	case "github.com/go-sql-driver/mysql":
+		return MySQLDriver
+	}
+	return ""
+}
+
+// loadMigrations reads dir and returns its migrations sorted by version
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, WrapError("read migrations", err)
+	}
+
+	byVersion := make(map[int64]*migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		} else if m.name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.name, match[2])
+		}
+
+		file := path.Join(dir, entry.Name())
+		if match[3] == "up" {
+			m.up = file
+		} else {
+			m.down = file
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// appliedVersions creates the migrations table if needed and returns the recorded versions
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]bool, error) {
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+		MigrationsTable,
+	)); err != nil {
+		return nil, WrapError("create migrations table", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM "+MigrationsTable)
+	if err != nil {
+		return nil, WrapError("read applied migrations", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, WrapError("read applied migrations", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs a migration file and its bookkeeping statement in one transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, fsys fs.FS, file, record string) error {
+	script, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
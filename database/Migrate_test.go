@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);")},
+	"migrations/001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"migrations/002_add_name.up.sql":       {Data: []byte("ALTER TABLE users ADD COLUMN name TEXT; CREATE INDEX users_email ON users (email);")},
+	"migrations/002_add_name.down.sql":     {Data: []byte("DROP INDEX users_email; ALTER TABLE users DROP COLUMN name;")},
+	"migrations/README.md":                 {Data: []byte("not a migration")},
+}
+
+func newMigrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func appliedList(t *testing.T, db *sql.DB) []int64 {
+	t.Helper()
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var versions []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func TestRunMigrations_AppliesOnceAndRecordsVersions(t *testing.T) {
+	db := newMigrationDB(t)
+	ctx := context.Background()
+
+	applied, err := RunMigrations(ctx, db, testMigrations, "migrations")
+	if err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if applied != 2 {
+		t.Fatalf("expected 2 migrations applied, got %d", applied)
+	}
+	if got := appliedList(t, db); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected recorded versions %v", got)
+	}
+	if _, err := db.Exec("INSERT INTO users (id, email, name) VALUES (1, 'a@example.com', 'A')"); err != nil {
+		t.Fatalf("schema was not migrated: %v", err)
+	}
+
+	applied, err = RunMigrations(ctx, db, testMigrations, "migrations")
+	if err != nil {
+		t.Fatalf("second RunMigrations: %v", err)
+	}
+	if applied != 0 {
+		t.Fatalf("expected a re-run to apply nothing, got %d", applied)
+	}
+}
+
+func TestRunMigrations_FailedMigrationIsNotRecorded(t *testing.T) {
+	db := newMigrationDB(t)
+	fsys := fstest.MapFS{
+		"m/001_ok.up.sql":     {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"m/002_broken.up.sql": {Data: []byte("CREATE TABLE b (id INTEGER); NOT SQL;")},
+	}
+
+	applied, err := RunMigrations(context.Background(), db, fsys, "m")
+	if err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+	if applied != 1 {
+		t.Fatalf("expected 1 migration applied before the failure, got %d", applied)
+	}
+	if got := appliedList(t, db); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("unexpected recorded versions %v", got)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	db := newMigrationDB(t)
+	ctx := context.Background()
+
+	if _, err := RunMigrations(ctx, db, testMigrations, "migrations"); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	rolledBack, err := Rollback(ctx, db, testMigrations, "migrations", 1)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if rolledBack != 1 {
+		t.Fatalf("expected 1 rollback, got %d", rolledBack)
+	}
+	if got := appliedList(t, db); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("expected only version 1 left, got %v", got)
+	}
+	if _, err := db.Exec("INSERT INTO users (id, email, name) VALUES (1, 'a', 'A')"); err == nil {
+		t.Fatal("expected the name column to be dropped")
+	}
+
+	// Asking for more steps than applied stops at zero
+	rolledBack, err = Rollback(ctx, db, testMigrations, "migrations", 5)
+	if err != nil || rolledBack != 1 {
+		t.Fatalf("expected 1 more rollback, got %d, %v", rolledBack, err)
+	}
+	if got := appliedList(t, db); len(got) != 0 {
+		t.Fatalf("expected no versions left, got %v", got)
+	}
+}
+
+func TestLoadMigrations_DuplicateVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/001_a.up.sql": {Data: []byte("SELECT 1;")},
+		"m/001_b.up.sql": {Data: []byte("SELECT 1;")},
+	}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Fatal("expected an error for duplicate versions")
+	}
+}
+
+func TestRunMigrations_NilDB(t *testing.T) {
+	if _, err := RunMigrations(context.Background(), nil, testMigrations, "migrations"); err == nil {
+		t.Fatal("expected an error for a nil pool")
+	}
+}
+
+func TestMigrationDialect(t *testing.T) {
+	cases := map[string]Driver{
+		"postgres": PostgresDriver,
+		"mysql":    MySQLDriver,
+		"sqlite3":  "",
+	}
+	for name, want := range cases {
+		db, err := sql.Open(name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := migrationDialect(db); got != want {
+			t.Errorf("%s: got dialect %q, want %q", name, got, want)
+		}
+		db.Close()
+	}
+}
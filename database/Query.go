@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bignyap/go-utilities/server"
+	"github.com/mattn/go-sqlite3"
+)
+
+// DefaultQueryTimeout bounds QueryRows and QueryRow when the Queryer has no
+// configured timeout. Zero disables the deadline.
+var DefaultQueryTimeout = 30 * time.Second
+
+// Queryer is satisfied by *sql.DB, *sql.Tx, *sql.Conn and *Connection
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryTimeouter is implemented by Queryers carrying their own query timeout
+type queryTimeouter interface {
+	QueryTimeout() time.Duration
+}
+
+// QueryRows runs query and scans every row with scan. The query, including
+// row iteration, runs under the Queryer's timeout and failures are returned
+// as *server.InternalError.
+func QueryRows[T any](ctx context.Context, db Queryer, query string, scan func(*sql.Rows) (T, error), args ...any) (results []T, err error) {
+	ctx, finish := startQuerySpan(ctx, db, query)
+	defer func() { finish(len(results), err) }()
+
+	ctx, cancel := withQueryTimeout(ctx, db)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapQueryError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, mapQueryError(err)
+		}
+		results = append(results, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapQueryError(err)
+	}
+	return results, nil
+}
+
+// QueryRow runs query and scans its first row. A query returning no rows
+// fails with a server.ErrorNotFound error.
+func QueryRow[T any](ctx context.Context, db Queryer, query string, scan func(*sql.Rows) (T, error), args ...any) (_ T, err error) {
+	var zero T
+
+	ctx, finish := startQuerySpan(ctx, db, query)
+	defer func() { finish(1, err) }()
+
+	ctx, cancel := withQueryTimeout(ctx, db)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return zero, mapQueryError(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return zero, mapQueryError(err)
+		}
+		return zero, mapQueryError(sql.ErrNoRows)
+	}
+	item, err := scan(rows)
+	if err != nil {
+		return zero, mapQueryError(err)
+	}
+	return item, nil
+}
+
+func withQueryTimeout(ctx context.Context, db Queryer) (context.Context, context.CancelFunc) {
+	timeout := DefaultQueryTimeout
+	if t, ok := db.(queryTimeouter); ok {
+		timeout = t.QueryTimeout()
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// mapQueryError converts driver errors to the matching server error type
+func mapQueryError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return server.NewError(server.ErrorNotFound, "record not found", err)
+	case server.IsUniqueViolation(err) || isSQLiteConstraint(err, sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey):
+		return server.NewError(server.ErrorConflict, "record already exists", err)
+	case server.IsForeignKeyViolation(err) || isSQLiteConstraint(err, sqlite3.ErrConstraintForeignKey):
+		return server.NewError(server.ErrorBadRequest, "referenced record does not exist", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return server.NewError(server.ErrorTimeout, "query timed out", err)
+	default:
+		return server.NewError(server.ErrorInternal, "query failed", err)
+	}
+}
+
+func isSQLiteConstraint(err error, codes ...sqlite3.ErrNoExtended) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	for _, code := range codes {
+		if sqliteErr.ExtendedCode == code {
+			return true
+		}
+	}
+	return false
+}
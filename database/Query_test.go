@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type queryUser struct {
+	ID    int64
+	Email string
+}
+
+func scanQueryUser(rows *sql.Rows) (queryUser, error) {
+	var u queryUser
+	err := rows.Scan(&u.ID, &u.Email)
+	return u, err
+}
+
+func newQueryDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := newMigrationDB(t)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE);
+		INSERT INTO users (id, email) VALUES (1, 'a@example.com'), (2, 'b@example.com'), (3, 'c@example.com');`); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func errorType(err error) (server.ErrorType, bool) {
+	var internal *server.InternalError
+	if !errors.As(err, &internal) {
+		return 0, false
+	}
+	return internal.Type, true
+}
+
+func TestQueryRows_ScansEveryRow(t *testing.T) {
+	db := newQueryDB(t)
+
+	users, err := QueryRows(context.Background(), db, "SELECT id, email FROM users WHERE id >= ? ORDER BY id", scanQueryUser, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0] != (queryUser{2, "b@example.com"}) || users[1] != (queryUser{3, "c@example.com"}) {
+		t.Fatalf("unexpected rows: %+v", users)
+	}
+
+	empty, err := QueryRows(context.Background(), db, "SELECT id, email FROM users WHERE id > 10", scanQueryUser)
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("expected no rows and no error, got %+v, %v", empty, err)
+	}
+}
+
+func TestQueryRow_NotFound(t *testing.T) {
+	db := newQueryDB(t)
+
+	u, err := QueryRow(context.Background(), db, "SELECT id, email FROM users WHERE id = ?", scanQueryUser, 1)
+	if err != nil || u.Email != "a@example.com" {
+		t.Fatalf("expected user 1, got %+v, %v", u, err)
+	}
+
+	_, err = QueryRow(context.Background(), db, "SELECT id, email FROM users WHERE id = ?", scanQueryUser, 42)
+	if typ, ok := errorType(err); !ok || typ != server.ErrorNotFound {
+		t.Fatalf("expected a NotFound error, got %v", err)
+	}
+	if !IsNotFound(err) {
+		t.Fatal("expected the NotFound error to wrap sql.ErrNoRows")
+	}
+}
+
+func TestQueryRow_UniqueViolationIsConflict(t *testing.T) {
+	db := newQueryDB(t)
+
+	_, err := QueryRow(context.Background(), db,
+		"INSERT INTO users (id, email) VALUES (?, ?) RETURNING id, email", scanQueryUser, 4, "a@example.com")
+	if typ, ok := errorType(err); !ok || typ != server.ErrorConflict {
+		t.Fatalf("expected a Conflict error, got %v", err)
+	}
+
+	u, err := QueryRow(context.Background(), db,
+		"INSERT INTO users (id, email) VALUES (?, ?) RETURNING id, email", scanQueryUser, 4, "d@example.com")
+	if err != nil || u != (queryUser{4, "d@example.com"}) {
+		t.Fatalf("expected the insert to succeed, got %+v, %v", u, err)
+	}
+}
+
+func TestQueryRows_AppliesConnectionTimeout(t *testing.T) {
+	conn := &Connection{
+		DB:         newQueryDB(t),
+		PoolConfig: &ConnectionPoolConfig{QueryTimeout: time.Nanosecond},
+	}
+
+	_, err := QueryRows(context.Background(), conn, "SELECT id, email FROM users", scanQueryUser)
+	if typ, ok := errorType(err); !ok || typ != server.ErrorTimeout {
+		t.Fatalf("expected a Timeout error, got %v", err)
+	}
+}
+
+func TestTracedQueryer_RecordsQuerySpans(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	db := NewTracedQueryer(newQueryDB(t), tracerProvider{tp})
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	if _, err := QueryRows(ctx, db, "SELECT id, email FROM users", scanQueryUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := QueryRow(ctx, db, "SELECT id, email FROM users WHERE id = ?", scanQueryUser, 42); err == nil {
+		t.Fatal("expected a NotFound error")
+	}
+	parent.End()
+
+	ended := spans.Ended()
+	if len(ended) != 3 {
+		t.Fatalf("expected two query spans and the parent, got %d", len(ended))
+	}
+	rows, notFound := ended[0], ended[1]
+	for _, s := range []sdktrace.ReadOnlySpan{rows, notFound} {
+		if s.Name() != "db.query" || s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("expected a db.query child span, got %s", s.Name())
+		}
+	}
+	attrs := attribute.NewSet(rows.Attributes()...)
+	if v, _ := attrs.Value(DBStatementKey); v.AsString() != "SELECT id, email FROM users" {
+		t.Fatalf("unexpected statement %q", v.AsString())
+	}
+	if v, _ := attrs.Value(DBRowsKey); v.AsInt64() != 3 {
+		t.Fatalf("expected 3 rows, got %d", v.AsInt64())
+	}
+	if notFound.Status().Code != codes.Error {
+		t.Fatal("expected the failed query to set an error status")
+	}
+}
+
+// tracerProvider is a minimal otel api.Provider backed by an SDK tracer provider
+type tracerProvider struct {
+	tp *sdktrace.TracerProvider
+}
+
+func (p tracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return p.tp.Tracer(name, opts...)
+}
+
+func (p tracerProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return noop.NewMeterProvider().Meter(name, opts...)
+}
+
+func (p tracerProvider) Shutdown(ctx context.Context) error { return p.tp.Shutdown(ctx) }
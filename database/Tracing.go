@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/bignyap/go-utilities/otel/api"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of database spans
+const TracerName = "github.com/bignyap/go-utilities/database"
+
+// Attribute keys set on db.query spans
+const (
+	DBStatementKey = "db.statement"
+	DBRowsKey      = "db.rows"
+)
+
+// TracedQueryer wraps a Queryer so QueryRows and QueryRow record a db.query
+// span carrying the statement and the number of rows returned. Plain
+// QueryContext calls are not traced.
+type TracedQueryer struct {
+	Queryer
+	tracer trace.Tracer
+}
+
+// NewTracedQueryer traces db's QueryRows and QueryRow calls with provider
+func NewTracedQueryer(db Queryer, provider api.Provider) *TracedQueryer {
+	return &TracedQueryer{Queryer: db, tracer: provider.Tracer(TracerName)}
+}
+
+// QueryTimeout returns the wrapped Queryer's timeout
+func (q *TracedQueryer) QueryTimeout() time.Duration {
+	if t, ok := q.Queryer.(queryTimeouter); ok {
+		return t.QueryTimeout()
+	}
+	return DefaultQueryTimeout
+}
+
+// startQuerySpan starts a db.query span when db is a TracedQueryer. The
+// returned func ends it, recording the row count or the error.
+func startQuerySpan(ctx context.Context, db Queryer, query string) (context.Context, func(rows int, err error)) {
+	q, ok := db.(*TracedQueryer)
+	if !ok {
+		return ctx, func(int, error) {}
+	}
+
+	ctx, span := q.tracer.Start(ctx, "db.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String(DBStatementKey, query)),
+	)
+	return ctx, func(rows int, err error) {
+		if err != nil {
+			api.RecordError(ctx, err)
+		} else {
+			span.SetAttributes(attribute.Int(DBRowsKey, rows))
+		}
+		span.End()
+	}
+}
@@ -44,13 +44,15 @@ func (h *SampleHandler) handleError(c *gin.Context) {
 func main() {
 	ctx := context.Background()
 
-	config := server.DefaultConfig(server.ServerHTTP)
-	config.Port = "8080"
-	config.Environment = "dev"
-	config.Version = "1.0.0"
-
 	// Assume you already have your logger
 	logger := &api.DefaultLogger{}
+
+	// Defaults, overlaid by config.yaml if present, then SERVER_* env vars
+	config, err := server.LoadConfig("config.yaml")
+	if err != nil {
+		logger.Error(ctx, "Invalid server config", err)
+		return
+	}
 	handler := NewSampleHandler(logger)
 
 	s := server.NewHTTPServer(
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
+	otelapi "github.com/bignyap/go-utilities/otel/api"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ============================================================================
+// Circuit Breaker State & Metrics
+// ============================================================================
+
+// CircuitState is the state of a client's hystrix circuit
+type CircuitState string
+
+const (
+	CircuitClosed CircuitState = "closed"
+	CircuitOpen   CircuitState = "open"
+	// CircuitHalfOpen means the circuit is open but the sleep window has
+	// passed, so the next request is let through to test the downstream
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitStats are cumulative counters for a circuit breaker command.
+// Counters are shared by every client using the same command name.
+type CircuitStats struct {
+	State         CircuitState
+	Successes     int64
+	Failures      int64
+	Timeouts      int64
+	ShortCircuits int64
+	Rejects       int64
+}
+
+// CircuitState reports whether the client's circuit is closed, open or half-open
+func (c *circuitClient) CircuitState() CircuitState {
+	circuit, _, err := hystrix.GetCircuit(c.command)
+	if err != nil || !circuit.IsOpen() {
+		return CircuitClosed
+	}
+	if time.Since(collectorFor(c.command).lastFailure()) > c.sleepWindow {
+		return CircuitHalfOpen
+	}
+	return CircuitOpen
+}
+
+// CircuitStats returns the circuit state and its counters
+func (c *circuitClient) CircuitStats() CircuitStats {
+	stats := collectorFor(c.command).stats()
+	stats.State = c.CircuitState()
+	return stats
+}
+
+// RegisterCircuitMetrics publishes the circuit state (0 closed, 1 open,
+// 2 half-open) and counters as observable instruments on the provider's meter
+func (c *circuitClient) RegisterCircuitMetrics(provider otelapi.Provider) (metric.Registration, error) {
+	meter := provider.Meter("httpclient")
+
+	state, err := meter.Int64ObservableGauge(
+		"http.client.circuit.state",
+		metric.WithDescription("Circuit breaker state: 0 closed, 1 open, 2 half-open"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	events, err := meter.Int64ObservableCounter(
+		"http.client.circuit.events",
+		metric.WithDescription("Circuit breaker outcomes by type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	command := attribute.String("circuit.command", c.command)
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := c.CircuitStats()
+		o.ObserveInt64(state, stateValue(stats.State), metric.WithAttributes(command))
+		for outcome, n := range map[string]int64{
+			"success":       stats.Successes,
+			"failure":       stats.Failures,
+			"timeout":       stats.Timeouts,
+			"short_circuit": stats.ShortCircuits,
+			"reject":        stats.Rejects,
+		} {
+			o.ObserveInt64(events, n, metric.WithAttributes(command, attribute.String("outcome", outcome)))
+		}
+		return nil
+	}, state, events)
+}
+
+func stateValue(s CircuitState) int64 {
+	switch s {
+	case CircuitOpen:
+		return 1
+	case CircuitHalfOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// circuitCollector accumulates hystrix metric results for one command.
+// hystrix keeps its own counters private, so this is registered alongside
+// the default collector.
+type circuitCollector struct {
+	mu            sync.Mutex
+	successes     int64
+	failures      int64
+	timeouts      int64
+	shortCircuits int64
+	rejects       int64
+	lastFailureAt time.Time
+}
+
+var (
+	registerCollectorsOnce sync.Once
+	collectorsMu           sync.Mutex
+	collectors             = map[string]*circuitCollector{}
+)
+
+// registerCircuitCollectors must run before a command's circuit is created
+func registerCircuitCollectors() {
+	registerCollectorsOnce.Do(func() {
+		metricCollector.Registry.Register(func(name string) metricCollector.MetricCollector {
+			return collectorFor(name)
+		})
+	})
+}
+
+func collectorFor(command string) *circuitCollector {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	cc, ok := collectors[command]
+	if !ok {
+		cc = &circuitCollector{}
+		collectors[command] = cc
+	}
+	return cc
+}
+
+func (cc *circuitCollector) Update(r metricCollector.MetricResult) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.successes += int64(r.Successes)
+	cc.failures += int64(r.Failures)
+	cc.timeouts += int64(r.Timeouts)
+	cc.shortCircuits += int64(r.ShortCircuits)
+	cc.rejects += int64(r.Rejects)
+	if r.Failures > 0 || r.Timeouts > 0 {
+		cc.lastFailureAt = time.Now()
+	}
+}
+
+// Reset is called by hystrix when a circuit is created or flushed
+func (cc *circuitCollector) Reset() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.successes, cc.failures, cc.timeouts, cc.shortCircuits, cc.rejects = 0, 0, 0, 0, 0
+	cc.lastFailureAt = time.Time{}
+}
+
+func (cc *circuitCollector) stats() CircuitStats {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return CircuitStats{
+		Successes:     cc.successes,
+		Failures:      cc.failures,
+		Timeouts:      cc.timeouts,
+		ShortCircuits: cc.shortCircuits,
+		Rejects:       cc.rejects,
+	}
+}
+
+func (cc *circuitCollector) lastFailure() time.Time {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.lastFailureAt
+}
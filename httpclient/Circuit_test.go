@@ -0,0 +1,152 @@
+package httpclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/bignyap/go-utilities/httpclient"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+func failingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// circuitConfig trips after 5 requests at 50% errors. Circuits are global
+// to hystrix, so they are flushed once the test ends.
+func circuitConfig(t *testing.T, command string, sleepWindow int) httpclient.ClientConfig {
+	t.Cleanup(hystrix.Flush)
+	return httpclient.ClientConfig{
+		RetryCount:             1,
+		BackoffInitial:         time.Millisecond,
+		BackoffMax:             2 * time.Millisecond,
+		CircuitBreakerCommand:  command,
+		ErrorPercentThreshold:  50,
+		RequestVolumeThreshold: 5,
+		SleepWindow:            sleepWindow,
+	}
+}
+
+// waitForState polls while hystrix processes metric updates asynchronously
+func waitForState(t *testing.T, client httpclient.Client, fire func(), want httpclient.CircuitState) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if client.CircuitState() == want {
+			return
+		}
+		fire()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("circuit never became %s, stats %+v", want, client.CircuitStats())
+}
+
+func TestCircuitState_OpensAfterRepeatedFailures(t *testing.T) {
+	server := failingServer(t)
+	client := httpclient.NewHystixClient(server.URL, circuitConfig(t, "circuit-open-test", 60000), nil)
+
+	if state := client.CircuitState(); state != httpclient.CircuitClosed {
+		t.Fatalf("expected a closed circuit, got %s", state)
+	}
+
+	fire := func() { _ = client.Get("/fail", nil, nil) }
+	for range 10 {
+		fire()
+	}
+	waitForState(t, client, fire, httpclient.CircuitOpen)
+
+	// Requests are now rejected without reaching the server
+	fire()
+	time.Sleep(50 * time.Millisecond)
+	stats := client.CircuitStats()
+	if stats.State != httpclient.CircuitOpen || stats.Failures == 0 || stats.ShortCircuits == 0 {
+		t.Fatalf("expected failures and short circuits on an open circuit, got %+v", stats)
+	}
+}
+
+func TestCircuitState_HalfOpenAfterSleepWindow(t *testing.T) {
+	server := failingServer(t)
+	client := httpclient.NewHystixClient(server.URL, circuitConfig(t, "circuit-half-open-test", 100), nil)
+
+	fire := func() { _ = client.Get("/fail", nil, nil) }
+	for range 10 {
+		fire()
+	}
+	waitForState(t, client, fire, httpclient.CircuitOpen)
+
+	time.Sleep(150 * time.Millisecond)
+	if state := client.CircuitState(); state != httpclient.CircuitHalfOpen {
+		t.Fatalf("expected a half-open circuit after the sleep window, got %s", state)
+	}
+}
+
+// meterProvider serves meters from an SDK provider
+type meterProvider struct {
+	provider metric.MeterProvider
+}
+
+func (p meterProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return tracenoop.NewTracerProvider().Tracer(name, opts...)
+}
+func (p meterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.provider.Meter(name, opts...)
+}
+func (p meterProvider) Shutdown(context.Context) error { return nil }
+
+func TestRegisterCircuitMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, circuitConfig(t, "circuit-metrics-test", 60000), nil)
+	if err := client.Get("/ok", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := sdkmetric.NewManualReader()
+	reg, err := client.RegisterCircuitMetrics(meterProvider{sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reg.Unregister()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.CircuitStats().Successes == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "http.client.circuit.events" {
+				for _, dp := range sum.DataPoints {
+					if outcome, _ := dp.Attributes.Value("outcome"); outcome.AsString() == "success" && dp.Value != 1 {
+						t.Fatalf("expected 1 success, got %d", dp.Value)
+					}
+				}
+			}
+		}
+	}
+	if !found["http.client.circuit.state"] || !found["http.client.circuit.events"] {
+		t.Fatalf("expected circuit instruments, got %v", found)
+	}
+}
@@ -6,16 +6,20 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,13 +36,19 @@ import (
 
 // Client defines a high-level HTTP client interface with common methods.
 type Client interface {
-	Get(path string, queryParams map[string]string, response any) error
-	Post(path string, data any, response any) error
-	Put(path string, data any, response any) error
-	Delete(path string) error
+	Get(path string, queryParams map[string]string, response any, opts ...RequestOption) error
+	Post(path string, data any, response any, opts ...RequestOption) error
+	Put(path string, data any, response any, opts ...RequestOption) error
+	Delete(path string, opts ...RequestOption) error
+	Head(path string, queryParams map[string]string, opts ...RequestOption) (http.Header, int, error)
+	PostForm(path string, values url.Values, response any, opts ...RequestOption) error
+	PostMultipart(path string, fields map[string]string, files map[string]io.Reader, response any, opts ...RequestOption) error
 	WithOverrideBaseURL(url string) Client
-	DoRequest(method, path string, queryParams map[string]string, requestBody any, responseBody any, headers map[string]string) error
+	DoRequest(method, path string, queryParams map[string]string, requestBody any, responseBody any, headers map[string]string, opts ...RequestOption) error
+	Do(ctx context.Context, req *http.Request, response any) error
 	DownloadToFile(method, path string, queryParams map[string]string, body any, outputDir string, headers []string) (*DownloadFileResponse, error)
+	CircuitState() CircuitState
+	CircuitStats() CircuitStats
 }
 
 // ClientConfig defines configuration for retries, backoff, and circuit breaker.
@@ -54,6 +64,16 @@ type ClientConfig struct {
 	SleepWindow            int
 	RequestVolumeThreshold int
 	TLSClientConfig        TLSClientConfig
+	// RetryIdempotentOnly limits retries to idempotent methods such as GET,
+	// PUT and DELETE, so a POST is never sent twice (default true)
+	RetryIdempotentOnly *bool
+	// ShouldRetry decides whether a response or error is retried (default DefaultShouldRetry)
+	ShouldRetry func(*http.Response, error) bool
+	// DefaultHeaders are sent with every request; per-request headers override them
+	DefaultHeaders map[string]string
+	// ForceJSONDecoding decodes responses as JSON whatever their Content-Type.
+	// By default only JSON or untyped responses are decoded into structs.
+	ForceJSONDecoding bool
 }
 
 // TLSClientConfig supports TLS and mTLS configurations.
@@ -81,8 +101,44 @@ type TLSClientConfig struct {
 // ============================================================================
 
 type circuitClient struct {
-	baseURL string
-	client  *hystrix.Client
+	baseURL        string
+	client         *hystrix.Client
+	forceJSON      bool
+	defaultHeaders map[string]string
+	command        string
+	sleepWindow    time.Duration
+}
+
+// RequestOption customizes a single request
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	ctx     context.Context
+	headers map[string]string
+}
+
+// WithHeader sets a request header, overriding any default
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[key] = value
+	}
+}
+
+// WithBearerToken sets the Authorization header to "Bearer <token>"
+func WithBearerToken(token string) RequestOption {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithContentType sets the request Content-Type (default application/json when there is a body)
+func WithContentType(contentType string) RequestOption {
+	return WithHeader("Content-Type", contentType)
+}
+
+// WithContext attaches ctx to the request for cancellation and trace propagation
+func WithContext(ctx context.Context) RequestOption {
+	return func(o *requestOptions) {
+		o.ctx = ctx
+	}
 }
 
 // DefaultConfig returns a sensible default configuration.
@@ -133,6 +189,12 @@ func (c *ClientConfig) applyDefaults() {
 	if c.RequestVolumeThreshold == 0 {
 		c.RequestVolumeThreshold = defaults.RequestVolumeThreshold
 	}
+	if c.RetryIdempotentOnly == nil {
+		c.RetryIdempotentOnly = Bool(true)
+	}
+	if c.ShouldRetry == nil {
+		c.ShouldRetry = DefaultShouldRetry
+	}
 }
 
 // ============================================================================
@@ -142,6 +204,7 @@ func (c *ClientConfig) applyDefaults() {
 // NewHystixClient creates a Heimdall Hystrix client with retries, backoff, and optional TLS/mTLS.
 func NewHystixClient(baseURL string, config ClientConfig, fallbackFn func(error) error) *circuitClient {
 	config.applyDefaults()
+	registerCircuitCollectors()
 
 	bo := heimdall.NewExponentialBackoff(config.BackoffInitial, config.BackoffMax, 2.0, config.BackoffMax)
 	transport, err := createCustomTransport(config.TLSClientConfig)
@@ -149,13 +212,20 @@ func NewHystixClient(baseURL string, config ClientConfig, fallbackFn func(error)
 		panic(fmt.Errorf("failed to create custom TLS transport: %w", err))
 	}
 
+	// Retries happen in the transport rather than in heimdall so they can be
+	// limited by method and honor Retry-After
 	httpClient := httpclient.NewClient(
 		httpclient.WithHTTPClient(&http.Client{
-			Transport: transport,
-			Timeout:   config.Timeout,
+			Transport: &retryTransport{
+				base:           transport,
+				retryCount:     config.RetryCount,
+				backoff:        bo,
+				maxWait:        config.BackoffMax,
+				idempotentOnly: *config.RetryIdempotentOnly,
+				shouldRetry:    config.ShouldRetry,
+			},
+			Timeout: config.Timeout,
 		}),
-		httpclient.WithRetryCount(config.RetryCount),
-		httpclient.WithRetrier(heimdall.NewRetrier(bo)),
 	)
 
 	hystrixClient := hystrix.NewClient(
@@ -170,8 +240,12 @@ func NewHystixClient(baseURL string, config ClientConfig, fallbackFn func(error)
 	)
 
 	return &circuitClient{
-		baseURL: strings.TrimRight(baseURL, "/"),
-		client:  hystrixClient,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		client:         hystrixClient,
+		forceJSON:      config.ForceJSONDecoding,
+		defaultHeaders: config.DefaultHeaders,
+		command:        config.CircuitBreakerCommand,
+		sleepWindow:    time.Duration(config.SleepWindow) * time.Millisecond,
 	}
 }
 
@@ -235,31 +309,168 @@ func propagateTraceID(req *http.Request) {
 // Request Methods
 // ============================================================================
 
-func (c *circuitClient) Get(path string, queryParams map[string]string, response any) error {
-	return c.DoRequest(http.MethodGet, path, queryParams, nil, response, nil)
+func (c *circuitClient) Get(path string, queryParams map[string]string, response any, opts ...RequestOption) error {
+	return c.DoRequest(http.MethodGet, path, queryParams, nil, response, nil, opts...)
 }
 
-func (c *circuitClient) Post(path string, data any, response any) error {
-	return c.DoRequest(http.MethodPost, path, nil, data, response, nil)
+func (c *circuitClient) Post(path string, data any, response any, opts ...RequestOption) error {
+	return c.DoRequest(http.MethodPost, path, nil, data, response, nil, opts...)
 }
 
-func (c *circuitClient) Put(path string, data any, response any) error {
-	return c.DoRequest(http.MethodPut, path, nil, data, response, nil)
+func (c *circuitClient) Put(path string, data any, response any, opts ...RequestOption) error {
+	return c.DoRequest(http.MethodPut, path, nil, data, response, nil, opts...)
 }
 
-func (c *circuitClient) Delete(path string) error {
-	return c.DoRequest(http.MethodDelete, path, nil, nil, nil, nil)
+func (c *circuitClient) Delete(path string, opts ...RequestOption) error {
+	return c.DoRequest(http.MethodDelete, path, nil, nil, nil, nil, opts...)
+}
+
+// Head sends a HEAD request and returns the response headers and status code.
+// Statuses of 400 and above are also returned as an error, so existence
+// checks can test the status code for 404.
+func (c *circuitClient) Head(path string, queryParams map[string]string, opts ...RequestOption) (http.Header, int, error) {
+	req, err := c.newRequest(http.MethodHead, path, queryParams, nil, nil, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.Header, resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.Header, resp.StatusCode, nil
+}
+
+// Do sends a caller-built request through the circuit breaker, for uncommon
+// methods or when the caller already has an *http.Request. A relative URL is
+// resolved against the base URL, and client default headers are added where
+// the request does not set them. The response is decoded as in DoRequest.
+func (c *circuitClient) Do(ctx context.Context, req *http.Request, response any) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req = req.WithContext(ctx)
+
+	if !req.URL.IsAbs() {
+		u, err := url.Parse(c.BuildURL(req.URL.Path))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		u.RawQuery = req.URL.RawQuery
+		req.URL = u
+		req.Host = u.Host
+	}
+	for k, v := range c.defaultHeaders {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	propagateTraceID(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.handleResponse(resp, response)
+}
+
+// PostForm sends values as an application/x-www-form-urlencoded body
+func (c *circuitClient) PostForm(path string, values url.Values, response any, opts ...RequestOption) error {
+	opts = append([]RequestOption{WithContentType("application/x-www-form-urlencoded")}, opts...)
+	return c.DoRequest(http.MethodPost, path, nil, strings.NewReader(values.Encode()), response, nil, opts...)
+}
+
+// PostMultipart sends fields and files as a multipart/form-data body. Each
+// file is sent under its map key as the form field name, with the base name
+// of its path as the filename when the reader is an *os.File.
+// The body is produced through a pipe rather than assembled up front, but
+// heimdall reads the whole body into memory before sending it.
+func (c *circuitClient) PostMultipart(path string, fields map[string]string, files map[string]io.Reader, response any, opts ...RequestOption) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	opts = append([]RequestOption{WithContentType(mw.FormDataContentType())}, opts...)
+	err := c.DoRequest(http.MethodPost, path, nil, pr, response, nil, opts...)
+	// Unblock the writer if the request ended before reading the whole body
+	pr.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files map[string]io.Reader) error {
+	for _, name := range sortedKeys(fields) {
+		if err := mw.WriteField(name, fields[name]); err != nil {
+			return fmt.Errorf("write form field %s: %w", name, err)
+		}
+	}
+	for _, name := range sortedKeys(files) {
+		filename := name
+		if named, ok := files[name].(interface{ Name() string }); ok {
+			filename = filepath.Base(named.Name())
+		}
+		part, err := mw.CreateFormFile(name, filename)
+		if err != nil {
+			return fmt.Errorf("create form file %s: %w", name, err)
+		}
+		if _, err := io.Copy(part, files[name]); err != nil {
+			return fmt.Errorf("write form file %s: %w", name, err)
+		}
+	}
+	return mw.Close()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (c *circuitClient) WithOverrideBaseURL(baseURL string) Client {
 	return &circuitClient{
-		baseURL: strings.TrimRight(baseURL, "/"),
-		client:  c.client,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		client:         c.client,
+		forceJSON:      c.forceJSON,
+		defaultHeaders: c.defaultHeaders,
+		command:        c.command,
+		sleepWindow:    c.sleepWindow,
 	}
 }
 
 // Core unified request method.
-func (c *circuitClient) DoRequest(method, path string, queryParams map[string]string, requestBody any, responseBody any, headers map[string]string) error {
+// responseBody may be a *[]byte or *string to receive the raw body; any other
+// target is JSON-decoded. 204 and empty responses leave it untouched.
+// Headers are applied in order: client defaults, the headers map, then opts.
+func (c *circuitClient) DoRequest(method, path string, queryParams map[string]string, requestBody any, responseBody any, headers map[string]string, opts ...RequestOption) error {
+	req, err := c.newRequest(method, path, queryParams, requestBody, headers, opts)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.handleResponse(resp, responseBody)
+}
+
+// newRequest builds a request against the base URL with the client's default headers
+func (c *circuitClient) newRequest(method, path string, queryParams map[string]string, requestBody any, headers map[string]string, opts []RequestOption) (*http.Request, error) {
+	ro := requestOptions{headers: map[string]string{}}
+	for _, opt := range opts {
+		opt(&ro)
+	}
+
 	var body io.Reader
 	switch v := requestBody.(type) {
 	case nil:
@@ -268,7 +479,7 @@ func (c *circuitClient) DoRequest(method, path string, queryParams map[string]st
 	default:
 		data, err := json.Marshal(v)
 		if err != nil {
-			return fmt.Errorf("marshal request: %w", err)
+			return nil, fmt.Errorf("marshal request: %w", err)
 		}
 		body = bytes.NewBuffer(data)
 	}
@@ -279,22 +490,47 @@ func (c *circuitClient) DoRequest(method, path string, queryParams map[string]st
 	}
 	finalURL = InjectQueryParams(finalURL, queryParams)
 
-	req, err := http.NewRequest(method, finalURL, body)
+	ctx := ro.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, finalURL, body)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
+	for k, v := range c.defaultHeaders {
+		req.Header.Set(k, v)
+	}
 	if requestBody != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
+	for k, v := range ro.headers {
+		req.Header.Set(k, v)
+	}
+	propagateTraceID(req)
+	return req, nil
+}
+
+// do sends req through the hystrix client
+func (c *circuitClient) do(req *http.Request) (*http.Response, error) {
+	// heimdall keeps retrying a canceled request, so fail fast here
+	if err := req.Context().Err(); err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("http request failed: %w", err)
+		return nil, fmt.Errorf("http request failed: %w", err)
 	}
+	return resp, nil
+}
+
+// handleResponse turns error statuses into errors and decodes the body into responseBody
+func (c *circuitClient) handleResponse(resp *http.Response, responseBody any) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
@@ -303,11 +539,60 @@ func (c *circuitClient) DoRequest(method, path string, queryParams map[string]st
 	}
 
 	if responseBody != nil {
-		return json.NewDecoder(resp.Body).Decode(responseBody)
+		return c.decodeResponse(resp, responseBody)
+	}
+	return nil
+}
+
+// errorBodyPreview is how much of the body decode errors include
+const errorBodyPreview = 256
+
+func (c *circuitClient) decodeResponse(resp *http.Response, target any) error {
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch v := target.(type) {
+	case *[]byte:
+		*v = data
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !c.forceJSON && contentType != "" && !isJSONContentType(contentType) {
+		return fmt.Errorf("unexpected response content type %q: %s", contentType, preview(data))
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("decode response: %w: %s", err, preview(data))
 	}
 	return nil
 }
 
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func preview(data []byte) string {
+	if len(data) > errorBodyPreview {
+		return string(data[:errorBodyPreview]) + "..."
+	}
+	return string(data)
+}
+
 // BuildURL constructs a full URL safely.
 func (c *circuitClient) BuildURL(paths ...string) string {
 	base := strings.TrimRight(c.baseURL, "/")
@@ -1,9 +1,12 @@
 package httpclient_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/bignyap/go-utilities/httpclient"
@@ -44,3 +47,218 @@ func TestPost_Success(t *testing.T) {
 		t.Errorf("expected status ok, got %s", res.Status)
 	}
 }
+
+func TestGet_NoContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{}, nil)
+
+	res := TestResponse{Status: "unchanged"}
+	if err := client.Get("/test", nil, &res); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res.Status != "unchanged" {
+		t.Errorf("expected response to be left untouched, got %s", res.Status)
+	}
+}
+
+func TestGet_PlainTextIntoString(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{}, nil)
+
+	var text string
+	if err := client.Get("/ping", nil, &text); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if text != "pong" {
+		t.Errorf("expected pong, got %q", text)
+	}
+
+	var raw []byte
+	if err := client.Get("/ping", nil, &raw); err != nil || string(raw) != "pong" {
+		t.Fatalf("expected raw body pong, got %q, %v", raw, err)
+	}
+
+	var res TestResponse
+	err := client.Get("/ping", nil, &res)
+	if err == nil || !strings.Contains(err.Error(), "text/plain") {
+		t.Fatalf("expected content type error, got %v", err)
+	}
+}
+
+func TestGet_MalformedJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "ok"`))
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{}, nil)
+
+	var res TestResponse
+	err := client.Get("/test", nil, &res)
+	if err == nil {
+		t.Fatal("expected decode error")
+	}
+	if !strings.Contains(err.Error(), "decode response") || !strings.Contains(err.Error(), `{"status": "ok"`) {
+		t.Fatalf("expected error to include the body, got %v", err)
+	}
+}
+
+func TestGet_ForceJSONDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{ForceJSONDecoding: true}, nil)
+
+	var res TestResponse
+	if err := client.Get("/test", nil, &res); err != nil || res.Status != "ok" {
+		t.Fatalf("expected forced JSON decoding, got %+v, %v", res, err)
+	}
+}
+
+func TestRequestOptions_MergeWithDefaultHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		echoed := map[string]string{}
+		for _, key := range []string{"Authorization", "Content-Type", "X-Tenant", "X-Request-Source"} {
+			echoed[key] = r.Header.Get(key)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(echoed)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{
+		DefaultHeaders: map[string]string{
+			"X-Tenant":         "default-tenant",
+			"X-Request-Source": "billing",
+		},
+	}, nil)
+
+	var echoed map[string]string
+	err := client.Post("/echo", TestMessage{Text: "hello"}, &echoed,
+		httpclient.WithBearerToken("token-123"),
+		httpclient.WithHeader("X-Tenant", "tenant-42"),
+		httpclient.WithContentType("application/merge-patch+json"),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := map[string]string{
+		"Authorization":    "Bearer token-123",
+		"Content-Type":     "application/merge-patch+json",
+		"X-Tenant":         "tenant-42",
+		"X-Request-Source": "billing",
+	}
+	for key, value := range want {
+		if echoed[key] != value {
+			t.Errorf("expected %s=%q, got %q", key, value, echoed[key])
+		}
+	}
+
+	echoed = nil
+	if err := client.Get("/echo", nil, &echoed); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if echoed["X-Tenant"] != "default-tenant" || echoed["Authorization"] != "" {
+		t.Errorf("expected only default headers, got %v", echoed)
+	}
+}
+
+func TestRequestOptions_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{RetryCount: 1}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.Delete("/items/1", httpclient.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if err := client.Delete("/items/1", httpclient.WithContext(context.Background())); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestHead_ReturnsHeadersAndStatus(t *testing.T) {
+	var gotMethod, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotQuery = r.Method, r.URL.RawQuery
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("body is not sent for HEAD"))
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{}, nil)
+
+	header, status, err := client.Head("/objects/a", map[string]string{"version": "2 3"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if gotMethod != http.MethodHead || status != http.StatusOK || header.Get("ETag") != `"v1"` {
+		t.Fatalf("unexpected HEAD result: method %s, status %d, headers %v", gotMethod, status, header)
+	}
+	if want := httpclient.InjectQueryParams("/", map[string]string{"version": "2 3"}); "/?"+gotQuery != want {
+		t.Fatalf("expected query encoded like Get (%s), got %s", want, gotQuery)
+	}
+
+	if _, status, err := client.Head("/missing", nil); err == nil || status != http.StatusNotFound {
+		t.Fatalf("expected a 404 status and error, got %d, %v", status, err)
+	}
+}
+
+func TestDo_CustomMethod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PURGE" || r.URL.Path != "/cache/items" || r.URL.Query().Get("all") != "true" {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(TestResponse{Status: r.Header.Get("X-Default") + "/" + r.Header.Get("X-Custom")})
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{
+		DefaultHeaders: map[string]string{"X-Default": "default", "X-Custom": "overridden"},
+	}, nil)
+
+	req, err := http.NewRequest("PURGE", "/cache/items?all=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Custom", "custom")
+
+	var res TestResponse
+	if err := client.Do(context.Background(), req, &res); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res.Status != "default/custom" {
+		t.Fatalf("expected default headers not to override the request's, got %q", res.Status)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ = http.NewRequest("PURGE", server.URL+"/cache/items", nil)
+	if err := client.Do(ctx, req, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gojek/heimdall"
+)
+
+// ============================================================================
+// Retries
+// ============================================================================
+
+// Bool returns a pointer to v, for optional boolean config fields
+func Bool(v bool) *bool {
+	return &v
+}
+
+// idempotentMethods are safe to retry without duplicating side effects (RFC 9110)
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// DefaultShouldRetry retries transport errors, 429 and 5xx responses
+func DefaultShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryTransport retries requests with backoff, waiting at least as long as
+// a 429/503 Retry-After header asks. A Retry-After longer than maxWait or the
+// request's remaining deadline is not waited out; the response is returned
+// instead. It runs inside the hystrix command, so the circuit breaker timeout
+// covers all attempts.
+type retryTransport struct {
+	base           http.RoundTripper
+	retryCount     int
+	backoff        heimdall.Backoff
+	maxWait        time.Duration
+	idempotentOnly bool
+	shouldRetry    func(*http.Response, error) bool
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.retryCount <= 0 || (t.idempotentOnly && !idempotentMethods[req.Method]) {
+		return t.base.RoundTrip(req)
+	}
+
+	getBody, err := rewindableBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && getBody != nil {
+			attemptReq = req.Clone(req.Context())
+			if attemptReq.Body, err = getBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= t.retryCount || !t.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := t.backoff.Next(attempt)
+		if resp != nil {
+			if after := retryAfter(resp); after > wait {
+				// Retrying sooner than asked would likely be refused again
+				if after > t.waitBudget(req) {
+					return resp, err
+				}
+				wait = after
+			}
+			// Drain so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// waitBudget is the longest a retry may be delayed: maxWait, further limited
+// by the time left before the request's deadline
+func (t *retryTransport) waitBudget(req *http.Request) time.Duration {
+	budget := time.Duration(math.MaxInt64)
+	if t.maxWait > 0 {
+		budget = t.maxWait
+	}
+	if deadline, ok := req.Context().Deadline(); ok {
+		budget = min(budget, time.Until(deadline))
+	}
+	return budget
+}
+
+// rewindableBody returns a function producing a fresh copy of the request
+// body for each attempt, buffering it when the request cannot replay it
+func rewindableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, nil
+}
+
+// retryAfter parses the Retry-After header of 429 and 503 responses,
+// given either in seconds or as an HTTP date
+func retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package httpclient_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/bignyap/go-utilities/httpclient"
+)
+
+func retryConfig(t *testing.T, command string) httpclient.ClientConfig {
+	t.Cleanup(hystrix.Flush)
+	return httpclient.ClientConfig{
+		RetryCount:            2,
+		BackoffInitial:        time.Millisecond,
+		BackoffMax:            2 * time.Millisecond,
+		CircuitBreakerCommand: command,
+	}
+}
+
+// countingServer responds with status to every request and counts them
+func countingServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetry_PostNotRetriedByDefault(t *testing.T) {
+	server, calls := countingServer(t, http.StatusServiceUnavailable)
+	client := httpclient.NewHystixClient(server.URL, retryConfig(t, "retry-post-test"), nil)
+
+	_ = client.Post("/orders", TestMessage{Text: "hello"}, nil)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected POST to be sent once, got %d", n)
+	}
+}
+
+func TestRetry_GetRetried(t *testing.T) {
+	server, calls := countingServer(t, http.StatusServiceUnavailable)
+	client := httpclient.NewHystixClient(server.URL, retryConfig(t, "retry-get-test"), nil)
+
+	_ = client.Get("/orders", nil, nil)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected GET to be attempted 3 times, got %d", n)
+	}
+}
+
+func TestRetry_NonIdempotentOptIn(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg TestMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		bodies = append(bodies, msg.Text)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cfg := retryConfig(t, "retry-post-opt-in-test")
+	cfg.RetryIdempotentOnly = httpclient.Bool(false)
+	client := httpclient.NewHystixClient(server.URL, cfg, nil)
+
+	_ = client.Post("/orders", TestMessage{Text: "hello"}, nil)
+	if len(bodies) != 3 {
+		t.Fatalf("expected POST to be attempted 3 times, got %d", len(bodies))
+	}
+	for _, b := range bodies {
+		if b != "hello" {
+			t.Fatalf("expected the body to be resent on every attempt, got %q", bodies)
+		}
+	}
+}
+
+func TestRetry_ShouldRetryHook(t *testing.T) {
+	server, calls := countingServer(t, http.StatusConflict)
+	cfg := retryConfig(t, "retry-hook-test")
+	cfg.ShouldRetry = func(resp *http.Response, err error) bool {
+		return err == nil && resp.StatusCode == http.StatusConflict
+	}
+	client := httpclient.NewHystixClient(server.URL, cfg, nil)
+
+	_ = client.Get("/orders", nil, nil)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected the hook to trigger retries, got %d attempts", n)
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := retryConfig(t, "retry-after-test")
+	cfg.BackoffMax = 2 * time.Second
+	client := httpclient.NewHystixClient(server.URL, cfg, nil)
+
+	start := time.Now()
+	if err := client.Get("/orders", nil, nil); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected Retry-After to delay the retry by 1s, took %s", elapsed)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestRetry_RetryAfterBeyondBudgetReturnsResponse(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, retryConfig(t, "retry-after-budget-test"), nil)
+
+	start := time.Now()
+	_ = client.Get("/orders", nil, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the response without waiting out Retry-After, took %s", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected a single attempt, got %d", n)
+	}
+}
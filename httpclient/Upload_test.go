@@ -0,0 +1,101 @@
+package httpclient_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/httpclient"
+)
+
+func TestPostForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			http.Error(w, "unexpected content type "+ct, http.StatusBadRequest)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.PostForm.Get("grant_type") + "|" + r.PostForm.Get("scope")))
+	}))
+	defer server.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{}, nil)
+
+	var echoed string
+	err := client.PostForm("/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"read write"}}, &echoed)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if echoed != "client_credentials|read write" {
+		t.Fatalf("unexpected form values %q", echoed)
+	}
+}
+
+func TestPostMultipart(t *testing.T) {
+	type upload struct {
+		Fields map[string]string `json:"fields"`
+		Files  map[string]string `json:"files"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res := upload{Fields: map[string]string{}, Files: map[string]string{}}
+		for name, values := range r.MultipartForm.Value {
+			res.Fields[name] = values[0]
+		}
+		for name, headers := range r.MultipartForm.File {
+			f, _ := headers[0].Open()
+			data, _ := io.ReadAll(f)
+			f.Close()
+			res.Files[name] = headers[0].Filename + ":" + string(data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("id,total\n1,42\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	client := httpclient.NewHystixClient(server.URL, httpclient.ClientConfig{}, nil)
+
+	var res upload
+	err = client.PostMultipart("/upload",
+		map[string]string{"tenant": "t1", "description": "monthly"},
+		map[string]io.Reader{"report": file, "notes": strings.NewReader("plain notes")},
+		&res,
+		httpclient.WithBearerToken("token"),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if res.Fields["tenant"] != "t1" || res.Fields["description"] != "monthly" {
+		t.Fatalf("unexpected fields %v", res.Fields)
+	}
+	if res.Files["report"] != "report.csv:id,total\n1,42\n" {
+		t.Fatalf("unexpected report file %q", res.Files["report"])
+	}
+	if res.Files["notes"] != "notes:plain notes" {
+		t.Fatalf("unexpected notes file %q", res.Files["notes"])
+	}
+}
@@ -0,0 +1,155 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/bignyap/go-utilities/cache"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+const (
+	defaultCertCacheMaxEntries = 100
+	defaultCertCacheTTL        = 1 * time.Hour
+
+	defaultTokenCacheMaxEntries = 1000
+	defaultTokenCacheTTL        = 1 * time.Minute
+)
+
+// jwksCache is a bounded LRU of JWK sets keyed by issuer.
+// Entries expire after ttl; the least recently used issuer is evicted when full.
+type jwksCache struct {
+	entries *cache.TTL[string, jwksEntry]
+}
+
+type jwksEntry struct {
+	set       jwk.Set
+	fetchedAt time.Time
+}
+
+func newJWKSCache(maxEntries int, ttl time.Duration) *jwksCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCertCacheMaxEntries
+	}
+	if ttl <= 0 {
+		ttl = defaultCertCacheTTL
+	}
+	return &jwksCache{
+		entries: cache.NewTTL[string, jwksEntry](ttl, cache.WithMaxEntries(maxEntries)),
+	}
+}
+
+// SetCertCacheLimits replaces the JWKS cache with one holding at most
+// maxEntries issuers, each cached for ttl. Zero values use the defaults
+// (100 issuers, 1 hour). It is safe to call while tokens are being verified.
+func SetCertCacheLimits(maxEntries int, ttl time.Duration) {
+	certCache.Store(newJWKSCache(maxEntries, ttl))
+}
+
+// Get returns the cached set for issuer and when it was fetched
+func (c *jwksCache) Get(issuer string) (jwk.Set, time.Time, bool) {
+	entry, ok := c.entries.Get(issuer)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return entry.set, entry.fetchedAt, true
+}
+
+// Set caches set for issuer, evicting the least recently used issuer if full
+func (c *jwksCache) Set(issuer string, set jwk.Set) {
+	c.entries.Set(issuer, jwksEntry{set: set, fetchedAt: time.Now()})
+}
+
+// Len returns the number of cached issuers
+func (c *jwksCache) Len() int {
+	return c.entries.Len()
+}
+
+// Flush removes all entries
+func (c *jwksCache) Flush() {
+	c.entries.Flush()
+}
+
+// tokenCache is a bounded LRU of verified tokens keyed by a SHA-256 of the
+// raw token. Entries expire after ttl or at the token's own exp, whichever
+// comes first, so an expired token is never served.
+type tokenCache struct {
+	entries *cache.TTL[string, tokenEntry]
+	now     func() time.Time
+}
+
+type tokenEntry struct {
+	claims    jwt.MapClaims
+	expiresAt time.Time
+}
+
+func newTokenCache(maxEntries int, ttl time.Duration) *tokenCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultTokenCacheMaxEntries
+	}
+	if ttl <= 0 {
+		ttl = defaultTokenCacheTTL
+	}
+	return &tokenCache{
+		entries: cache.NewTTL[string, tokenEntry](ttl, cache.WithMaxEntries(maxEntries)),
+		now:     time.Now,
+	}
+}
+
+// SetTokenCacheLimits replaces the verified-token cache with one holding at
+// most maxEntries tokens, each cached for at most ttl. Zero values use the
+// defaults (1000 tokens, 1 minute). It is safe to call while tokens are
+// being verified.
+func SetTokenCacheLimits(maxEntries int, ttl time.Duration) {
+	verifiedTokens.Store(newTokenCache(maxEntries, ttl))
+}
+
+func tokenCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns a copy of the claims cached for token if it has not expired
+func (c *tokenCache) Get(token string) (jwt.MapClaims, bool) {
+	key := tokenCacheKey(token)
+	entry, ok := c.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		c.entries.Delete(key)
+		return nil, false
+	}
+	return copyClaims(entry.claims), true
+}
+
+// Set caches the verified claims for token. Tokens without an exp claim,
+// or that have already expired, are not cached.
+func (c *tokenCache) Set(token string, claims jwt.MapClaims) {
+	expiresAt, ok := TokenExpiry(claims)
+	if !ok || !c.now().Before(expiresAt) {
+		return
+	}
+	c.entries.Set(tokenCacheKey(token), tokenEntry{claims: copyClaims(claims), expiresAt: expiresAt})
+}
+
+// Len returns the number of cached tokens
+func (c *tokenCache) Len() int {
+	return c.entries.Len()
+}
+
+// Flush removes all entries
+func (c *tokenCache) Flush() {
+	c.entries.Flush()
+}
+
+// copyClaims keeps callers from mutating cached claims
+func copyClaims(claims jwt.MapClaims) jwt.MapClaims {
+	out := make(jwt.MapClaims, len(claims))
+	for k, v := range claims {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,272 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+func TestJWKSCache_EvictsLeastRecentlyUsedIssuer(t *testing.T) {
+	c := newJWKSCache(2, time.Hour)
+
+	c.Set("issuer-a", jwk.NewSet())
+	c.Set("issuer-b", jwk.NewSet())
+
+	// Keep issuer-a active so issuer-b becomes the oldest
+	if _, _, ok := c.Get("issuer-a"); !ok {
+		t.Fatal("expected issuer-a to be cached")
+	}
+
+	c.Set("issuer-c", jwk.NewSet())
+
+	if c.Len() != 2 {
+		t.Fatalf("expected cache size 2, got %d", c.Len())
+	}
+	if _, _, ok := c.Get("issuer-b"); ok {
+		t.Fatal("expected issuer-b to be evicted")
+	}
+	if _, _, ok := c.Get("issuer-a"); !ok {
+		t.Fatal("expected active issuer-a to stay cached")
+	}
+	if _, _, ok := c.Get("issuer-c"); !ok {
+		t.Fatal("expected issuer-c to be cached")
+	}
+}
+
+func TestJWKSCache_FillPastCap(t *testing.T) {
+	c := newJWKSCache(3, time.Hour)
+	issuers := []string{"i1", "i2", "i3", "i4", "i5"}
+	for _, iss := range issuers {
+		c.Set(iss, jwk.NewSet())
+	}
+
+	if c.Len() != 3 {
+		t.Fatalf("expected cache size 3, got %d", c.Len())
+	}
+	for _, iss := range issuers[:2] {
+		if _, _, ok := c.Get(iss); ok {
+			t.Errorf("expected %s to be evicted", iss)
+		}
+	}
+	for _, iss := range issuers[2:] {
+		if _, _, ok := c.Get(iss); !ok {
+			t.Errorf("expected %s to be cached", iss)
+		}
+	}
+}
+
+func TestJWKSCache_TTLExpiry(t *testing.T) {
+	c := newJWKSCache(10, 20*time.Millisecond)
+	c.Set("issuer-a", jwk.NewSet())
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, _, ok := c.Get("issuer-a"); ok {
+		t.Fatal("expected expired entry to be dropped")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected expired entry to be removed, got size %d", c.Len())
+	}
+}
+
+func TestSetCertCacheLimits(t *testing.T) {
+	original := certCache.Load()
+	defer certCache.Store(original)
+
+	SetCertCacheLimits(1, time.Minute)
+	certCache.Load().Set("issuer-a", jwk.NewSet())
+	certCache.Load().Set("issuer-b", jwk.NewSet())
+
+	if certCache.Load().Len() != 1 {
+		t.Fatalf("expected cache size 1, got %d", certCache.Load().Len())
+	}
+}
+
+func TestSetCertCacheLimits_ConcurrentWithLookups(t *testing.T) {
+	original := certCache.Load()
+	defer certCache.Store(original)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				certCache.Load().Set("issuer", jwk.NewSet())
+				certCache.Load().Get("issuer")
+			}
+		}()
+	}
+	for range 100 {
+		SetCertCacheLimits(10, time.Minute)
+	}
+	wg.Wait()
+}
+
+func TestSetTokenCacheLimits_ConcurrentWithLookups(t *testing.T) {
+	original := verifiedTokens.Load()
+	defer verifiedTokens.Store(original)
+
+	claims := jwtlib.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				verifiedTokens.Load().Set("token", claims)
+				verifiedTokens.Load().Get("token")
+			}
+		}()
+	}
+	for range 100 {
+		SetTokenCacheLimits(10, time.Minute)
+	}
+	wg.Wait()
+}
+
+func TestParseAndVerifyJWT_RefreshesOnUnknownKID(t *testing.T) {
+	certCache.Load().Flush()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pubJWK, _ := jwk.New(&priv.PublicKey)
+	_ = pubJWK.Set(jwk.KeyIDKey, "rotated")
+	set := jwk.NewSet()
+	set.Add(pubJWK)
+	jwksJSON, _ := json.Marshal(set)
+
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwksJSON)
+	}))
+	defer srv.Close()
+
+	issuer := srv.URL + "/realms/dev"
+	t.Setenv("AUTH_URL", srv.URL)
+
+	// Seed the cache with a stale set that predates the key rotation
+	certCache.Load().entries.Set(issuer, jwksEntry{set: jwk.NewSet(), fetchedAt: time.Now().Add(-time.Minute)})
+
+	claims := jwtlib.MapClaims{"iss": issuer, "aud": "api", "exp": time.Now().Add(time.Hour).Unix()}
+	tok := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, claims)
+	tok.Header["kid"] = "rotated"
+	signed, _ := tok.SignedString(priv)
+
+	if _, err := ParseAndVerifyJWT(signed); err != nil {
+		t.Fatalf("expected token to verify after refresh, got: %v", err)
+	}
+	if fetches.Load() != 1 {
+		t.Fatalf("expected exactly one JWKS refetch, got %d", fetches.Load())
+	}
+}
+
+func TestParseAndVerifyJWT_CachesVerifiedToken(t *testing.T) {
+	certCache.Load().Flush()
+	verifiedTokens.Load().Flush()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pubJWK, _ := jwk.New(&priv.PublicKey)
+	_ = pubJWK.Set(jwk.KeyIDKey, "kid1")
+	set := jwk.NewSet()
+	set.Add(pubJWK)
+	jwksJSON, _ := json.Marshal(set)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwksJSON)
+	}))
+	defer srv.Close()
+
+	issuer := srv.URL + "/realms/dev"
+	t.Setenv("AUTH_URL", srv.URL)
+
+	claims := jwtlib.MapClaims{"iss": issuer, "aud": "api", "exp": time.Now().Add(time.Hour).Unix()}
+	tok := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, claims)
+	tok.Header["kid"] = "kid1"
+	signed, _ := tok.SignedString(priv)
+
+	if _, err := ParseAndVerifyJWT(signed); err != nil {
+		t.Fatalf("unexpected error verifying token: %v", err)
+	}
+
+	// With the keys gone, only the token cache can verify the second call
+	srv.Close()
+	certCache.Load().Flush()
+
+	got, err := ParseAndVerifyJWT(signed)
+	if err != nil {
+		t.Fatalf("expected the second verification to hit the cache, got: %v", err)
+	}
+	if got["realm"] != "dev" {
+		t.Fatalf("expected cached claims to include realm=dev, got %v", got["realm"])
+	}
+}
+
+func TestTokenCache_RejectsExpiredEntry(t *testing.T) {
+	now := time.Now()
+	c := newTokenCache(10, time.Hour)
+	c.now = func() time.Time { return now }
+
+	c.Set("token", jwtlib.MapClaims{"sub": "user-123", "exp": float64(now.Add(time.Minute).Unix())})
+	if _, ok := c.Get("token"); !ok {
+		t.Fatal("expected token to be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("token"); ok {
+		t.Fatal("expected expired token not to be served")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected expired entry to be removed, got size %d", c.Len())
+	}
+
+	c.Set("expired", jwtlib.MapClaims{"exp": float64(now.Add(-time.Second).Unix())})
+	c.Set("no-exp", jwtlib.MapClaims{"sub": "user-123"})
+	if c.Len() != 0 {
+		t.Fatalf("expected expired and exp-less tokens not to be cached, got size %d", c.Len())
+	}
+}
+
+func TestTokenCache_ReturnsCopy(t *testing.T) {
+	c := newTokenCache(10, time.Hour)
+	c.Set("token", jwtlib.MapClaims{"sub": "user-123", "exp": float64(time.Now().Add(time.Minute).Unix())})
+
+	claims, _ := c.Get("token")
+	claims["sub"] = "someone-else"
+
+	if again, _ := c.Get("token"); again["sub"] != "user-123" {
+		t.Fatalf("expected cached claims to be unaffected, got %v", again["sub"])
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	got, ok := TokenExpiry(map[string]interface{}{"exp": float64(exp.Unix())})
+	if !ok || !got.Equal(exp) {
+		t.Fatalf("expected expiry %v, got %v (ok=%v)", exp, got, ok)
+	}
+	if _, ok := TokenExpiry(map[string]interface{}{"sub": "user-123"}); ok {
+		t.Fatal("expected no expiry without an exp claim")
+	}
+	if _, ok := TokenExpiry(map[string]interface{}{"exp": "tomorrow"}); ok {
+		t.Fatal("expected no expiry for a malformed exp claim")
+	}
+}
@@ -9,26 +9,62 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/bignyap/go-utilities/httpclient"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/lestrrat-go/jwx/jwk"
-	"github.com/patrickmn/go-cache"
 )
 
-var certCache *cache.Cache
+// jwksRefreshCooldown limits how often an unknown kid can force a JWKS refetch per issuer
+const jwksRefreshCooldown = 30 * time.Second
 
-func init() {
-	// Initialize the cache with a default expiration time of 1 hour, and cleanup interval of 30 minutes
-	certCache = cache.New(1*time.Hour, 30*time.Minute)
+// certCache is read by concurrent verifications while SetCertCacheLimits may
+// swap it, so it is held in an atomic pointer
+var certCache = storedPointer(newJWKSCache(defaultCertCacheMaxEntries, defaultCertCacheTTL))
+
+// storedPointer returns an atomic pointer holding v
+func storedPointer[T any](v *T) *atomic.Pointer[T] {
+	p := new(atomic.Pointer[T])
+	p.Store(v)
+	return p
 }
 
+// verifiedTokens is swapped by SetTokenCacheLimits in the same way
+var verifiedTokens = storedPointer(newTokenCache(defaultTokenCacheMaxEntries, defaultTokenCacheTTL))
+
 func getJWKSet(issuer string) (jwk.Set, error) {
-	if jwks, found := certCache.Get(issuer); found {
-		return jwks.(jwk.Set), nil
+	if jwks, _, found := certCache.Load().Get(issuer); found {
+		return jwks, nil
 	}
 
+	jwks, err := fetchJWKSet(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the jwks for future use
+	certCache.Load().Set(issuer, jwks)
+	return jwks, nil
+}
+
+// refreshJWKSet refetches the issuer's keys, e.g. after a key rotation.
+// It returns false if the cached set is too recent to refetch or the fetch fails.
+func refreshJWKSet(issuer string) (jwk.Set, bool) {
+	if _, fetchedAt, found := certCache.Load().Get(issuer); found && time.Since(fetchedAt) < jwksRefreshCooldown {
+		return nil, false
+	}
+
+	jwks, err := fetchJWKSet(issuer)
+	if err != nil {
+		return nil, false
+	}
+	certCache.Load().Set(issuer, jwks)
+	return jwks, true
+}
+
+func fetchJWKSet(issuer string) (jwk.Set, error) {
 	// Build the JWKS endpoint URL
 	certEndpoint, err := url.JoinPath(issuer, "protocol/openid-connect/certs")
 	if err != nil {
@@ -78,9 +114,6 @@ func getJWKSet(issuer string) (jwk.Set, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
 	}
-
-	// Cache the jwks for future use
-	certCache.Set(issuer, jwks, cache.DefaultExpiration)
 	return jwks, nil
 }
 
@@ -105,8 +138,15 @@ func extractRealmFromPath(path string) (string, error) {
 	return "", fmt.Errorf("issuer URL path does not contain 'realms' segment")
 }
 
+// ParseAndVerifyJWT verifies tokenString against its issuer's JWKS and
+// returns its claims. Verified tokens are cached until their exp (bounded by
+// the token cache TTL), so repeated calls with the same token are cheap.
 func ParseAndVerifyJWT(tokenString string) (jwt.MapClaims, error) {
 
+	if claims, ok := verifiedTokens.Load().Get(tokenString); ok {
+		return claims, nil
+	}
+
 	// Step 1: Parse the JWT token without verifying the signature
 	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
 	if err != nil {
@@ -166,6 +206,12 @@ func ParseAndVerifyJWT(tokenString string) (jwt.MapClaims, error) {
 
 	// Find the corresponding key
 	key, found := jwks.LookupKeyID(kid)
+	if !found {
+		// The issuer may have rotated its keys since the set was cached
+		if refreshed, ok := refreshJWKSet(issuer); ok {
+			key, found = refreshed.LookupKeyID(kid)
+		}
+	}
 	if !found {
 		return jwt.MapClaims{}, fmt.Errorf("key ID not found in the certificate endpoint")
 	}
@@ -202,9 +248,20 @@ func ParseAndVerifyJWT(tokenString string) (jwt.MapClaims, error) {
 		return jwt.MapClaims{}, fmt.Errorf("invalid token")
 	}
 
+	verifiedTokens.Load().Set(tokenString, claims)
 	return claims, nil
 }
 
+// TokenExpiry returns the time in the claims' exp, if present and valid,
+// so callers can decide when to refresh a token
+func TokenExpiry(claims map[string]interface{}) (time.Time, bool) {
+	exp, err := jwt.MapClaims(claims).GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}, false
+	}
+	return exp.Time, true
+}
+
 func ExtractToken(r *http.Request) (string, error) {
 
 	// Extract the token from header
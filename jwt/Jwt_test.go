@@ -69,9 +69,7 @@ func TestExtractToken(t *testing.T) {
 
 func TestParseAndVerifyJWT_Success(t *testing.T) {
 	// fresh cache per test
-	if certCache != nil {
-		certCache.Flush()
-	}
+	certCache.Load().Flush()
 
 	// RSA keypair
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -139,9 +137,7 @@ func TestParseAndVerifyJWT_Success(t *testing.T) {
 }
 
 func TestParseAndVerifyJWT_UnknownKID(t *testing.T) {
-	if certCache != nil {
-		certCache.Flush()
-	}
+	certCache.Load().Flush()
 
 	// RSA keypair
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -182,9 +178,7 @@ func TestParseAndVerifyJWT_UnknownKID(t *testing.T) {
 }
 
 func TestParseAndVerifyJWT_InvalidIssuerHost(t *testing.T) {
-	if certCache != nil {
-		certCache.Flush()
-	}
+	certCache.Load().Flush()
 
 	// Minimal server that returns empty JWKS (won't be reached if host check fails)
 	srv := httptest.NewServer(http.NotFoundHandler())
@@ -208,9 +202,7 @@ func TestParseAndVerifyJWT_InvalidIssuerHost(t *testing.T) {
 }
 
 func TestParseAndVerifyJWT_AudienceOK(t *testing.T) {
-	if certCache != nil {
-		certCache.Flush()
-	}
+	certCache.Load().Flush()
 
 	// RSA keypair
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -263,9 +255,7 @@ func TestParseAndVerifyJWT_AudienceOK(t *testing.T) {
 }
 
 func TestParseAndVerifyJWT_AudienceMismatch(t *testing.T) {
-	if certCache != nil {
-		certCache.Flush()
-	}
+	certCache.Load().Flush()
 
 	// RSA keypair
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
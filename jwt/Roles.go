@@ -0,0 +1,64 @@
+package jwt
+
+import (
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AllRoles aggregates Keycloak realm and client roles into one deduplicated
+// list. Realm roles are prefixed "realm:" and client roles
+// "client:<client>:", e.g. "realm:admin" and "client:account:view". Realm
+// roles come first, then clients in name order. Missing or malformed
+// sections are skipped.
+func AllRoles(claims jwt.MapClaims) []string {
+	roles := []string{}
+	seen := map[string]bool{}
+	add := func(role string) {
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+
+	if realm, ok := claims["realm_access"].(map[string]interface{}); ok {
+		for _, role := range stringRoles(realm) {
+			add("realm:" + role)
+		}
+	}
+
+	if resources, ok := claims["resource_access"].(map[string]interface{}); ok {
+		clients := make([]string, 0, len(resources))
+		for client := range resources {
+			clients = append(clients, client)
+		}
+		sort.Strings(clients)
+
+		for _, client := range clients {
+			access, ok := resources[client].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, role := range stringRoles(access) {
+				add("client:" + client + ":" + role)
+			}
+		}
+	}
+
+	return roles
+}
+
+// stringRoles returns the non-empty string entries of an access section's "roles" array
+func stringRoles(access map[string]interface{}) []string {
+	raw, ok := access["roles"].([]interface{})
+	if !ok {
+		return nil
+	}
+	roles := make([]string, 0, len(raw))
+	for _, r := range raw {
+		if role, ok := r.(string); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,76 @@
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+// keycloakPayload is the role-related part of a typical Keycloak access token
+const keycloakPayload = `{
+	"iss": "https://auth.example.com/realms/dev",
+	"aud": "account",
+	"realm_access": {
+		"roles": ["offline_access", "admin", "uma_authorization", "admin"]
+	},
+	"resource_access": {
+		"account": {
+			"roles": ["manage-account", "view-profile", "view"]
+		},
+		"billing-api": {
+			"roles": ["invoices:read"]
+		}
+	}
+}`
+
+func TestAllRoles_Keycloak(t *testing.T) {
+	var claims jwtlib.MapClaims
+	if err := json.Unmarshal([]byte(keycloakPayload), &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"realm:offline_access",
+		"realm:admin",
+		"realm:uma_authorization",
+		"client:account:manage-account",
+		"client:account:view-profile",
+		"client:account:view",
+		"client:billing-api:invoices:read",
+	}
+	if got := AllRoles(claims); !reflect.DeepEqual(got, want) {
+		t.Fatalf("AllRoles() = %v, want %v", got, want)
+	}
+}
+
+func TestAllRoles_MissingOrMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims string
+		want   []string
+	}{
+		{"no role sections", `{"sub": "user"}`, []string{}},
+		{"realm_access not an object", `{"realm_access": "admin"}`, []string{}},
+		{"roles not an array", `{"realm_access": {"roles": "admin"}}`, []string{}},
+		{"non-string roles skipped", `{"realm_access": {"roles": ["admin", 7, null, ""]}}`, []string{"realm:admin"}},
+		{
+			"malformed client skipped",
+			`{"resource_access": {"broken": ["x"], "account": {"roles": ["view"]}, "empty": {}}}`,
+			[]string{"client:account:view"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwtlib.MapClaims
+			if err := json.Unmarshal([]byte(tt.claims), &claims); err != nil {
+				t.Fatal(err)
+			}
+			if got := AllRoles(claims); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("AllRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/bignyap/go-utilities/server"
+)
+
+// ++++++++++++++++++    ASYNC PRODUCER   +++++++++++++++++++++
+
+// AsyncHandlers receive delivery results from an AsyncProducer.
+// They are called from a background goroutine and must not block for long.
+type AsyncHandlers struct {
+	OnSuccess func(msg *sarama.ProducerMessage)
+	OnError   func(msg *sarama.ProducerMessage, err error)
+}
+
+// AsyncProducer sends messages without waiting for broker acknowledgement,
+// letting sarama batch them. Delivery results are reported to AsyncHandlers.
+type AsyncProducer struct {
+	producer   sarama.AsyncProducer
+	topic      string
+	handlers   AsyncHandlers
+	serializer Serializer
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Ensure AsyncProducer implements Producer
+var _ Producer = (*AsyncProducer)(nil)
+
+// NewAsyncProducer creates an asynchronous producer for the configured provider
+func NewAsyncProducer(cfg *BrokerConfig, opts *BaseProducerOptions, handlers AsyncHandlers) (*AsyncProducer, error) {
+	var (
+		config     *sarama.Config
+		brokerSasl string
+	)
+	switch c := cfg.Config.(type) {
+	case *LocalConfig:
+		config = NewLocalProducerConfig(c, opts)
+		brokerSasl = c.BrokerSasl
+	case *AWSConfig:
+		config = newAWSProducerConfig(c, opts)
+		brokerSasl = c.BrokerSasl
+	default:
+		return nil, server.NewError(
+			server.ErrorInternal,
+			fmt.Sprintf("unsupported broker provider: %s", cfg.Provider),
+			nil,
+		)
+	}
+
+	prod, err := sarama.NewAsyncProducer(getBrokerAddresses(brokerSasl), config)
+	if err != nil {
+		return nil, server.NewError(server.ErrorInternal, "failed to create async producer", err)
+	}
+	return newAsyncProducer(prod, cfg.Config.GetTopic(), handlers), nil
+}
+
+func newAsyncProducer(prod sarama.AsyncProducer, topic string, handlers AsyncHandlers) *AsyncProducer {
+	ap := &AsyncProducer{
+		producer: prod,
+		topic:    topic,
+		handlers: handlers,
+	}
+
+	// sarama blocks unless the result channels are drained
+	ap.wg.Add(2)
+	go func() {
+		defer ap.wg.Done()
+		for msg := range prod.Successes() {
+			if ap.handlers.OnSuccess != nil {
+				ap.handlers.OnSuccess(msg)
+			}
+		}
+	}()
+	go func() {
+		defer ap.wg.Done()
+		for perr := range prod.Errors() {
+			if ap.handlers.OnError != nil {
+				ap.handlers.OnError(perr.Msg, perr.Err)
+			} else {
+				fmt.Printf("Async producer error: %v\n", perr.Err)
+			}
+		}
+	}()
+	return ap
+}
+
+func (ap *AsyncProducer) Init() error { return nil }
+
+// SetSerializer sets how message values are encoded (default JSONSerializer)
+func (ap *AsyncProducer) SetSerializer(s Serializer) {
+	ap.serializer = s
+}
+
+// SendMessage serializes msg and enqueues it for delivery
+func (ap *AsyncProducer) SendMessage(msg interface{}) error {
+	return ap.SendMessageWithKey("", msg, nil)
+}
+
+// SendMessageContext serializes msg and enqueues it, giving up if ctx is done
+// before the producer accepts it
+func (ap *AsyncProducer) SendMessageContext(ctx context.Context, msg interface{}) error {
+	pm, err := buildMessage(ap.serializer, ap.topic, "", msg, nil)
+	if err != nil {
+		return err
+	}
+	return ap.enqueue(ctx, pm)
+}
+
+// SendMessageWithKey serializes msg and enqueues it with the given key and headers
+func (ap *AsyncProducer) SendMessageWithKey(key string, msg interface{}, headers map[string]string) error {
+	pm, err := buildMessage(ap.serializer, ap.topic, key, msg, headers)
+	if err != nil {
+		return err
+	}
+	return ap.SendRaw(pm)
+}
+
+// SendRaw enqueues a prepared message, defaulting its topic to the producer's topic.
+// A nil error means the message was accepted, not that it was delivered.
+func (ap *AsyncProducer) SendRaw(msg *sarama.ProducerMessage) error {
+	return ap.enqueue(context.Background(), msg)
+}
+
+func (ap *AsyncProducer) enqueue(ctx context.Context, msg *sarama.ProducerMessage) error {
+	if msg.Topic == "" {
+		msg.Topic = ap.topic
+	}
+
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+	if ap.closed {
+		return server.NewError(server.ErrorInternal, "async producer is closed", nil)
+	}
+	select {
+	case ap.producer.Input() <- msg:
+		return nil
+	case <-ctx.Done():
+		return contextError(ctx.Err())
+	}
+}
+
+// Close flushes outstanding messages, waits for their results to be
+// delivered to the handlers, and shuts the producer down
+func (ap *AsyncProducer) Close() error {
+	ap.mu.Lock()
+	if ap.closed {
+		ap.mu.Unlock()
+		return nil
+	}
+	ap.closed = true
+	ap.mu.Unlock()
+
+	ap.producer.AsyncClose()
+	ap.wg.Wait()
+	return nil
+}
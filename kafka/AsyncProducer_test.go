@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+func newMockAsyncConfig() *sarama.Config {
+	config := mocks.NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+	return config
+}
+
+func TestAsyncProducer_DeliversAllMessages(t *testing.T) {
+	const total = 50
+	mock := mocks.NewAsyncProducer(t, newMockAsyncConfig())
+	for i := 0; i < total; i++ {
+		mock.ExpectInputAndSucceed()
+	}
+
+	var (
+		mu        sync.Mutex
+		delivered []string
+	)
+	ap := newAsyncProducer(mock, "orders", AsyncHandlers{
+		OnSuccess: func(msg *sarama.ProducerMessage) {
+			key, _ := msg.Key.Encode()
+			mu.Lock()
+			delivered = append(delivered, string(key))
+			mu.Unlock()
+		},
+		OnError: func(msg *sarama.ProducerMessage, err error) {
+			t.Errorf("unexpected delivery error: %v", err)
+		},
+	})
+
+	for i := 0; i < total; i++ {
+		if err := ap.SendMessageWithKey("order", map[string]int{"n": i}, nil); err != nil {
+			t.Fatalf("send %d failed: %v", i, err)
+		}
+	}
+	if err := ap.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if len(delivered) != total {
+		t.Fatalf("expected %d delivered messages, got %d", total, len(delivered))
+	}
+	if err := ap.SendMessage("late"); err == nil {
+		t.Fatal("expected send after Close to fail")
+	}
+}
+
+func TestAsyncProducer_ErrorsReachCallback(t *testing.T) {
+	mock := mocks.NewAsyncProducer(t, newMockAsyncConfig())
+	mock.ExpectInputAndSucceed()
+	mock.ExpectInputAndFail(sarama.ErrOutOfBrokers)
+
+	var (
+		mu     sync.Mutex
+		failed []error
+	)
+	ap := newAsyncProducer(mock, "orders", AsyncHandlers{
+		OnError: func(msg *sarama.ProducerMessage, err error) {
+			mu.Lock()
+			failed = append(failed, err)
+			mu.Unlock()
+		},
+	})
+
+	_ = ap.SendMessage("ok")
+	_ = ap.SendMessage("fails")
+	_ = ap.Close()
+
+	if len(failed) != 1 || !errors.Is(failed[0], sarama.ErrOutOfBrokers) {
+		t.Fatalf("expected one ErrOutOfBrokers error, got %v", failed)
+	}
+}
+
+func BenchmarkProducer_Sync(b *testing.B) {
+	mock := mocks.NewSyncProducer(b, nil)
+	for i := 0; i < b.N; i++ {
+		mock.ExpectSendMessageAndSucceed()
+	}
+	bp := &BaseProducer{producer: mock, topic: "bench"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := bp.SendMessage(i); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	_ = mock.Close()
+}
+
+func BenchmarkProducer_Async(b *testing.B) {
+	mock := mocks.NewAsyncProducer(b, newMockAsyncConfig())
+	for i := 0; i < b.N; i++ {
+		mock.ExpectInputAndSucceed()
+	}
+	ap := newAsyncProducer(mock, "bench", AsyncHandlers{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ap.SendMessage(i); err != nil {
+			b.Fatal(err)
+		}
+	}
+	_ = ap.Close()
+}
@@ -1,9 +1,15 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/bignyap/go-utilities/server"
@@ -37,23 +43,292 @@ func (tq *TopicQueue) SendMessage(payload interface{}) error {
 	return nil
 }
 
+// buildMessage serializes payload for topic, falling back to JSON when s is nil
+func buildMessage(s Serializer, topic, key string, payload interface{}, headers map[string]string) (*sarama.ProducerMessage, error) {
+	if s == nil {
+		s = JSONSerializer{}
+	}
+	data, err := s.Serialize(topic, payload)
+	if err != nil {
+		return nil, server.NewError(server.ErrorInternal, "failed to serialize message", err)
+	}
+	pm := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(data),
+	}
+	if key != "" {
+		pm.Key = sarama.StringEncoder(key)
+	}
+	for k, v := range headers {
+		pm.Headers = append(pm.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return pm, nil
+}
+
+// contextError converts the error of a done context into a send error
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return server.NewError(server.ErrorTimeout, "timed out sending message", err)
+	}
+	return server.NewError(server.ErrorInternal, "message send canceled", err)
+}
+
 func getBrokerAddresses(brokerSasl string) []string {
 	return strings.Split(brokerSasl, ",")
 }
 
 type consumerGroupHandler struct {
-	handler HandlerFunc
+	handler      HandlerFunc
+	filter       MessageFilter
+	policy       ErrorPolicy
+	deadLetter   *DeadLetterConfig
+	manualCommit bool
+	// startOffset is applied once, on the first session after Start
+	startOffset *int64
+	metrics     MetricsHandler
+	// redeliveries counts failed sessions per message
+	redeliveries *redeliveryTracker
+	// failed is set when a claim ends on a message that could not be handled
+	failed atomic.Bool
+}
+
+func (h *consumerGroupHandler) Setup(sess sarama.ConsumerGroupSession) error {
+	if h.startOffset == nil {
+		return nil
+	}
+	offset := *h.startOffset
+	for topic, partitions := range sess.Claims() {
+		for _, partition := range partitions {
+			// ResetOffset only moves backwards and MarkOffset only forwards
+			sess.ResetOffset(topic, partition, offset, "")
+			sess.MarkOffset(topic, partition, offset, "")
+		}
+	}
+	h.startOffset = nil
+	return nil
+}
+
+// Cleanup runs when the session ends (rebalance or shutdown) and flushes marked offsets
+func (h *consumerGroupHandler) Cleanup(sess sarama.ConsumerGroupSession) error {
+	if h.manualCommit {
+		sess.Commit()
+	}
+	return nil
 }
 
-func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
-func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
 func (h *consumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for msg := range claim.Messages() {
-		err := h.handler(msg)
+		if h.filter != nil && !h.filter(msg) {
+			h.markConsumed(sess, claim, msg)
+			continue
+		}
+
+		start := time.Now()
+		err := h.processMessage(sess.Context(), msg)
+		if h.metrics != nil {
+			h.metrics(MessageMetrics{
+				Topic:     msg.Topic,
+				Partition: msg.Partition,
+				Offset:    msg.Offset,
+				Duration:  time.Since(start),
+				Err:       err,
+			})
+		}
+		if err != nil && sess.Context().Err() == nil && h.redeliveries.exhausted(msg) {
+			err = h.giveUp(msg, err)
+		}
 		if err != nil {
-			fmt.Printf("Handler error: %v\n", err)
+			// Leave the message unmarked so it is redelivered after the rebalance
+			h.failed.Store(true)
+			return err
 		}
-		sess.MarkMessage(msg, "")
+		h.redeliveries.clear(msg)
+		h.markConsumed(sess, claim, msg)
 	}
 	return nil
 }
+
+// markConsumed marks msg and, with manual commit, commits once caught up
+// rather than after every message
+func (h *consumerGroupHandler) markConsumed(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, msg *sarama.ConsumerMessage) {
+	sess.MarkMessage(msg, "")
+	if h.manualCommit && len(claim.Messages()) == 0 {
+		sess.Commit()
+	}
+}
+
+// processMessage runs the handler and applies the error policy. A non-nil
+// return means the message could not be dealt with and must not be committed.
+func (h *consumerGroupHandler) processMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	err := h.handler(msg)
+	if err == nil {
+		return nil
+	}
+
+	action := Skip()
+	if h.manualCommit {
+		action = Stop()
+	}
+	if h.deadLetter != nil {
+		action = Retry(h.deadLetter.MaxRetries)
+	}
+	if h.policy.OnError != nil {
+		action = h.policy.OnError(msg, err)
+	}
+
+	retries := 0
+	if action.kind == actionRetry {
+		retries, err = h.retry(ctx, msg, action.retries, err)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if h.manualCommit && h.deadLetter == nil {
+			return server.NewError(server.ErrorInternal, fmt.Sprintf("handler failed after %d retries, leaving message uncommitted", retries), err)
+		}
+		if h.deadLetter == nil {
+			fmt.Printf("Handler error after %d retries, skipping message: %v\n", retries, err)
+			return nil
+		}
+		action = DeadLetter(h.deadLetter.Topic)
+	}
+
+	switch action.kind {
+	case actionDeadLetter:
+		return h.sendToDeadLetter(action.topic, msg, err, retries)
+	case actionStop:
+		return server.NewError(server.ErrorInternal, "handler failed, leaving message uncommitted", err)
+	}
+	fmt.Printf("Handler error: %v\n", err)
+	return nil
+}
+
+// giveUp disposes of a message that failed in too many sessions: it is sent
+// to the dead letter topic when one is configured and skipped otherwise
+func (h *consumerGroupHandler) giveUp(msg *sarama.ConsumerMessage, err error) error {
+	if h.deadLetter != nil && h.policy.DLQProducer != nil {
+		return h.sendToDeadLetter(h.deadLetter.Topic, msg, err, h.redeliveries.max)
+	}
+	fmt.Printf("Handler error after %d redeliveries, skipping message: %v\n", h.redeliveries.max, err)
+	return nil
+}
+
+// retry re-runs the handler up to n times with exponential backoff and
+// returns the number of retries made along with the last error
+func (h *consumerGroupHandler) retry(ctx context.Context, msg *sarama.ConsumerMessage, n int, err error) (int, error) {
+	backoff := h.policy.RetryBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	for attempt := 1; attempt <= n; attempt++ {
+		select {
+		case <-ctx.Done():
+			return attempt - 1, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if err = h.handler(msg); err == nil {
+			return attempt, nil
+		}
+		backoff *= 2
+	}
+	return n, err
+}
+
+// sendToDeadLetter produces msg to topic, recording where it came from and why it failed
+func (h *consumerGroupHandler) sendToDeadLetter(topic string, msg *sarama.ConsumerMessage, handlerErr error, retries int) error {
+	if h.policy.DLQProducer == nil {
+		return server.NewError(server.ErrorInternal, "dead letter producer is not configured", handlerErr)
+	}
+
+	includeHeaders := h.deadLetter == nil || h.deadLetter.IncludeHeaders
+	var headers []sarama.RecordHeader
+	if includeHeaders {
+		for _, hdr := range msg.Headers {
+			if hdr != nil {
+				headers = append(headers, *hdr)
+			}
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte("x-original-topic"), Value: []byte(msg.Topic)},
+		sarama.RecordHeader{Key: []byte("x-retry-count"), Value: []byte(strconv.Itoa(retries))},
+	)
+	if includeHeaders {
+		headers = append(headers, sarama.RecordHeader{Key: []byte("x-error"), Value: []byte(handlerErr.Error())})
+	}
+
+	dlqMsg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		dlqMsg.Key = sarama.ByteEncoder(msg.Key)
+	}
+
+	if err := h.policy.DLQProducer.SendRaw(dlqMsg); err != nil {
+		return server.NewError(server.ErrorInternal, "failed to send message to dead letter topic", err)
+	}
+	return nil
+}
+
+// defaultMaxRedeliveries is used when BaseConsumerOptions.MaxRedeliveries is zero
+const defaultMaxRedeliveries = 5
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+type offsetFailures struct {
+	offset int64
+	count  int
+}
+
+// redeliveryTracker counts how many sessions in a row each partition's
+// current message has failed. Claims run concurrently, so it is locked.
+type redeliveryTracker struct {
+	mu       sync.Mutex
+	max      int
+	failures map[partitionKey]offsetFailures
+}
+
+func newRedeliveryTracker(limit int) *redeliveryTracker {
+	return &redeliveryTracker{max: limit, failures: make(map[partitionKey]offsetFailures)}
+}
+
+// exhausted records a failure of msg and reports whether it has now been
+// redelivered more than max times
+func (t *redeliveryTracker) exhausted(msg *sarama.ConsumerMessage) bool {
+	if t == nil || t.max < 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partitionKey{msg.Topic, msg.Partition}
+	f := t.failures[key]
+	if f.offset != msg.Offset {
+		f = offsetFailures{offset: msg.Offset}
+	}
+	f.count++
+	if f.count > t.max {
+		delete(t.failures, key)
+		return true
+	}
+	t.failures[key] = f
+	return false
+}
+
+// clear forgets the failures of msg's partition once a message there succeeds
+func (t *redeliveryTracker) clear(msg *sarama.ConsumerMessage) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.failures, partitionKey{msg.Topic, msg.Partition})
+	t.mu.Unlock()
+}
@@ -15,7 +15,12 @@ import (
 // 	  "username": "kafka-user",
 // 	  "password": "secret",
 // 	  "topic": "my-topic",
-// 	  "group_id": "my-group"
+// 	  "group_id": "my-group",
+// 	  "security": {
+// 		"tls": true,
+// 		"sasl": true,
+// 		"sasl_mechanism": "SCRAM-SHA-512"
+// 	  }
 // 	},
 // 	"options": {
 // 	  "producer": {
@@ -30,7 +35,12 @@ import (
 // 		"heartbeat_interval_ms": 3000,
 // 		"rebalance_timeout_ms": 60000,
 // 		"rebalance_retry_max": 4,
-// 		"rebalance_retry_backoff_ms": 2000
+// 		"rebalance_retry_backoff_ms": 2000,
+// 		"dead_letter": {
+// 		  "topic": "my-topic.dlq",
+// 		  "max_retries": 3,
+// 		  "include_headers": true
+// 		}
 // 	  }
 // 	}
 //   }
@@ -92,6 +102,8 @@ type AWSConfig struct {
 	Password   string `json:"password" env:"AWS_PASSWORD"`
 	Topic      string `json:"topic" env:"AWS_TOPIC"`
 	GroupID    string `json:"group_id"`
+	// Security defaults to TLS with SASL/PLAIN
+	Security SecurityConfig `json:"security"`
 }
 
 func (c AWSConfig) GetType() string       { return "aws" }
@@ -102,6 +114,10 @@ type LocalConfig struct {
 	BrokerSasl string `json:"broker_sasl"`
 	Topic      string `json:"topic"`
 	GroupID    string `json:"group_id"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	// Security defaults to plaintext
+	Security SecurityConfig `json:"security"`
 }
 
 func (c LocalConfig) GetType() string       { return "local" }
@@ -2,6 +2,9 @@ package kafka
 
 import (
 	"context"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -12,56 +15,312 @@ import (
 
 type HandlerFunc func(msg *sarama.ConsumerMessage) error
 
+// DecodedHandlerFunc receives a message along with its deserialized value
+type DecodedHandlerFunc func(msg *sarama.ConsumerMessage, value interface{}) error
+
 type Consumer interface {
 	Start(context.Context, string, HandlerFunc) error
+	StartMulti(context.Context, []string, HandlerFunc) error
+	SetErrorPolicy(ErrorPolicy)
+	SetMessageFilter(MessageFilter)
+	SetMetricsHandler(MetricsHandler)
+	ConsumerLag(ctx context.Context) (map[string]map[int32]int64, error)
 	Close() error
 }
 
+type errorActionKind int
+
+const (
+	actionSkip errorActionKind = iota
+	actionRetry
+	actionDeadLetter
+	actionStop
+)
+
+// ErrorAction tells the consumer what to do with a message whose handler failed
+type ErrorAction struct {
+	kind    errorActionKind
+	retries int
+	topic   string
+}
+
+// Skip marks the failed message as consumed and moves on
+func Skip() ErrorAction {
+	return ErrorAction{kind: actionSkip}
+}
+
+// Retry re-runs the handler up to n more times with exponential backoff.
+// The message is skipped if every attempt fails, or left uncommitted as with
+// Stop when ManualCommit is set.
+func Retry(n int) ErrorAction {
+	return ErrorAction{kind: actionRetry, retries: n}
+}
+
+// DeadLetter produces the failed message to topic and marks it as consumed.
+// Requires ErrorPolicy.DLQProducer; without one the message is treated as
+// with Stop.
+func DeadLetter(topic string) ErrorAction {
+	return ErrorAction{kind: actionDeadLetter, topic: topic}
+}
+
+// Stop leaves the failed message unmarked and ends the session, so it is
+// redelivered from the last committed offset. Only meaningful with
+// ManualCommit, since auto-commit may already have committed past it.
+//
+// The consumer then rejoins the group after a backoff that doubles with each
+// failed session, and the message is handled again. A message that keeps
+// failing would loop forever, so after BaseConsumerOptions.MaxRedeliveries
+// redeliveries it is dead-lettered, or skipped without a DeadLetterConfig.
+func Stop() ErrorAction {
+	return ErrorAction{kind: actionStop}
+}
+
+// ErrorHandler decides how a handler error is dealt with
+type ErrorHandler func(msg *sarama.ConsumerMessage, err error) ErrorAction
+
+// ErrorPolicy configures handler error processing for a consumer
+type ErrorPolicy struct {
+	// OnError picks the action for a failed message. Defaults to Skip, to
+	// Stop with ManualCommit, or to Retry(MaxRetries) when a DeadLetterConfig is set.
+	OnError ErrorHandler
+	// DLQProducer publishes dead-lettered messages. Start fails without one
+	// when BaseConsumerOptions.DeadLetter is set.
+	DLQProducer *BaseProducer
+	// RetryBackoff is the initial delay between retries, doubled after each attempt (default 100ms)
+	RetryBackoff time.Duration
+}
+
+// DeadLetterConfig sends messages that still fail after MaxRetries to a dead letter topic.
+// DLQ messages carry x-original-topic and x-retry-count headers. The topic is
+// written with ErrorPolicy.DLQProducer, which must be set before Start.
+type DeadLetterConfig struct {
+	Topic      string `json:"topic" env:"BROKER_DLQ_TOPIC"`
+	MaxRetries int    `json:"max_retries" env:"BROKER_DLQ_MAX_RETRIES"`
+	// IncludeHeaders copies the original headers and adds the failure reason as x-error
+	IncludeHeaders bool `json:"include_headers" env:"BROKER_DLQ_INCLUDE_HEADERS"`
+}
+
 type BaseConsumer struct {
-	consumerGroup sarama.ConsumerGroup
+	consumerGroup   sarama.ConsumerGroup
+	errorPolicy     ErrorPolicy
+	deadLetter      *DeadLetterConfig
+	manualCommit    bool
+	shutdownTimeout time.Duration
+	// sessionBackoff is the first delay before rejoining after a session
+	// ended on a failed message, doubled per consecutive failure up to
+	// maxSessionBackoff
+	sessionBackoff    time.Duration
+	maxSessionBackoff time.Duration
+	maxRedeliveries   int
+	deserializer      Deserializer
+	filter            MessageFilter
+	startOffset       *int64
+	metrics           MetricsHandler
+	offsets           offsetSource
+	topics            atomic.Value
+	closeOnce         sync.Once
+	closeErr          error
+}
+
+func (bc *BaseConsumer) init(grp sarama.ConsumerGroup, opts *BaseConsumerOptions) {
+	bc.consumerGroup = grp
+	bc.deadLetter = deadLetterConfig(opts)
+	bc.startOffset = startOffset(opts)
+	bc.shutdownTimeout = 10 * time.Second
+	bc.sessionBackoff = 500 * time.Millisecond
+	bc.maxSessionBackoff = 30 * time.Second
+	bc.maxRedeliveries = defaultMaxRedeliveries
+	if opts != nil {
+		bc.manualCommit = opts.ManualCommit
+		if opts.MaxRedeliveries != 0 {
+			bc.maxRedeliveries = opts.MaxRedeliveries
+		}
+		if opts.ShutdownTimeout > 0 {
+			bc.shutdownTimeout = opts.ShutdownTimeout
+		}
+	}
+}
+
+// SetErrorPolicy sets how handler errors are handled. Must be called before Start.
+func (bc *BaseConsumer) SetErrorPolicy(policy ErrorPolicy) {
+	bc.errorPolicy = policy
 }
 
+// MessageFilter reports whether a message should be handled. Messages it
+// rejects are marked as consumed without calling the handler.
+type MessageFilter func(msg *sarama.ConsumerMessage) bool
+
+// SetMessageFilter sets which messages reach the handler. Must be called before Start.
+func (bc *BaseConsumer) SetMessageFilter(filter MessageFilter) {
+	bc.filter = filter
+}
+
+// HeaderFilter accepts messages whose header key has one of values
+func HeaderFilter(key string, values ...string) MessageFilter {
+	return func(msg *sarama.ConsumerMessage) bool {
+		for _, h := range msg.Headers {
+			if h == nil || string(h.Key) != key {
+				continue
+			}
+			for _, v := range values {
+				if string(h.Value) == v {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// SetDeserializer sets how StartDecoded decodes message values (default JSONDeserializer).
+// Must be called before StartDecoded.
+func (bc *BaseConsumer) SetDeserializer(d Deserializer) {
+	bc.deserializer = d
+}
+
+// StartDecoded is like Start but deserializes each message before calling handler.
+// Decoding failures are treated as handler errors and go through the error policy.
+func (bc *BaseConsumer) StartDecoded(ctx context.Context, topic string, handler DecodedHandlerFunc) error {
+	return bc.Start(ctx, topic, decodeWith(bc.deserializer, handler))
+}
+
+func decodeWith(d Deserializer, handler DecodedHandlerFunc) HandlerFunc {
+	if d == nil {
+		d = JSONDeserializer{}
+	}
+	return func(msg *sarama.ConsumerMessage) error {
+		value, err := d.Deserialize(msg.Topic, msg.Value)
+		if err != nil {
+			return server.NewError(server.ErrorBadRequest, "failed to deserialize message", err)
+		}
+		return handler(msg, value)
+	}
+}
+
+// Start consumes topic until ctx is done
 func (bc *BaseConsumer) Start(ctx context.Context, topic string, handler HandlerFunc) error {
-	cgh := &consumerGroupHandler{handler: handler}
+	return bc.StartMulti(ctx, []string{topic}, handler)
+}
+
+// StartMulti consumes all topics in one group session until ctx is done.
+// The handler can tell topics apart via msg.Topic. When a session ends on a
+// message that could not be handled, the group is rejoined after a backoff.
+func (bc *BaseConsumer) StartMulti(ctx context.Context, topics []string, handler HandlerFunc) error {
+	if len(topics) == 0 {
+		return server.NewError(server.ErrorBadRequest, "at least one topic is required", nil)
+	}
+	if bc.deadLetter != nil && bc.errorPolicy.DLQProducer == nil {
+		return server.NewError(server.ErrorBadRequest, "dead letter topic is configured without ErrorPolicy.DLQProducer", nil)
+	}
+	cgh := &consumerGroupHandler{
+		handler:      handler,
+		filter:       bc.filter,
+		policy:       bc.errorPolicy,
+		deadLetter:   bc.deadLetter,
+		manualCommit: bc.manualCommit,
+		startOffset:  bc.startOffset,
+		metrics:      bc.metrics,
+		redeliveries: newRedeliveryTracker(bc.maxRedeliveries),
+	}
+	bc.topics.Store(append([]string(nil), topics...))
+	backoff := bc.sessionBackoff
 	for {
-		if err := bc.consumerGroup.Consume(ctx, []string{topic}, cgh); err != nil {
+		// Consume only returns once the session's offsets have been committed
+		err := bc.consumerGroup.Consume(ctx, topics, cgh)
+		if ctx.Err() != nil {
+			return bc.shutdown(ctx.Err())
+		}
+		if err != nil {
 			return err
 		}
-		if ctx.Err() != nil {
-			return ctx.Err()
+		if !cgh.failed.Swap(false) {
+			backoff = bc.sessionBackoff
+			continue
+		}
+
+		// Rejoining at once would redeliver the failed message in a tight loop
+		select {
+		case <-ctx.Done():
+			return bc.shutdown(ctx.Err())
+		case <-time.After(backoff):
 		}
+		backoff = min(backoff*2, bc.maxSessionBackoff)
 	}
 }
 
+// shutdown closes the consumer group, giving up after the shutdown timeout
+func (bc *BaseConsumer) shutdown(cause error) error {
+	done := make(chan error, 1)
+	go func() { done <- bc.Close() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return server.NewError(server.ErrorInternal, "failed to close consumer group", err)
+		}
+		return cause
+	case <-time.After(bc.shutdownTimeout):
+		return server.NewError(server.ErrorInternal, "timed out closing consumer group", cause)
+	}
+}
+
+// Close closes the consumer group. It is safe to call more than once.
 func (bc *BaseConsumer) Close() error {
-	return bc.consumerGroup.Close()
+	bc.closeOnce.Do(func() {
+		bc.closeErr = bc.consumerGroup.Close()
+		// Groups built from a client leave closing the client to the caller
+		if closer, ok := bc.offsets.(io.Closer); ok {
+			if err := closer.Close(); err != nil && bc.closeErr == nil {
+				bc.closeErr = err
+			}
+		}
+	})
+	return bc.closeErr
 }
 
 // BaseConsumerOptions allows customizing consumer behavior
 type BaseConsumerOptions struct {
-	ClientID              string        `json:"client_id" env:"BROKER_CLIENT_ID"`
-	AutoCommitInterval    time.Duration `json:"auto_commit_interval" env:"BROKER_AUTO_COMMIT_INTERVAL"`
-	MaxWaitTime           time.Duration `json:"max_wait_time" env:"BROKER_MAX_WAIT_TIME"`
-	InitialOffset         int64         `json:"initial_offset" env:"BROKER_INITIAL_OFFSET"` // e.g. -2 for newest, -1 for oldest
-	SessionTimeout        time.Duration `json:"session_timeout" env:"BROKER_SESSION_TIMEOUT"`
-	HeartbeatInterval     time.Duration `json:"heartbeat_interval" env:"BROKER_HEARTBEAT_INTERVAL"`
-	RebalanceTimeout      time.Duration `json:"rebalance_timeout" env:"BROKER_REBALANCE_TIMEOUT"`
-	RebalanceRetryMax     int           `json:"rebalance_retry_max" env:"BROKER_REBALANCE_RETRY_MAX"`
-	RebalanceRetryBackoff time.Duration `json:"rebalance_retry_backoff" env:"BROKER_REBALANCE_RETRY_BACKOFF"`
+	ClientID           string        `json:"client_id" env:"BROKER_CLIENT_ID"`
+	AutoCommitInterval time.Duration `json:"auto_commit_interval" env:"BROKER_AUTO_COMMIT_INTERVAL"`
+	MaxWaitTime        time.Duration `json:"max_wait_time" env:"BROKER_MAX_WAIT_TIME"`
+	// InitialOffset is where consumption starts; nil means sarama.OffsetOldest.
+	// sarama.OffsetNewest and sarama.OffsetOldest apply only when the group has no
+	// committed offset. A non-negative offset (see Offset) is applied to every
+	// assigned partition when the consumer first joins the group.
+	InitialOffset         *int64            `json:"initial_offset,omitempty"`
+	SessionTimeout        time.Duration     `json:"session_timeout" env:"BROKER_SESSION_TIMEOUT"`
+	HeartbeatInterval     time.Duration     `json:"heartbeat_interval" env:"BROKER_HEARTBEAT_INTERVAL"`
+	RebalanceTimeout      time.Duration     `json:"rebalance_timeout" env:"BROKER_REBALANCE_TIMEOUT"`
+	RebalanceRetryMax     int               `json:"rebalance_retry_max" env:"BROKER_REBALANCE_RETRY_MAX"`
+	RebalanceRetryBackoff time.Duration     `json:"rebalance_retry_backoff" env:"BROKER_REBALANCE_RETRY_BACKOFF"`
+	DeadLetter            *DeadLetterConfig `json:"dead_letter,omitempty"`
+	// ManualCommit disables auto-commit for at-least-once delivery. A message is
+	// marked only once its handler succeeds, and by default a failure stops the
+	// session instead of skipping the message (see Stop). Marked offsets are
+	// committed when the consumer catches up and when the session ends,
+	// including on shutdown.
+	ManualCommit bool `json:"manual_commit" env:"BROKER_MANUAL_COMMIT"`
+	// MaxRedeliveries is how often a message that ended its session is
+	// redelivered before it is dead-lettered, or skipped without a
+	// DeadLetterConfig. Zero means 5 and a negative value never gives up.
+	MaxRedeliveries int           `json:"max_redeliveries" env:"BROKER_MAX_REDELIVERIES"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"BROKER_SHUTDOWN_TIMEOUT"`
+	// Security overrides the provider's TLS/SASL settings
+	Security *SecurityConfig `json:"security,omitempty"`
 }
 
+// BaseConsumerConfig builds the provider independent consumer config.
+// TLS and SASL are left disabled; the provider constructors configure them.
 func BaseConsumerConfig(opts *BaseConsumerOptions) *sarama.Config {
 	config := sarama.NewConfig()
 	config.Version = sarama.V1_1_0_0
-	config.Net.TLS.Enable = true
-	config.Net.SASL.Enable = true
 	config.Consumer.Return.Errors = true
 
 	defaults := BaseConsumerOptions{
 		ClientID:              "default-consumer",
 		AutoCommitInterval:    250 * time.Millisecond,
 		MaxWaitTime:           500 * time.Millisecond,
-		InitialOffset:         sarama.OffsetOldest,
+		InitialOffset:         Offset(sarama.OffsetOldest),
 		SessionTimeout:        10 * time.Second,
 		HeartbeatInterval:     3 * time.Second,
 		RebalanceTimeout:      60 * time.Second,
@@ -79,7 +338,9 @@ func BaseConsumerConfig(opts *BaseConsumerOptions) *sarama.Config {
 		if opts.MaxWaitTime > 0 {
 			defaults.MaxWaitTime = opts.MaxWaitTime
 		}
-		if opts.InitialOffset != 0 {
+		// Consumer groups only accept newest/oldest here; specific offsets are
+		// applied in the handler's Setup and fall back to oldest
+		if opts.InitialOffset != nil && *opts.InitialOffset < 0 {
 			defaults.InitialOffset = opts.InitialOffset
 		}
 		if opts.SessionTimeout > 0 {
@@ -100,10 +361,10 @@ func BaseConsumerConfig(opts *BaseConsumerOptions) *sarama.Config {
 	}
 
 	config.ClientID = defaults.ClientID
-	config.Consumer.Offsets.AutoCommit.Enable = true
+	config.Consumer.Offsets.AutoCommit.Enable = opts == nil || !opts.ManualCommit
 	config.Consumer.Offsets.AutoCommit.Interval = defaults.AutoCommitInterval
 	config.Consumer.MaxWaitTime = defaults.MaxWaitTime
-	config.Consumer.Offsets.Initial = defaults.InitialOffset
+	config.Consumer.Offsets.Initial = *defaults.InitialOffset
 	config.Consumer.Group.Session.Timeout = defaults.SessionTimeout
 	config.Consumer.Group.Heartbeat.Interval = defaults.HeartbeatInterval
 	config.Consumer.Group.Rebalance.Timeout = defaults.RebalanceTimeout
@@ -113,6 +374,40 @@ func BaseConsumerConfig(opts *BaseConsumerOptions) *sarama.Config {
 	return config
 }
 
+// newConsumerGroup creates a consumer group on its own client, which is kept for lag queries
+func newConsumerGroup(brokers []string, groupID string, config *sarama.Config) (sarama.Client, sarama.ConsumerGroup, error) {
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	grp, err := sarama.NewConsumerGroupFromClient(groupID, client)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, err
+	}
+	return client, grp, nil
+}
+
+// Offset returns a pointer to offset, for BaseConsumerOptions.InitialOffset
+func Offset(offset int64) *int64 {
+	return &offset
+}
+
+// startOffset returns the explicit non-negative start offset, if any
+func startOffset(opts *BaseConsumerOptions) *int64 {
+	if opts == nil || opts.InitialOffset == nil || *opts.InitialOffset < 0 {
+		return nil
+	}
+	return opts.InitialOffset
+}
+
+func deadLetterConfig(opts *BaseConsumerOptions) *DeadLetterConfig {
+	if opts == nil || opts.DeadLetter == nil || opts.DeadLetter.Topic == "" {
+		return nil
+	}
+	return opts.DeadLetter
+}
+
 // ++++++++++++++++++    AWS CONSUMER   +++++++++++++++++++++
 
 type AWSConsumer struct {
@@ -120,13 +415,31 @@ type AWSConsumer struct {
 	config AWSConfig
 }
 
+// NewAWSConsumerConfig builds a consumer config with the AWS defaults of TLS and SASL/PLAIN
 func NewAWSConsumerConfig(username, password string, opts *BaseConsumerOptions) *sarama.Config {
+	return newAWSConsumerConfig(&AWSConfig{Username: username, Password: password}, opts)
+}
+
+func newAWSConsumerConfig(cfg *AWSConfig, opts *BaseConsumerOptions) *sarama.Config {
+	config := BaseConsumerConfig(opts)
+	applySecurity(config, cfg.Security.merge(consumerSecurity(opts)), true, cfg.Username, cfg.Password)
+	return config
+}
+
+// NewLocalConsumerConfig builds a consumer config that is plaintext unless security is configured
+func NewLocalConsumerConfig(cfg *LocalConfig, opts *BaseConsumerOptions) *sarama.Config {
 	config := BaseConsumerConfig(opts)
-	config.Net.SASL.User = username
-	config.Net.SASL.Password = password
+	applySecurity(config, cfg.Security.merge(consumerSecurity(opts)), false, cfg.Username, cfg.Password)
 	return config
 }
 
+func consumerSecurity(opts *BaseConsumerOptions) *SecurityConfig {
+	if opts == nil {
+		return nil
+	}
+	return opts.Security
+}
+
 func NewAWSConsumer(cfg *AWSConfig, opts *BaseConsumerOptions) (*AWSConsumer, error) {
 	if cfg == nil {
 		return nil, server.NewError(server.ErrorInternal, "aws config is required", nil)
@@ -135,20 +448,18 @@ func NewAWSConsumer(cfg *AWSConfig, opts *BaseConsumerOptions) (*AWSConsumer, er
 	if groupID == "" {
 		groupID = "default-group"
 	}
-	config := NewAWSConsumerConfig(cfg.Username, cfg.Password, opts)
+	config := newAWSConsumerConfig(cfg, opts)
 	brokers := getBrokerAddresses(cfg.BrokerSasl)
 
-	grp, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	client, grp, err := newConsumerGroup(brokers, groupID, config)
 	if err != nil {
 		return nil, server.NewError(server.ErrorInternal, "failed to create aws consumer", err)
 	}
 
-	return &AWSConsumer{
-		BaseConsumer: BaseConsumer{
-			consumerGroup: grp,
-		},
-		config: *cfg,
-	}, nil
+	consumer := &AWSConsumer{config: *cfg}
+	consumer.init(grp, opts)
+	consumer.offsets = newClientOffsetSource(client, groupID)
+	return consumer, nil
 }
 
 // ++++++++++++++++++    LOCAL CONSUMER   +++++++++++++++++++++
@@ -166,18 +477,16 @@ func NewLocalConsumer(cfg *LocalConfig, opts *BaseConsumerOptions) (*LocalConsum
 	if groupID == "" {
 		groupID = "default-group"
 	}
-	config := BaseConsumerConfig(opts)
+	config := NewLocalConsumerConfig(cfg, opts)
 	brokers := getBrokerAddresses(cfg.BrokerSasl)
 
-	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	client, consumerGroup, err := newConsumerGroup(brokers, groupID, config)
 	if err != nil {
 		return nil, server.NewError(server.ErrorInternal, "failed to create local consumer", err)
 	}
 
-	return &LocalConsumer{
-		BaseConsumer: BaseConsumer{
-			consumerGroup: consumerGroup,
-		},
-		config: *cfg,
-	}, nil
+	consumer := &LocalConsumer{config: *cfg}
+	consumer.init(consumerGroup, opts)
+	consumer.offsets = newClientOffsetSource(client, groupID)
+	return consumer, nil
 }
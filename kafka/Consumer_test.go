@@ -0,0 +1,728 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+// fakeSession records which messages were marked as consumed
+type fakeSession struct {
+	ctx     context.Context
+	marked  []int64
+	commits int
+	// committed holds the marked offsets as of the last Commit
+	committed []int64
+	claims    map[string][]int32
+	// offsets records MarkOffset and ResetOffset calls per "topic/partition"
+	offsets map[string][]int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return s.claims }
+func (s *fakeSession) MemberID() string           { return "member" }
+func (s *fakeSession) GenerationID() int32        { return 1 }
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, _ string) {
+	s.recordOffset(topic, partition, offset)
+}
+func (s *fakeSession) Commit() {
+	s.commits++
+	s.committed = append([]int64(nil), s.marked...)
+}
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, _ string) {
+	s.recordOffset(topic, partition, offset)
+}
+func (s *fakeSession) Context() context.Context { return s.ctx }
+func (s *fakeSession) recordOffset(topic string, partition int32, offset int64) {
+	if s.offsets == nil {
+		s.offsets = make(map[string][]int64)
+	}
+	key := fmt.Sprintf("%s/%d", topic, partition)
+	s.offsets[key] = append(s.offsets[key], offset)
+}
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+// fakeClaim serves a fixed set of messages
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func newFakeClaim(msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	ch := make(chan *sarama.ConsumerMessage, len(msgs))
+	for _, m := range msgs {
+		ch <- m
+	}
+	close(ch)
+	return &fakeClaim{messages: ch}
+}
+
+func (c *fakeClaim) Topic() string                            { return "orders" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func testMessage(offset int64) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{Topic: "orders", Partition: 0, Offset: offset, Key: []byte("k"), Value: []byte("v")}
+}
+
+var errHandler = errors.New("handler failed")
+
+func TestConsumeClaim_SkipMarksFailedMessage(t *testing.T) {
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error { return errHandler },
+		policy: ErrorPolicy{OnError: func(*sarama.ConsumerMessage, error) ErrorAction {
+			return Skip()
+		}},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1), testMessage(2))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sess.marked) != 2 {
+		t.Fatalf("expected both messages to be marked, got %v", sess.marked)
+	}
+}
+
+func TestConsumeClaim_RetrySucceeds(t *testing.T) {
+	calls := 0
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error {
+			calls++
+			if calls < 3 {
+				return errHandler
+			}
+			return nil
+		},
+		policy: ErrorPolicy{
+			OnError:      func(*sarama.ConsumerMessage, error) ErrorAction { return Retry(3) },
+			RetryBackoff: time.Millisecond,
+		},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 handler calls, got %d", calls)
+	}
+	if len(sess.marked) != 1 {
+		t.Fatalf("expected message to be marked, got %v", sess.marked)
+	}
+}
+
+func TestConsumeClaim_RetryExhaustedSkips(t *testing.T) {
+	calls := 0
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error {
+			calls++
+			return errHandler
+		},
+		policy: ErrorPolicy{
+			OnError:      func(*sarama.ConsumerMessage, error) ErrorAction { return Retry(2) },
+			RetryBackoff: time.Millisecond,
+		},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 1 attempt plus 2 retries, got %d calls", calls)
+	}
+	if len(sess.marked) != 1 {
+		t.Fatalf("expected exhausted message to be marked, got %v", sess.marked)
+	}
+}
+
+func TestConsumeClaim_DeadLetter(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error { return errHandler },
+		policy: ErrorPolicy{
+			OnError:     func(*sarama.ConsumerMessage, error) ErrorAction { return DeadLetter("orders.dlq") },
+			DLQProducer: &BaseProducer{producer: producer},
+		},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(7))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sess.marked) != 1 {
+		t.Fatalf("expected dead-lettered message to be marked, got %v", sess.marked)
+	}
+	if sent == nil || sent.Topic != "orders.dlq" {
+		t.Fatalf("expected message produced to orders.dlq, got %+v", sent)
+	}
+	headers := make(map[string]string)
+	for _, hdr := range sent.Headers {
+		headers[string(hdr.Key)] = string(hdr.Value)
+	}
+	if headers["x-original-topic"] != "orders" || headers["x-retry-count"] != "0" ||
+		headers["x-error"] != errHandler.Error() {
+		t.Fatalf("unexpected dead letter headers: %v", headers)
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+func TestConsumeClaim_DeadLetterFailureLeavesMessageUncommitted(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error { return errHandler },
+		policy: ErrorPolicy{
+			OnError:     func(*sarama.ConsumerMessage, error) ErrorAction { return DeadLetter("orders.dlq") },
+			DLQProducer: &BaseProducer{producer: producer},
+		},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1), testMessage(2))); err == nil {
+		t.Fatal("expected an error when the dead letter produce fails")
+	}
+	if len(sess.marked) != 0 {
+		t.Fatalf("expected no messages to be marked, got %v", sess.marked)
+	}
+}
+
+func TestConsumeClaim_DeadLetterConfigAfterRetries(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	calls := 0
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error {
+			calls++
+			return errHandler
+		},
+		policy: ErrorPolicy{
+			DLQProducer:  &BaseProducer{producer: producer},
+			RetryBackoff: time.Millisecond,
+		},
+		deadLetter: &DeadLetterConfig{Topic: "orders.dlq", MaxRetries: 2, IncludeHeaders: true},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	msg := testMessage(3)
+	msg.Headers = []*sarama.RecordHeader{{Key: []byte("tenant"), Value: []byte("t1")}}
+	if err := h.ConsumeClaim(sess, newFakeClaim(msg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 1 attempt plus 2 retries, got %d calls", calls)
+	}
+	if len(sess.marked) != 1 || sess.marked[0] != 3 {
+		t.Fatalf("expected original offset to be committed, got %v", sess.marked)
+	}
+	if sent == nil || sent.Topic != "orders.dlq" {
+		t.Fatalf("expected message produced to orders.dlq, got %+v", sent)
+	}
+
+	headers := make(map[string]string)
+	for _, hdr := range sent.Headers {
+		headers[string(hdr.Key)] = string(hdr.Value)
+	}
+	want := map[string]string{
+		"tenant":           "t1",
+		"x-original-topic": "orders",
+		"x-retry-count":    "2",
+		"x-error":          errHandler.Error(),
+	}
+	for k, v := range want {
+		if headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, headers[k], v)
+		}
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+func TestConsumeClaim_DeadLetterConfigWithoutHeaders(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	h := &consumerGroupHandler{
+		handler:    func(msg *sarama.ConsumerMessage) error { return errHandler },
+		policy:     ErrorPolicy{DLQProducer: &BaseProducer{producer: producer}},
+		deadLetter: &DeadLetterConfig{Topic: "orders.dlq"},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	msg := testMessage(1)
+	msg.Headers = []*sarama.RecordHeader{{Key: []byte("tenant"), Value: []byte("t1")}}
+	if err := h.ConsumeClaim(sess, newFakeClaim(msg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := make([]string, 0, len(sent.Headers))
+	for _, hdr := range sent.Headers {
+		keys = append(keys, string(hdr.Key))
+	}
+	if len(keys) != 2 || keys[0] != "x-original-topic" || keys[1] != "x-retry-count" {
+		t.Fatalf("expected only x-original-topic and x-retry-count headers, got %v", keys)
+	}
+}
+
+// fakeConsumerGroup runs a single session over the given messages until ctx is cancelled
+type fakeConsumerGroup struct {
+	messages []*sarama.ConsumerMessage
+	session  *fakeSession
+	events   chan string
+	closed   int
+	topics   []string
+}
+
+func (g *fakeConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	g.topics = topics
+	g.session = &fakeSession{ctx: ctx}
+	if err := handler.Setup(g.session); err != nil {
+		return err
+	}
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(g.messages))}
+	for _, m := range g.messages {
+		claim.messages <- m
+	}
+	go func() {
+		<-ctx.Done()
+		close(claim.messages)
+	}()
+	g.events <- "consuming"
+
+	err := handler.ConsumeClaim(g.session, claim)
+	commitsBefore := g.session.commits
+	_ = handler.Cleanup(g.session)
+	if g.session.commits > commitsBefore {
+		g.events <- "cleanup-commit"
+	}
+	return err
+}
+
+func (g *fakeConsumerGroup) Errors() <-chan error      { return nil }
+func (g *fakeConsumerGroup) Close() error              { g.closed++; return nil }
+func (g *fakeConsumerGroup) Pause(map[string][]int32)  {}
+func (g *fakeConsumerGroup) Resume(map[string][]int32) {}
+func (g *fakeConsumerGroup) PauseAll()                 {}
+func (g *fakeConsumerGroup) ResumeAll()                {}
+
+func TestBaseConsumer_CommitsOnShutdown(t *testing.T) {
+	group := &fakeConsumerGroup{
+		messages: []*sarama.ConsumerMessage{testMessage(1), testMessage(2)},
+		events:   make(chan string, 4),
+	}
+	bc := &BaseConsumer{}
+	bc.init(group, &BaseConsumerOptions{ManualCommit: true})
+
+	var processed atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- bc.Start(ctx, "orders", func(msg *sarama.ConsumerMessage) error {
+			processed.Add(1)
+			return nil
+		})
+	}()
+
+	if ev := <-group.events; ev != "consuming" {
+		t.Fatalf("unexpected event %q", ev)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not stop after cancellation")
+	}
+
+	if ev := <-group.events; ev != "cleanup-commit" {
+		t.Fatalf("expected offsets to be committed on shutdown, got event %q", ev)
+	}
+	if processed.Load() != 2 || len(group.session.marked) != 2 {
+		t.Fatalf("expected 2 processed and marked messages, got %d processed, marked %v",
+			processed.Load(), group.session.marked)
+	}
+	if group.closed != 1 {
+		t.Fatalf("expected the group to be closed once, got %d", group.closed)
+	}
+
+	// Closing again after shutdown is a no-op
+	if err := bc.Close(); err != nil || group.closed != 1 {
+		t.Fatalf("second Close should be a no-op, got err=%v closed=%d", err, group.closed)
+	}
+}
+
+func TestBaseConsumer_StartMulti(t *testing.T) {
+	group := &fakeConsumerGroup{
+		messages: []*sarama.ConsumerMessage{
+			{Topic: "orders", Offset: 1},
+			{Topic: "payments", Offset: 2},
+		},
+		events: make(chan string, 4),
+	}
+	bc := &BaseConsumer{}
+	bc.init(group, nil)
+
+	received := make(chan string, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- bc.StartMulti(ctx, []string{"orders", "payments"}, func(msg *sarama.ConsumerMessage) error {
+			received <- msg.Topic
+			return nil
+		})
+	}()
+
+	seen := map[string]bool{}
+	for range 2 {
+		select {
+		case topic := <-received:
+			seen[topic] = true
+		case <-time.After(time.Second):
+			t.Fatalf("expected messages from both topics, got %v", seen)
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if !seen["orders"] || !seen["payments"] {
+		t.Fatalf("expected messages from both topics, got %v", seen)
+	}
+	if len(group.topics) != 2 || group.topics[0] != "orders" || group.topics[1] != "payments" {
+		t.Fatalf("expected the group to consume both topics, got %v", group.topics)
+	}
+
+	if err := bc.StartMulti(context.Background(), nil, nil); err == nil {
+		t.Fatal("expected error for an empty topic list")
+	}
+}
+
+func TestBaseConsumer_AutoCommitSkipsManualCommit(t *testing.T) {
+	h := &consumerGroupHandler{handler: func(*sarama.ConsumerMessage) error { return nil }}
+	sess := &fakeSession{ctx: context.Background()}
+
+	_ = h.ConsumeClaim(sess, newFakeClaim(testMessage(1)))
+	_ = h.Cleanup(sess)
+
+	if sess.commits != 0 {
+		t.Fatalf("auto-commit mode should not commit explicitly, got %d commits", sess.commits)
+	}
+}
+
+func TestBaseConsumerConfig_ManualCommit(t *testing.T) {
+	if !BaseConsumerConfig(nil).Consumer.Offsets.AutoCommit.Enable {
+		t.Fatal("auto-commit should be enabled by default")
+	}
+	if BaseConsumerConfig(&BaseConsumerOptions{ManualCommit: true}).Consumer.Offsets.AutoCommit.Enable {
+		t.Fatal("auto-commit should be disabled in manual-commit mode")
+	}
+}
+
+func TestBaseConsumerConfig_InitialOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset *int64
+		want   int64
+	}{
+		{"unset defaults to oldest", nil, sarama.OffsetOldest},
+		{"newest", Offset(sarama.OffsetNewest), sarama.OffsetNewest},
+		{"oldest", Offset(sarama.OffsetOldest), sarama.OffsetOldest},
+		{"specific offset falls back to oldest", Offset(0), sarama.OffsetOldest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := BaseConsumerConfig(&BaseConsumerOptions{InitialOffset: tt.offset})
+			if cfg.Consumer.Offsets.Initial != tt.want {
+				t.Fatalf("expected initial offset %d, got %d", tt.want, cfg.Consumer.Offsets.Initial)
+			}
+		})
+	}
+}
+
+func TestBaseConsumer_SpecificInitialOffset(t *testing.T) {
+	for _, offset := range []int64{0, 42} {
+		t.Run(fmt.Sprint(offset), func(t *testing.T) {
+			bc := &BaseConsumer{}
+			bc.init(&fakeConsumerGroup{}, &BaseConsumerOptions{InitialOffset: Offset(offset)})
+			h := &consumerGroupHandler{startOffset: bc.startOffset}
+
+			sess := &fakeSession{ctx: context.Background(), claims: map[string][]int32{"orders": {0, 1}}}
+			if err := h.Setup(sess); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, key := range []string{"orders/0", "orders/1"} {
+				got := sess.offsets[key]
+				if len(got) == 0 || got[0] != offset {
+					t.Fatalf("expected %s to be reset to %d, got %v", key, offset, got)
+				}
+			}
+
+			// Later sessions (rebalances) keep the committed position
+			next := &fakeSession{ctx: context.Background(), claims: map[string][]int32{"orders": {0}}}
+			if err := h.Setup(next); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(next.offsets) != 0 {
+				t.Fatalf("expected offsets to be applied only once, got %v", next.offsets)
+			}
+		})
+	}
+}
+
+func TestBaseConsumer_NegativeInitialOffsetNotReset(t *testing.T) {
+	bc := &BaseConsumer{}
+	bc.init(&fakeConsumerGroup{}, &BaseConsumerOptions{InitialOffset: Offset(sarama.OffsetNewest)})
+	if bc.startOffset != nil {
+		t.Fatalf("expected no explicit start offset, got %d", *bc.startOffset)
+	}
+}
+
+func TestConsumeClaim_FilterSkipsHandlerButMarksMessage(t *testing.T) {
+	withType := func(offset int64, eventType string) *sarama.ConsumerMessage {
+		msg := testMessage(offset)
+		msg.Headers = []*sarama.RecordHeader{{Key: []byte("event-type"), Value: []byte(eventType)}}
+		return msg
+	}
+
+	var handled []int64
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error {
+			handled = append(handled, msg.Offset)
+			return nil
+		},
+		filter:       HeaderFilter("event-type", "order.created", "order.paid"),
+		manualCommit: true,
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	claim := newFakeClaim(
+		withType(1, "order.created"),
+		withType(2, "user.deleted"),
+		testMessage(3),
+		withType(4, "order.paid"),
+		withType(5, "user.created"),
+	)
+	if err := h.ConsumeClaim(sess, claim); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fmt.Sprint(handled) != "[1 4]" {
+		t.Fatalf("expected only matching messages to be handled, got %v", handled)
+	}
+	if fmt.Sprint(sess.marked) != "[1 2 3 4 5]" {
+		t.Fatalf("expected every message to be marked consumed, got %v", sess.marked)
+	}
+	// The last message is filtered out and still triggers the caught-up commit
+	if sess.commits != 1 {
+		t.Fatalf("expected one commit once caught up, got %d", sess.commits)
+	}
+}
+
+func TestConsumeClaim_ManualCommitLeavesFailedMessageUncommitted(t *testing.T) {
+	var handled []int64
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error {
+			handled = append(handled, msg.Offset)
+			if msg.Offset == 3 {
+				return errHandler
+			}
+			return nil
+		},
+		manualCommit: true,
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1), testMessage(2), testMessage(3), testMessage(4)))
+	if !errors.Is(err, errHandler) {
+		t.Fatalf("expected the handler error to end the claim, got %v", err)
+	}
+	_ = h.Cleanup(sess)
+
+	if fmt.Sprint(handled) != "[1 2 3]" {
+		t.Fatalf("expected consumption to stop at the failed message, got %v", handled)
+	}
+	if fmt.Sprint(sess.committed) != "[1 2]" {
+		t.Fatalf("expected only the messages before the failure to be committed, got %v", sess.committed)
+	}
+}
+
+func TestConsumeClaim_ManualCommitRetryExhaustedStops(t *testing.T) {
+	h := &consumerGroupHandler{
+		handler: func(*sarama.ConsumerMessage) error { return errHandler },
+		policy: ErrorPolicy{
+			OnError:      func(*sarama.ConsumerMessage, error) ErrorAction { return Retry(2) },
+			RetryBackoff: time.Millisecond,
+		},
+		manualCommit: true,
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1))); !errors.Is(err, errHandler) {
+		t.Fatalf("expected the handler error after retries, got %v", err)
+	}
+	if len(sess.marked) != 0 {
+		t.Fatalf("expected the message to stay unmarked, got %v", sess.marked)
+	}
+}
+
+func TestBaseConsumer_DeadLetterRequiresProducer(t *testing.T) {
+	group := &fakeConsumerGroup{}
+	bc := &BaseConsumer{}
+	bc.init(group, &BaseConsumerOptions{DeadLetter: &DeadLetterConfig{Topic: "orders.dlq"}})
+
+	err := bc.Start(context.Background(), "orders", func(*sarama.ConsumerMessage) error { return nil })
+	if err == nil {
+		t.Fatal("expected Start to reject a dead letter topic without a producer")
+	}
+	if group.session != nil {
+		t.Fatal("expected the group not to be joined")
+	}
+}
+
+// rejoinGroup mimics sarama ending a session on a claim error: Consume
+// returns nil and records when each session started
+type rejoinGroup struct {
+	fakeConsumerGroup
+	starts []time.Time
+	cancel context.CancelFunc
+	limit  int
+}
+
+func (g *rejoinGroup) Consume(ctx context.Context, _ []string, handler sarama.ConsumerGroupHandler) error {
+	g.starts = append(g.starts, time.Now())
+	if len(g.starts) == g.limit {
+		g.cancel()
+	}
+	sess := &fakeSession{ctx: ctx}
+	_ = handler.ConsumeClaim(sess, newFakeClaim(testMessage(1)))
+	return handler.Cleanup(sess)
+}
+
+func TestBaseConsumer_BacksOffAfterFailedSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	group := &rejoinGroup{cancel: cancel, limit: 4}
+	bc := &BaseConsumer{}
+	bc.init(group, &BaseConsumerOptions{ManualCommit: true})
+	bc.sessionBackoff = 20 * time.Millisecond
+	bc.maxSessionBackoff = 40 * time.Millisecond
+
+	err := bc.Start(ctx, "orders", func(*sarama.ConsumerMessage) error { return errHandler })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(group.starts) != 4 {
+		t.Fatalf("expected 4 sessions, got %d", len(group.starts))
+	}
+	for i, want := range []time.Duration{20, 40, 40} {
+		if gap := group.starts[i+1].Sub(group.starts[i]); gap < want*time.Millisecond {
+			t.Fatalf("expected at least %dms before session %d, got %v", want, i+2, gap)
+		}
+	}
+}
+
+func TestConsumeClaim_RedeliveryLimitSkips(t *testing.T) {
+	h := &consumerGroupHandler{
+		handler:      func(*sarama.ConsumerMessage) error { return errHandler },
+		manualCommit: true,
+		redeliveries: newRedeliveryTracker(2),
+	}
+
+	for session := 1; session <= 2; session++ {
+		sess := &fakeSession{ctx: context.Background()}
+		if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1))); !errors.Is(err, errHandler) {
+			t.Fatalf("session %d: expected the message to stay uncommitted, got %v", session, err)
+		}
+	}
+
+	sess := &fakeSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(sess, newFakeClaim(testMessage(1))); err != nil {
+		t.Fatalf("expected the message to be skipped after 2 redeliveries, got %v", err)
+	}
+	if fmt.Sprint(sess.marked) != "[1]" {
+		t.Fatalf("expected the skipped message to be marked, got %v", sess.marked)
+	}
+}
+
+func TestConsumeClaim_RedeliveryLimitDeadLetters(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	h := &consumerGroupHandler{
+		handler: func(*sarama.ConsumerMessage) error { return errHandler },
+		policy: ErrorPolicy{
+			OnError:     func(*sarama.ConsumerMessage, error) ErrorAction { return Stop() },
+			DLQProducer: &BaseProducer{producer: producer},
+		},
+		deadLetter:   &DeadLetterConfig{Topic: "orders.dlq"},
+		manualCommit: true,
+		redeliveries: newRedeliveryTracker(1),
+	}
+
+	first := &fakeSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(first, newFakeClaim(testMessage(4))); !errors.Is(err, errHandler) {
+		t.Fatalf("expected the first failure to stop the session, got %v", err)
+	}
+	second := &fakeSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(second, newFakeClaim(testMessage(4))); err != nil {
+		t.Fatalf("expected the redelivered message to be dead-lettered, got %v", err)
+	}
+	if sent == nil || sent.Topic != "orders.dlq" || len(second.marked) != 1 {
+		t.Fatalf("expected the message on orders.dlq and marked, got %+v marked %v", sent, second.marked)
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+func TestRedeliveryTracker_ResetsOnNewOffset(t *testing.T) {
+	tracker := newRedeliveryTracker(1)
+	if tracker.exhausted(testMessage(1)) {
+		t.Fatal("first failure should not exhaust the limit")
+	}
+	if tracker.exhausted(testMessage(2)) {
+		t.Fatal("a failure at a new offset should start a new count")
+	}
+	if !tracker.exhausted(testMessage(2)) {
+		t.Fatal("expected the second failure at offset 2 to exhaust the limit")
+	}
+	if newRedeliveryTracker(-1).exhausted(testMessage(1)) {
+		t.Fatal("a negative limit should never give up")
+	}
+}
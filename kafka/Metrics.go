@@ -0,0 +1,170 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/bignyap/go-utilities/otel/api"
+	"github.com/bignyap/go-utilities/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ++++++++++++++++++    CONSUMER METRICS   +++++++++++++++++++++
+
+// MessageMetrics describes one processed message
+type MessageMetrics struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Duration  time.Duration
+	// Err is the error left after the error policy ran, nil if the message was handled
+	Err error
+}
+
+// MetricsHandler is called after each message is processed. It runs on the
+// consumer goroutine and must not block.
+type MetricsHandler func(m MessageMetrics)
+
+// SetMetricsHandler sets the per-message metrics callback. Must be called before Start.
+func (bc *BaseConsumer) SetMetricsHandler(fn MetricsHandler) {
+	bc.metrics = fn
+}
+
+// OtelMetricsHandler records consumed message counts and processing durations
+// with the provider's meter
+func OtelMetricsHandler(provider api.Provider) MetricsHandler {
+	meter := provider.Meter("kafka-consumer")
+
+	messageCounter, _ := meter.Int64Counter(
+		"messaging.consumer.messages",
+		metric.WithDescription("Total number of consumed messages"),
+	)
+
+	processDuration, _ := meter.Float64Histogram(
+		"messaging.consumer.duration",
+		metric.WithDescription("Message processing duration in milliseconds"),
+		metric.WithUnit("ms"),
+	)
+
+	return func(m MessageMetrics) {
+		attrs := metric.WithAttributes(
+			attribute.String("messaging.destination", m.Topic),
+			attribute.Int("messaging.partition", int(m.Partition)),
+			attribute.Bool("error", m.Err != nil),
+		)
+		ctx := context.Background()
+		messageCounter.Add(ctx, 1, attrs)
+		processDuration.Record(ctx, float64(m.Duration.Microseconds())/1000, attrs)
+	}
+}
+
+// ++++++++++++++++++    CONSUMER LAG   +++++++++++++++++++++
+
+// offsetSource provides the offsets needed to compute consumer lag
+type offsetSource interface {
+	Partitions(topic string) ([]int32, error)
+	GetOffset(topic string, partition int32, time int64) (int64, error)
+	// CommittedOffsets returns the group's committed offset per partition, -1 if none
+	CommittedOffsets(topic string, partitions []int32) (map[int32]int64, error)
+}
+
+// clientOffsetSource reads offsets through the consumer group's sarama client.
+// Closing it closes the client.
+type clientOffsetSource struct {
+	sarama.Client
+	groupID string
+
+	mu    sync.Mutex
+	admin sarama.ClusterAdmin
+}
+
+func newClientOffsetSource(client sarama.Client, groupID string) *clientOffsetSource {
+	return &clientOffsetSource{Client: client, groupID: groupID}
+}
+
+func (s *clientOffsetSource) CommittedOffsets(topic string, partitions []int32) (map[int32]int64, error) {
+	// The admin shares the client, so it is created on first use and never closed separately
+	s.mu.Lock()
+	if s.admin == nil {
+		admin, err := sarama.NewClusterAdminFromClient(s.Client)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+		s.admin = admin
+	}
+	admin := s.admin
+	s.mu.Unlock()
+
+	resp, err := admin.ListConsumerGroupOffsets(s.groupID, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int32]int64, len(partitions))
+	for _, p := range partitions {
+		offsets[p] = -1
+		if block := resp.GetBlock(topic, p); block != nil {
+			if block.Err != sarama.ErrNoError {
+				return nil, block.Err
+			}
+			offsets[p] = block.Offset
+		}
+	}
+	return offsets, nil
+}
+
+// ConsumerLag returns, for each partition of every consumed topic, the
+// number of messages between the group's committed offset and the
+// high-water mark, keyed by topic and then partition. Partitions without a
+// committed offset count from the oldest available message.
+func (bc *BaseConsumer) ConsumerLag(ctx context.Context) (map[string]map[int32]int64, error) {
+	if bc.offsets == nil {
+		return nil, server.NewError(server.ErrorInternal, "consumer lag is not available for this consumer", nil)
+	}
+	topics, _ := bc.topics.Load().([]string)
+	if len(topics) == 0 {
+		return nil, server.NewError(server.ErrorInternal, "consumer has not been started", nil)
+	}
+	lag := make(map[string]map[int32]int64, len(topics))
+	for _, topic := range topics {
+		partitions, err := computeLag(ctx, bc.offsets, topic)
+		if err != nil {
+			return nil, err
+		}
+		lag[topic] = partitions
+	}
+	return lag, nil
+}
+
+func computeLag(ctx context.Context, src offsetSource, topic string) (map[int32]int64, error) {
+	partitions, err := src.Partitions(topic)
+	if err != nil {
+		return nil, server.NewError(server.ErrorInternal, "failed to list partitions", err)
+	}
+	committed, err := src.CommittedOffsets(topic, partitions)
+	if err != nil {
+		return nil, server.NewError(server.ErrorInternal, "failed to fetch committed offsets", err)
+	}
+
+	lag := make(map[int32]int64, len(partitions))
+	for _, p := range partitions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hwm, err := src.GetOffset(topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, server.NewError(server.ErrorInternal, "failed to fetch high-water mark", err)
+		}
+		offset := committed[p]
+		if offset < 0 {
+			if offset, err = src.GetOffset(topic, p, sarama.OffsetOldest); err != nil {
+				return nil, server.NewError(server.ErrorInternal, "failed to fetch oldest offset", err)
+			}
+		}
+		lag[p] = max(hwm-offset, 0)
+	}
+	return lag, nil
+}
@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Ensure the concrete consumers satisfy Consumer
+var (
+	_ Consumer = (*LocalConsumer)(nil)
+	_ Consumer = (*AWSConsumer)(nil)
+)
+
+func TestConsumeClaim_ReportsMetricsPerMessage(t *testing.T) {
+	var got []MessageMetrics
+	h := &consumerGroupHandler{
+		handler: func(msg *sarama.ConsumerMessage) error {
+			if msg.Offset == 2 {
+				return errHandler
+			}
+			return nil
+		},
+		policy: ErrorPolicy{OnError: func(*sarama.ConsumerMessage, error) ErrorAction {
+			return DeadLetter("orders.dlq")
+		}},
+		metrics: func(m MessageMetrics) { got = append(got, m) },
+	}
+	sess := &fakeSession{ctx: context.Background()}
+
+	// The second message fails to dead-letter (no producer) and stops the claim
+	_ = h.ConsumeClaim(sess, newFakeClaim(testMessage(1), testMessage(2)))
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 metric reports, got %d", len(got))
+	}
+	if got[0].Topic != "orders" || got[0].Partition != 0 || got[0].Offset != 1 || got[0].Err != nil {
+		t.Fatalf("unexpected metrics for first message: %+v", got[0])
+	}
+	if got[1].Offset != 2 || got[1].Err == nil {
+		t.Fatalf("expected failure to be reported for second message: %+v", got[1])
+	}
+	if got[0].Duration < 0 {
+		t.Fatalf("unexpected duration %v", got[0].Duration)
+	}
+}
+
+// fakeOffsetSource serves fixed offsets
+type fakeOffsetSource struct {
+	partitions []int32
+	newest     map[int32]int64
+	oldest     map[int32]int64
+	committed  map[int32]int64
+	err        error
+}
+
+func (s *fakeOffsetSource) Partitions(string) ([]int32, error) { return s.partitions, nil }
+
+func (s *fakeOffsetSource) GetOffset(_ string, p int32, t int64) (int64, error) {
+	if t == sarama.OffsetOldest {
+		return s.oldest[p], nil
+	}
+	return s.newest[p], nil
+}
+
+func (s *fakeOffsetSource) CommittedOffsets(string, []int32) (map[int32]int64, error) {
+	return s.committed, s.err
+}
+
+func TestConsumerLag(t *testing.T) {
+	src := &fakeOffsetSource{
+		partitions: []int32{0, 1, 2, 3},
+		newest:     map[int32]int64{0: 100, 1: 50, 2: 30, 3: 10},
+		oldest:     map[int32]int64{0: 0, 1: 0, 2: 20, 3: 0},
+		// partition 2 has no commit; partition 3 is ahead of a truncated log
+		committed: map[int32]int64{0: 90, 1: 50, 2: -1, 3: 12},
+	}
+	bc := &BaseConsumer{}
+	bc.init(&fakeConsumerGroup{}, nil)
+	bc.offsets = src
+
+	if _, err := bc.ConsumerLag(context.Background()); err == nil {
+		t.Fatal("expected error before the consumer is started")
+	}
+
+	bc.topics.Store([]string{"orders", "payments"})
+	lag, err := bc.ConsumerLag(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lag) != 2 {
+		t.Fatalf("expected lag for both topics, got %v", lag)
+	}
+	want := map[int32]int64{0: 10, 1: 0, 2: 10, 3: 0}
+	for _, topic := range []string{"orders", "payments"} {
+		for p, l := range want {
+			if lag[topic][p] != l {
+				t.Fatalf("%s partition %d: expected lag %d, got %d (all: %v)", topic, p, l, lag[topic][p], lag)
+			}
+		}
+	}
+
+	src.err = errors.New("coordinator unavailable")
+	if _, err := bc.ConsumerLag(context.Background()); err == nil {
+		t.Fatal("expected committed offset error to be returned")
+	}
+}
+
+func TestConsumerLag_Unavailable(t *testing.T) {
+	bc := &BaseConsumer{}
+	bc.init(&fakeConsumerGroup{}, nil)
+	if _, err := bc.ConsumerLag(context.Background()); err == nil {
+		t.Fatal("expected error without an offset source")
+	}
+}
+
+// meterProvider is a minimal otel api.Provider backed by an SDK meter provider
+type meterProvider struct {
+	mp *sdkmetric.MeterProvider
+}
+
+func (p meterProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return noop.NewTracerProvider().Tracer(name, opts...)
+}
+
+func (p meterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.mp.Meter(name, opts...)
+}
+
+func (p meterProvider) Shutdown(ctx context.Context) error { return p.mp.Shutdown(ctx) }
+
+func TestOtelMetricsHandler(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := meterProvider{mp: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))}
+
+	handler := OtelMetricsHandler(provider)
+	handler(MessageMetrics{Topic: "orders", Partition: 1, Offset: 5})
+	handler(MessageMetrics{Topic: "orders", Partition: 1, Offset: 6, Err: errHandler})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "messaging.consumer.messages" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				total += dp.Value
+			}
+		}
+	}
+	if total != 2 {
+		t.Fatalf("expected 2 consumed messages recorded, got %d", total)
+	}
+}
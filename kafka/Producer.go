@@ -1,11 +1,14 @@
 package kafka
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/bignyap/go-utilities/server"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ++++++++++++++++++    BASE PRODUCER   +++++++++++++++++++++
@@ -14,16 +17,126 @@ type Producer interface {
 	Init() error
 	Close() error
 	SendMessage(msg interface{}) error
+	SendMessageContext(ctx context.Context, msg interface{}) error
+	SendMessageWithKey(key string, msg interface{}, headers map[string]string) error
+	SendRaw(msg *sarama.ProducerMessage) error
 }
 
 type BaseProducer struct {
-	producer sarama.SyncProducer
-	topic    string
+	producer   sarama.SyncProducer
+	topic      string
+	serializer Serializer
+	tracer     trace.Tracer
+}
+
+// SetSerializer sets how message values are encoded (default JSONSerializer)
+func (bp *BaseProducer) SetSerializer(s Serializer) {
+	bp.serializer = s
 }
 
 func (bp *BaseProducer) SendMessage(msg interface{}) error {
-	tq := TopicQueue{Producer: bp.producer, Topic: bp.topic}
-	return tq.SendMessage(msg)
+	return bp.SendMessageContext(context.Background(), msg)
+}
+
+// SendMessageContext serializes msg and sends it, returning early when ctx is
+// done with a server.ErrorTimeout error (or ErrorInternal when canceled).
+// This includes cancellation while sarama is backing off between retries.
+// sarama cannot abort an in-flight send, so it completes in the background
+// and its result is discarded.
+func (bp *BaseProducer) SendMessageContext(ctx context.Context, msg interface{}) error {
+	pm, err := buildMessage(bp.serializer, bp.topic, "", msg, nil)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return contextError(err)
+	}
+
+	return bp.traceSend(ctx, pm, func() error {
+		done := make(chan error, 1)
+		go func() { done <- bp.SendRaw(pm) }()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return contextError(ctx.Err())
+		}
+	})
+}
+
+// SendMessageWithKey serializes msg and sends it with the given key and headers.
+// Messages with the same key land on the same partition, preserving their order.
+func (bp *BaseProducer) SendMessageWithKey(key string, msg interface{}, headers map[string]string) error {
+	pm, err := buildMessage(bp.serializer, bp.topic, key, msg, headers)
+	if err != nil {
+		return err
+	}
+	return bp.traceSend(context.Background(), pm, func() error { return bp.SendRaw(pm) })
+}
+
+// BatchError reports which messages of a SendMessages batch failed,
+// keyed by their index in the batch
+type BatchError struct {
+	Total  int
+	Failed map[int]error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d messages failed", len(e.Failed), e.Total)
+}
+
+// SendMessages serializes msgs and sends them to the producer's topic as one
+// batch. If any fail, the returned error wraps a *BatchError; messages that
+// could not be serialized are reported there and the rest are still sent.
+func (bp *BaseProducer) SendMessages(msgs []interface{}) error {
+	batch := &BatchError{Total: len(msgs), Failed: map[int]error{}}
+	pms := make([]*sarama.ProducerMessage, 0, len(msgs))
+	index := make(map[*sarama.ProducerMessage]int, len(msgs))
+
+	for i, msg := range msgs {
+		pm, err := buildMessage(bp.serializer, bp.topic, "", msg, nil)
+		if err != nil {
+			batch.Failed[i] = err
+			continue
+		}
+		pms = append(pms, pm)
+		index[pm] = i
+	}
+
+	if len(pms) > 0 {
+		if err := bp.producer.SendMessages(pms); err != nil {
+			var perrs sarama.ProducerErrors
+			if errors.As(err, &perrs) {
+				for _, perr := range perrs {
+					if i, ok := index[perr.Msg]; ok {
+						batch.Failed[i] = perr.Err
+					}
+				}
+			} else {
+				// Not attributable to single messages, so the whole batch failed
+				for _, i := range index {
+					batch.Failed[i] = err
+				}
+			}
+		}
+	}
+
+	if len(batch.Failed) > 0 {
+		return server.NewError(server.ErrorInternal, "failed to send message batch", batch)
+	}
+	return nil
+}
+
+// SendRaw sends a prepared message as-is, defaulting its topic to the producer's topic
+func (bp *BaseProducer) SendRaw(msg *sarama.ProducerMessage) error {
+	if msg.Topic == "" {
+		msg.Topic = bp.topic
+	}
+	if _, _, err := bp.producer.SendMessage(msg); err != nil {
+		return server.NewError(server.ErrorInternal, "failed to send message", err)
+	}
+	return nil
 }
 
 func (bp *BaseProducer) Init() error  { return nil }
@@ -36,8 +149,17 @@ type BaseProducerOptions struct {
 	EnableIdempotence   bool                    `json:"enable_idempotence" env:"BROKER_ENABLE_IDEMPOTENCE"`
 	ClientID            string                  `json:"client_id" env:"BROKER_CLIENT_ID"`
 	MaxMessageBytes     int                     `json:"max_message_bytes" env:"BROKER_MAX_MESSAGE_BYTES"`
+	// RetryMax and RetryBackoff bound sarama's internal send retries (default 10 x 200ms).
+	// SendMessageContext stops waiting on cancellation, but an abandoned send keeps
+	// retrying in the background until this budget is spent.
+	RetryMax     int           `json:"retry_max" env:"BROKER_PRODUCER_RETRY_MAX"`
+	RetryBackoff time.Duration `json:"retry_backoff" env:"BROKER_PRODUCER_RETRY_BACKOFF"`
+	// Security overrides the provider's TLS/SASL settings
+	Security *SecurityConfig `json:"security,omitempty"`
 }
 
+// BaseProducerConfig builds the provider independent producer config.
+// TLS and SASL are left disabled; the provider constructors configure them.
 func BaseProducerConfig(userOpts *BaseProducerOptions) *sarama.Config {
 	defaultOpts := BaseProducerOptions{
 		IncludeFlushConfigs: true,
@@ -45,6 +167,8 @@ func BaseProducerConfig(userOpts *BaseProducerOptions) *sarama.Config {
 		EnableIdempotence:   true,
 		ClientID:            "default-producer",
 		MaxMessageBytes:     1000000,
+		RetryMax:            10,
+		RetryBackoff:        200 * time.Millisecond,
 	}
 
 	// Override defaults with user-specified options
@@ -58,20 +182,24 @@ func BaseProducerConfig(userOpts *BaseProducerOptions) *sarama.Config {
 		if userOpts.MaxMessageBytes != 0 {
 			defaultOpts.MaxMessageBytes = userOpts.MaxMessageBytes
 		}
+		if userOpts.RetryMax > 0 {
+			defaultOpts.RetryMax = userOpts.RetryMax
+		}
+		if userOpts.RetryBackoff > 0 {
+			defaultOpts.RetryBackoff = userOpts.RetryBackoff
+		}
 		defaultOpts.IncludeFlushConfigs = userOpts.IncludeFlushConfigs
 		defaultOpts.EnableIdempotence = userOpts.EnableIdempotence
 	}
 
 	config := sarama.NewConfig()
 	config.Version = sarama.V1_1_0_0
-	config.Net.TLS.Enable = true
-	config.Net.SASL.Enable = true
 
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Return.Successes = true
 	config.Producer.Return.Errors = true
-	config.Producer.Retry.Max = 10
-	config.Producer.Retry.Backoff = 200 * time.Millisecond
+	config.Producer.Retry.Max = defaultOpts.RetryMax
+	config.Producer.Retry.Backoff = defaultOpts.RetryBackoff
 
 	config.ClientID = defaultOpts.ClientID
 	config.Producer.Compression = defaultOpts.Compression
@@ -109,7 +237,7 @@ func NewAWSProducer(cfg *AWSConfig, opts *BaseProducerOptions) (*AWSProducer, er
 		return nil, server.NewError(server.ErrorInternal, "aws config is required", nil)
 	}
 
-	acfg := NewAWSProducerConfig(cfg.Username, cfg.Password, opts)
+	acfg := newAWSProducerConfig(cfg, opts)
 
 	brokers := getBrokerAddresses(cfg.BrokerSasl)
 	prod, err := sarama.NewSyncProducer(brokers, acfg)
@@ -126,13 +254,31 @@ func NewAWSProducer(cfg *AWSConfig, opts *BaseProducerOptions) (*AWSProducer, er
 	}, nil
 }
 
+// NewAWSProducerConfig builds a producer config with the AWS defaults of TLS and SASL/PLAIN
 func NewAWSProducerConfig(username string, password string, opts *BaseProducerOptions) *sarama.Config {
+	return newAWSProducerConfig(&AWSConfig{Username: username, Password: password}, opts)
+}
+
+func newAWSProducerConfig(cfg *AWSConfig, opts *BaseProducerOptions) *sarama.Config {
+	config := BaseProducerConfig(opts)
+	applySecurity(config, cfg.Security.merge(producerSecurity(opts)), true, cfg.Username, cfg.Password)
+	return config
+}
+
+// NewLocalProducerConfig builds a producer config that is plaintext unless security is configured
+func NewLocalProducerConfig(cfg *LocalConfig, opts *BaseProducerOptions) *sarama.Config {
 	config := BaseProducerConfig(opts)
-	config.Net.SASL.User = username
-	config.Net.SASL.Password = password
+	applySecurity(config, cfg.Security.merge(producerSecurity(opts)), false, cfg.Username, cfg.Password)
 	return config
 }
 
+func producerSecurity(opts *BaseProducerOptions) *SecurityConfig {
+	if opts == nil {
+		return nil
+	}
+	return opts.Security
+}
+
 // ++++++++++++++++++    LOCAL PRODUCER   +++++++++++++++++++++
 
 type LocalProducer struct {
@@ -149,7 +295,7 @@ func NewLocalProducer(config *LocalConfig, opts *BaseProducerOptions) (*LocalPro
 		)
 	}
 
-	localConfig := BaseProducerConfig(opts)
+	localConfig := NewLocalProducerConfig(config, opts)
 	brokers := getBrokerAddresses(config.BrokerSasl)
 
 	producer, err := sarama.NewSyncProducer(brokers, localConfig)
@@ -0,0 +1,295 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/bignyap/go-utilities/server"
+)
+
+func TestBaseProducer_SendMessageWithKey(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+	err := bp.SendMessageWithKey("order-42", map[string]string{"status": "paid"}, map[string]string{
+		"tenant":       "t1",
+		"content-type": "application/json",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sent.Topic != "orders" {
+		t.Fatalf("expected topic orders, got %q", sent.Topic)
+	}
+	key, _ := sent.Key.Encode()
+	if string(key) != "order-42" {
+		t.Fatalf("expected key order-42, got %q", key)
+	}
+	value, _ := sent.Value.Encode()
+	if string(value) != `{"status":"paid"}` {
+		t.Fatalf("unexpected value %s", value)
+	}
+
+	headers := make(map[string]string)
+	for _, hdr := range sent.Headers {
+		headers[string(hdr.Key)] = string(hdr.Value)
+	}
+	if headers["tenant"] != "t1" || headers["content-type"] != "application/json" || len(headers) != 2 {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+func TestBaseProducer_SendRaw(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+
+	// Topic defaults to the producer's topic
+	if err := bp.SendRaw(&sarama.ProducerMessage{Key: sarama.StringEncoder("k"), Value: sarama.StringEncoder("raw")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent.Topic != "orders" {
+		t.Fatalf("expected default topic orders, got %q", sent.Topic)
+	}
+
+	// Send failures are surfaced
+	if err := bp.SendRaw(&sarama.ProducerMessage{Topic: "audit", Value: sarama.StringEncoder("raw")}); err == nil {
+		t.Fatal("expected send failure to be returned")
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+// blockingSyncProducer blocks sends until release is closed
+type blockingSyncProducer struct {
+	sarama.SyncProducer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingSyncProducer) SendMessage(*sarama.ProducerMessage) (int32, int64, error) {
+	close(p.started)
+	<-p.release
+	return 0, 0, nil
+}
+
+func TestBaseProducer_SendMessageContextCanceled(t *testing.T) {
+	prod := &blockingSyncProducer{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(prod.release)
+	bp := &BaseProducer{producer: prod, topic: "orders"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-prod.started
+		cancel()
+	}()
+
+	err := bp.SendMessageContext(ctx, map[string]string{"status": "paid"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBaseProducer_SendMessageContextTimeout(t *testing.T) {
+	prod := &blockingSyncProducer{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(prod.release)
+	bp := &BaseProducer{producer: prod, topic: "orders"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := bp.SendMessageContext(ctx, "paid")
+	var ierr *server.InternalError
+	if !errors.As(err, &ierr) || ierr.Type != server.ErrorTimeout {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error to be wrapped, got %v", err)
+	}
+}
+
+func TestBaseProducer_SendMessageContextSucceeds(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+
+	if err := bp.SendMessageContext(context.Background(), "paid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+// retryingBroker answers produce requests with a retriable error, so sarama
+// keeps retrying the send
+func retryingBroker(t *testing.T) *sarama.MockBroker {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).
+			SetError("orders", 0, sarama.ErrNotEnoughReplicas),
+	})
+	return broker
+}
+
+func TestBaseProducer_SendMessageContextCanceledMidRetry(t *testing.T) {
+	broker := retryingBroker(t)
+	defer broker.Close()
+
+	opts := &BaseProducerOptions{RetryMax: 10, RetryBackoff: 100 * time.Millisecond}
+	prod, err := sarama.NewSyncProducer([]string{broker.Addr()}, BaseProducerConfig(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prod.Close()
+	bp := &BaseProducer{producer: prod, topic: "orders"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(150*time.Millisecond, cancel)
+
+	start := time.Now()
+	err = bp.SendMessageContext(ctx, map[string]string{"id": "1"})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// The full budget is 10 retries x 100ms
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("expected send to stop shortly after cancellation, took %s", elapsed)
+	}
+}
+
+func TestBaseProducer_SendMessageContextDeadlineMidRetry(t *testing.T) {
+	broker := retryingBroker(t)
+	defer broker.Close()
+
+	opts := &BaseProducerOptions{RetryMax: 10, RetryBackoff: 100 * time.Millisecond}
+	prod, err := sarama.NewSyncProducer([]string{broker.Addr()}, BaseProducerConfig(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prod.Close()
+	bp := &BaseProducer{producer: prod, topic: "orders"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = bp.SendMessageContext(ctx, map[string]string{"id": "1"})
+	elapsed := time.Since(start)
+
+	var ierr *server.InternalError
+	if !errors.As(err, &ierr) || ierr.Type != server.ErrorTimeout || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a wrapped ErrorTimeout, got %v", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("expected send to stop at the deadline, took %s", elapsed)
+	}
+}
+
+func TestBaseProducerConfig_RetryOptions(t *testing.T) {
+	cfg := BaseProducerConfig(nil)
+	if cfg.Producer.Retry.Max != 10 || cfg.Producer.Retry.Backoff != 200*time.Millisecond {
+		t.Fatalf("unexpected default retries %d x %s", cfg.Producer.Retry.Max, cfg.Producer.Retry.Backoff)
+	}
+	cfg = BaseProducerConfig(&BaseProducerOptions{RetryMax: 2, RetryBackoff: 50 * time.Millisecond})
+	if cfg.Producer.Retry.Max != 2 || cfg.Producer.Retry.Backoff != 50*time.Millisecond {
+		t.Fatalf("unexpected retries %d x %s", cfg.Producer.Retry.Max, cfg.Producer.Retry.Backoff)
+	}
+}
+
+func TestBaseProducer_SendMessagesBatch(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent []string
+	for range 3 {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			value, _ := msg.Value.Encode()
+			sent = append(sent, string(value))
+			return nil
+		})
+	}
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+
+	if err := bp.SendMessages([]interface{}{"a", "b", "c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sent) != 3 || sent[0] != `"a"` || sent[2] != `"c"` {
+		t.Fatalf("expected the batch to be sent in order, got %v", sent)
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+// partialSyncProducer fails the messages whose value is in fail, as sarama
+// does when some messages of a batch are rejected
+type partialSyncProducer struct {
+	sarama.SyncProducer
+	fail    map[string]bool
+	batches int
+}
+
+func (p *partialSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.batches++
+	var errs sarama.ProducerErrors
+	for _, msg := range msgs {
+		value, _ := msg.Value.Encode()
+		if p.fail[string(value)] {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: sarama.ErrMessageSizeTooLarge})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func TestBaseProducer_SendMessagesPartialFailure(t *testing.T) {
+	prod := &partialSyncProducer{fail: map[string]bool{`"b"`: true, `"d"`: true}}
+	bp := &BaseProducer{producer: prod, topic: "orders"}
+
+	// The channel cannot be serialized, so it fails before sending
+	err := bp.SendMessages([]interface{}{"a", "b", make(chan int), "d"})
+
+	var batch *BatchError
+	if !errors.As(err, &batch) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if batch.Total != 4 || len(batch.Failed) != 3 {
+		t.Fatalf("expected 3 of 4 messages to fail, got %+v", batch)
+	}
+	if !errors.Is(batch.Failed[1], sarama.ErrMessageSizeTooLarge) || !errors.Is(batch.Failed[3], sarama.ErrMessageSizeTooLarge) {
+		t.Fatalf("expected broker errors for messages 1 and 3, got %v", batch.Failed)
+	}
+	if batch.Failed[2] == nil {
+		t.Fatal("expected a serialization error for message 2")
+	}
+	if _, ok := batch.Failed[0]; ok || prod.batches != 1 {
+		t.Fatalf("expected message 0 to succeed in a single batch, got %v after %d batches", batch.Failed, prod.batches)
+	}
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// ++++++++++++++++++    SCRAM CLIENT   +++++++++++++++++++++
+
+// scramClient implements sarama.SCRAMClient (RFC 5802) for SHA-256 and SHA-512
+type scramClient struct {
+	hash  func() hash.Hash
+	nonce func() (string, error)
+
+	step            int
+	done            bool
+	username        string
+	password        string
+	gs2Header       string
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+var _ sarama.SCRAMClient = (*scramClient)(nil)
+
+func newSCRAMClientGenerator(h func() hash.Hash) func() sarama.SCRAMClient {
+	return func() sarama.SCRAMClient {
+		return &scramClient{hash: h, nonce: randomNonce}
+	}
+}
+
+func randomNonce() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(b), nil
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	c.username = userName
+	c.password = password
+	c.gs2Header = "n,,"
+	if authzID != "" {
+		c.gs2Header = "n,a=" + escapeSCRAMName(authzID) + ","
+	}
+	c.step = 0
+	c.done = false
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	switch c.step {
+	case 0:
+		nonce, err := c.nonce()
+		if err != nil {
+			return "", err
+		}
+		c.clientNonce = nonce
+		c.clientFirstBare = "n=" + escapeSCRAMName(c.username) + ",r=" + nonce
+		c.step++
+		return c.gs2Header + c.clientFirstBare, nil
+	case 1:
+		c.step++
+		return c.clientFinal(challenge)
+	case 2:
+		c.step++
+		c.done = true
+		return "", c.verifyServerFinal(challenge)
+	default:
+		return "", errors.New("scram: unexpected challenge after authentication completed")
+	}
+}
+
+func (c *scramClient) Done() bool { return c.done }
+
+func (c *scramClient) clientFinal(serverFirst string) (string, error) {
+	attrs := parseSCRAMAttributes(serverFirst)
+	serverNonce, salt64, iter64 := attrs["r"], attrs["s"], attrs["i"]
+
+	if !strings.HasPrefix(serverNonce, c.clientNonce) {
+		return "", errors.New("scram: server nonce does not extend client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil {
+		return "", fmt.Errorf("scram: invalid salt: %w", err)
+	}
+	iterations, err := strconv.Atoi(iter64)
+	if err != nil || iterations <= 0 {
+		return "", fmt.Errorf("scram: invalid iteration count %q", iter64)
+	}
+
+	salted, err := pbkdf2.Key(c.hash, c.password, salt, iterations, c.hash().Size())
+	if err != nil {
+		return "", fmt.Errorf("scram: %w", err)
+	}
+	clientKey := c.hmac(salted, "Client Key")
+	storedKey := c.sum(clientKey)
+
+	clientFinalNoProof := "c=" + base64.StdEncoding.EncodeToString([]byte(c.gs2Header)) + ",r=" + serverNonce
+	authMessage := c.clientFirstBare + "," + serverFirst + "," + clientFinalNoProof
+
+	proof := c.hmac(storedKey, authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	c.serverSignature = c.hmac(c.hmac(salted, "Server Key"), authMessage)
+
+	return clientFinalNoProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func (c *scramClient) verifyServerFinal(serverFinal string) error {
+	attrs := parseSCRAMAttributes(serverFinal)
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("scram: server error: %s", e)
+	}
+	sig, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || !hmac.Equal(sig, c.serverSignature) {
+		return errors.New("scram: server signature mismatch")
+	}
+	return nil
+}
+
+func (c *scramClient) hmac(key []byte, msg string) []byte {
+	mac := hmac.New(c.hash, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+func (c *scramClient) sum(b []byte) []byte {
+	h := c.hash()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+func parseSCRAMAttributes(msg string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(msg, ",") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+// escapeSCRAMName escapes the characters SCRAM reserves in user names
+func escapeSCRAMName(name string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(name)
+}
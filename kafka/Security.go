@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/IBM/sarama"
+)
+
+// ++++++++++++++++++    CONNECTION SECURITY   +++++++++++++++++++++
+
+// Supported SASL mechanisms
+const (
+	SASLMechanismPlain       = sarama.SASLTypePlaintext
+	SASLMechanismSCRAMSHA256 = sarama.SASLTypeSCRAMSHA256
+	SASLMechanismSCRAMSHA512 = sarama.SASLTypeSCRAMSHA512
+)
+
+// SecurityConfig controls TLS and SASL on broker connections. Unset toggles
+// fall back to the provider default: plaintext for local, TLS+SASL for AWS.
+type SecurityConfig struct {
+	TLS  *bool `json:"tls,omitempty"`
+	SASL *bool `json:"sasl,omitempty"`
+	// SASLMechanism is PLAIN (default), SCRAM-SHA-256 or SCRAM-SHA-512
+	SASLMechanism string `json:"sasl_mechanism,omitempty"`
+	// TLSConfig replaces the default TLS settings, e.g. one built by NewTLSConfig
+	TLSConfig *tls.Config `json:"-"`
+}
+
+// Bool returns a pointer to v, for SecurityConfig toggles
+func Bool(v bool) *bool {
+	return &v
+}
+
+// NewTLSConfig builds a TLS config trusting the CA bundle at caFile and, when
+// certFile and keyFile are set, presenting that client certificate
+func NewTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// merge returns s with any fields set in override taking precedence
+func (s SecurityConfig) merge(override *SecurityConfig) SecurityConfig {
+	if override == nil {
+		return s
+	}
+	if override.TLS != nil {
+		s.TLS = override.TLS
+	}
+	if override.SASL != nil {
+		s.SASL = override.SASL
+	}
+	if override.SASLMechanism != "" {
+		s.SASLMechanism = override.SASLMechanism
+	}
+	if override.TLSConfig != nil {
+		s.TLSConfig = override.TLSConfig
+	}
+	return s
+}
+
+// applySecurity configures TLS and SASL on config; secure is the provider
+// default used for toggles left unset
+func applySecurity(config *sarama.Config, sec SecurityConfig, secure bool, username, password string) {
+	config.Net.TLS.Enable = secure
+	if sec.TLS != nil {
+		config.Net.TLS.Enable = *sec.TLS
+	}
+	if config.Net.TLS.Enable {
+		config.Net.TLS.Config = sec.TLSConfig
+	}
+
+	config.Net.SASL.Enable = secure
+	if sec.SASL != nil {
+		config.Net.SASL.Enable = *sec.SASL
+	}
+	if !config.Net.SASL.Enable {
+		return
+	}
+
+	config.Net.SASL.User = username
+	config.Net.SASL.Password = password
+	config.Net.SASL.Mechanism = SASLMechanismPlain
+	if sec.SASLMechanism != "" {
+		config.Net.SASL.Mechanism = sarama.SASLMechanism(sec.SASLMechanism)
+	}
+	switch config.Net.SASL.Mechanism {
+	case SASLMechanismSCRAMSHA256:
+		config.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(sha256.New)
+	case SASLMechanismSCRAMSHA512:
+		config.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(sha512.New)
+	}
+}
@@ -0,0 +1,208 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestSecurity_ProviderDefaults(t *testing.T) {
+	local := NewLocalProducerConfig(&LocalConfig{}, nil)
+	if local.Net.TLS.Enable || local.Net.SASL.Enable {
+		t.Fatalf("expected local producer to be plaintext, got tls=%v sasl=%v", local.Net.TLS.Enable, local.Net.SASL.Enable)
+	}
+	localConsumer := NewLocalConsumerConfig(&LocalConfig{}, nil)
+	if localConsumer.Net.TLS.Enable || localConsumer.Net.SASL.Enable {
+		t.Fatal("expected local consumer to be plaintext")
+	}
+
+	aws := NewAWSProducerConfig("user", "secret", nil)
+	if !aws.Net.TLS.Enable || !aws.Net.SASL.Enable {
+		t.Fatalf("expected aws producer to use TLS and SASL, got tls=%v sasl=%v", aws.Net.TLS.Enable, aws.Net.SASL.Enable)
+	}
+	if aws.Net.SASL.Mechanism != SASLMechanismPlain || aws.Net.SASL.User != "user" || aws.Net.SASL.Password != "secret" {
+		t.Fatalf("unexpected SASL settings: %+v", aws.Net.SASL)
+	}
+	awsConsumer := NewAWSConsumerConfig("user", "secret", nil)
+	if !awsConsumer.Net.TLS.Enable || !awsConsumer.Net.SASL.Enable || awsConsumer.Net.SASL.User != "user" {
+		t.Fatal("expected aws consumer to use TLS and SASL")
+	}
+}
+
+func TestSecurity_Combinations(t *testing.T) {
+	tests := []struct {
+		name      string
+		sec       SecurityConfig
+		wantTLS   bool
+		wantSASL  bool
+		mechanism sarama.SASLMechanism
+		scram     bool
+	}{
+		{"plaintext", SecurityConfig{}, false, false, "", false},
+		{"tls only", SecurityConfig{TLS: Bool(true)}, true, false, "", false},
+		{"sasl plain", SecurityConfig{SASL: Bool(true)}, false, true, SASLMechanismPlain, false},
+		{"tls and scram-sha-256", SecurityConfig{TLS: Bool(true), SASL: Bool(true), SASLMechanism: SASLMechanismSCRAMSHA256}, true, true, SASLMechanismSCRAMSHA256, true},
+		{"scram-sha-512", SecurityConfig{SASL: Bool(true), SASLMechanism: SASLMechanismSCRAMSHA512}, false, true, SASLMechanismSCRAMSHA512, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewLocalConsumerConfig(&LocalConfig{Username: "u", Password: "p", Security: tt.sec}, nil)
+			if cfg.Net.TLS.Enable != tt.wantTLS || cfg.Net.SASL.Enable != tt.wantSASL {
+				t.Fatalf("got tls=%v sasl=%v", cfg.Net.TLS.Enable, cfg.Net.SASL.Enable)
+			}
+			if tt.wantSASL && cfg.Net.SASL.Mechanism != tt.mechanism {
+				t.Fatalf("expected mechanism %s, got %s", tt.mechanism, cfg.Net.SASL.Mechanism)
+			}
+			if (cfg.Net.SASL.SCRAMClientGeneratorFunc != nil) != tt.scram {
+				t.Fatalf("unexpected SCRAM generator presence: %v", cfg.Net.SASL.SCRAMClientGeneratorFunc != nil)
+			}
+			if tt.wantSASL {
+				if err := cfg.Validate(); err != nil {
+					t.Fatalf("generated config is invalid: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurity_OptionsOverrideProvider(t *testing.T) {
+	custom := &tls.Config{ServerName: "broker.internal"}
+	opts := &BaseProducerOptions{Security: &SecurityConfig{SASL: Bool(false), TLSConfig: custom}}
+
+	cfg := NewAWSProducerConfig("user", "secret", opts)
+	if !cfg.Net.TLS.Enable || cfg.Net.SASL.Enable {
+		t.Fatalf("expected TLS without SASL, got tls=%v sasl=%v", cfg.Net.TLS.Enable, cfg.Net.SASL.Enable)
+	}
+	if cfg.Net.TLS.Config != custom {
+		t.Fatal("expected the custom TLS config to be used")
+	}
+
+	consumer := NewAWSConsumerConfig("user", "secret", &BaseConsumerOptions{Security: &SecurityConfig{TLS: Bool(false)}})
+	if consumer.Net.TLS.Enable || !consumer.Net.SASL.Enable {
+		t.Fatalf("expected SASL without TLS, got tls=%v sasl=%v", consumer.Net.TLS.Enable, consumer.Net.SASL.Enable)
+	}
+}
+
+func TestNewBrokerProviderConfig_WithSecurityField(t *testing.T) {
+	t.Setenv("AWS_BROKER_SASL", "broker:9096")
+	cfg, err := NewBrokerProviderConfig("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GetBrokerSasl() != "broker:9096" {
+		t.Fatalf("unexpected broker %q", cfg.GetBrokerSasl())
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir)
+
+	cfg, err := NewTLSConfig(certFile, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 {
+		t.Fatalf("expected CA pool and client certificate, got %+v", cfg)
+	}
+
+	if _, err := NewTLSConfig(filepath.Join(dir, "missing.pem"), "", ""); err == nil {
+		t.Fatal("expected error for missing CA bundle")
+	}
+	if _, err := NewTLSConfig(keyFile, "", ""); err == nil {
+		t.Fatal("expected error for a CA bundle without certificates")
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate and its key
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// RFC 7677 section 3 test vector
+func TestSCRAMClient_SHA256(t *testing.T) {
+	c := &scramClient{hash: sha256.New, nonce: func() (string, error) { return "rOprNGfwEbeRWgbNEkqO", nil }}
+	if err := c.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := c.Step("")
+	if err != nil || first != "n,,n=user,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Fatalf("unexpected client-first %q, %v", first, err)
+	}
+
+	final, err := c.Step("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	if err != nil || final != want {
+		t.Fatalf("unexpected client-final %q, %v", final, err)
+	}
+
+	if _, err := c.Step("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err != nil {
+		t.Fatalf("server signature rejected: %v", err)
+	}
+	if !c.Done() {
+		t.Fatal("expected conversation to be done")
+	}
+}
+
+func TestSCRAMClient_RejectsBadServer(t *testing.T) {
+	c := &scramClient{hash: sha256.New, nonce: func() (string, error) { return "abc", nil }}
+	_ = c.Begin("user", "pencil", "")
+	_, _ = c.Step("")
+
+	if _, err := c.Step("r=xyz,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err == nil {
+		t.Fatal("expected error for a server nonce not extending the client nonce")
+	}
+
+	c = &scramClient{hash: sha256.New, nonce: func() (string, error) { return "abc", nil }}
+	_ = c.Begin("user", "pencil", "")
+	_, _ = c.Step("")
+	if _, err := c.Step("r=abcdef,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Step("v=AAAA"); err == nil {
+		t.Fatal("expected server signature mismatch")
+	}
+}
@@ -0,0 +1,305 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bignyap/go-utilities/server"
+	"google.golang.org/protobuf/proto"
+)
+
+// ++++++++++++++++++    SERIALIZATION   +++++++++++++++++++++
+
+// Serializer encodes a message value for a topic
+type Serializer interface {
+	Serialize(topic string, v interface{}) ([]byte, error)
+}
+
+// Deserializer decodes a message value read from a topic
+type Deserializer interface {
+	Deserialize(topic string, data []byte) (interface{}, error)
+}
+
+// JSONSerializer is the default serializer
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(_ string, v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// JSONDeserializer decodes JSON values. New returns a pointer to decode into;
+// when nil, values decode into generic maps and slices.
+type JSONDeserializer struct {
+	New func() interface{}
+}
+
+func (d JSONDeserializer) Deserialize(_ string, data []byte) (interface{}, error) {
+	if d.New == nil {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	v := d.New()
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ++++++++++++++++++    SCHEMA REGISTRY   +++++++++++++++++++++
+
+// wireMagicByte prefixes every schema registry framed message
+const wireMagicByte byte = 0
+
+// EncodeWireFormat frames payload as magic byte + 4-byte big-endian schema ID + payload
+func EncodeWireFormat(schemaID int, payload []byte) []byte {
+	out := make([]byte, 5+len(payload))
+	out[0] = wireMagicByte
+	binary.BigEndian.PutUint32(out[1:5], uint32(schemaID))
+	copy(out[5:], payload)
+	return out
+}
+
+// DecodeWireFormat splits a framed message into its schema ID and payload
+func DecodeWireFormat(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != wireMagicByte {
+		return 0, nil, server.NewError(server.ErrorBadRequest, "message is not in schema registry wire format", nil)
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
+
+// SchemaRegistry looks up schemas registered in a Confluent compatible registry
+type SchemaRegistry interface {
+	// LatestSchemaID returns the ID of the latest schema version for subject
+	LatestSchemaID(subject string) (int, error)
+	// Schema returns the schema definition registered under id
+	Schema(id int) (string, error)
+}
+
+// SchemaRegistryConfig configures SchemaRegistryClient
+type SchemaRegistryConfig struct {
+	URL      string        `json:"url" env:"SCHEMA_REGISTRY_URL"`
+	Username string        `json:"username" env:"SCHEMA_REGISTRY_USERNAME"`
+	Password string        `json:"password" env:"SCHEMA_REGISTRY_PASSWORD"`
+	Timeout  time.Duration `json:"timeout" env:"SCHEMA_REGISTRY_TIMEOUT"`
+}
+
+// SchemaRegistryClient is a minimal REST client for the Confluent Schema Registry.
+// Schema IDs are immutable, so lookups are cached for the life of the client.
+type SchemaRegistryClient struct {
+	cfg        SchemaRegistryConfig
+	httpClient *http.Client
+
+	mu       sync.RWMutex
+	subjects map[string]int
+	schemas  map[int]string
+}
+
+// Ensure SchemaRegistryClient implements SchemaRegistry
+var _ SchemaRegistry = (*SchemaRegistryClient)(nil)
+
+func NewSchemaRegistryClient(cfg SchemaRegistryConfig) *SchemaRegistryClient {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &SchemaRegistryClient{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		subjects:   make(map[string]int),
+		schemas:    make(map[int]string),
+	}
+}
+
+func (c *SchemaRegistryClient) LatestSchemaID(subject string) (int, error) {
+	c.mu.RLock()
+	id, ok := c.subjects[subject]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	var resp struct {
+		ID     int    `json:"id"`
+		Schema string `json:"schema"`
+	}
+	if err := c.get("/subjects/"+url.PathEscape(subject)+"/versions/latest", &resp); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.subjects[subject] = resp.ID
+	c.schemas[resp.ID] = resp.Schema
+	c.mu.Unlock()
+	return resp.ID, nil
+}
+
+func (c *SchemaRegistryClient) Schema(id int) (string, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var resp struct {
+		Schema string `json:"schema"`
+	}
+	if err := c.get(fmt.Sprintf("/schemas/ids/%d", id), &resp); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = resp.Schema
+	c.mu.Unlock()
+	return resp.Schema, nil
+}
+
+func (c *SchemaRegistryClient) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.cfg.URL+path, nil)
+	if err != nil {
+		return server.NewError(server.ErrorInternal, "failed to build schema registry request", err)
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return server.NewError(server.ErrorInternal, "schema registry request failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return server.NewError(
+			server.ErrorInternal,
+			fmt.Sprintf("schema registry returned %d for %s", resp.StatusCode, path),
+			fmt.Errorf("%s", body),
+		)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return server.NewError(server.ErrorInternal, "failed to decode schema registry response", err)
+	}
+	return nil
+}
+
+// TopicValueSubject is the default TopicNameStrategy subject for message values
+func TopicValueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// RegistrySerializer encodes values with Encode and frames them with the
+// schema ID registered for the topic's subject
+type RegistrySerializer struct {
+	Registry SchemaRegistry
+	Encode   func(v interface{}) ([]byte, error)
+	// Subject maps a topic to its registry subject (default TopicValueSubject)
+	Subject func(topic string) string
+	// prefix is written between the schema ID and the payload
+	prefix []byte
+}
+
+// NewAvroSerializer frames Avro payloads. encode converts a value to Avro
+// binary, typically via a codec built from the subject's schema.
+func NewAvroSerializer(registry SchemaRegistry, encode func(v interface{}) ([]byte, error)) *RegistrySerializer {
+	return &RegistrySerializer{Registry: registry, Encode: encode}
+}
+
+// NewProtobufSerializer frames proto.Message values. Only the first message
+// type of a schema is supported, written as the single 0 message index.
+func NewProtobufSerializer(registry SchemaRegistry) *RegistrySerializer {
+	return &RegistrySerializer{
+		Registry: registry,
+		Encode: func(v interface{}) ([]byte, error) {
+			m, ok := v.(proto.Message)
+			if !ok {
+				return nil, fmt.Errorf("protobuf serializer: %T is not a proto.Message", v)
+			}
+			return proto.Marshal(m)
+		},
+		prefix: []byte{0},
+	}
+}
+
+func (s *RegistrySerializer) Serialize(topic string, v interface{}) ([]byte, error) {
+	subject := TopicValueSubject(topic)
+	if s.Subject != nil {
+		subject = s.Subject(topic)
+	}
+	id, err := s.Registry.LatestSchemaID(subject)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := s.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeWireFormat(id, append(append([]byte{}, s.prefix...), payload...)), nil
+}
+
+// RegistryDeserializer strips the wire format framing and hands the payload
+// and its schema ID to Decode
+type RegistryDeserializer struct {
+	Decode func(schemaID int, payload []byte) (interface{}, error)
+	// skipIndex drops the protobuf message index preceding the payload
+	skipIndex bool
+}
+
+// NewAvroDeserializer decodes framed Avro payloads; decode typically resolves
+// the writer schema through SchemaRegistry.Schema
+func NewAvroDeserializer(decode func(schemaID int, payload []byte) (interface{}, error)) *RegistryDeserializer {
+	return &RegistryDeserializer{Decode: decode}
+}
+
+// NewProtobufDeserializer decodes framed payloads into messages returned by newMessage
+func NewProtobufDeserializer(newMessage func() proto.Message) *RegistryDeserializer {
+	return &RegistryDeserializer{
+		Decode: func(_ int, payload []byte) (interface{}, error) {
+			m := newMessage()
+			if err := proto.Unmarshal(payload, m); err != nil {
+				return nil, err
+			}
+			return m, nil
+		},
+		skipIndex: true,
+	}
+}
+
+func (d *RegistryDeserializer) Deserialize(_ string, data []byte) (interface{}, error) {
+	id, payload, err := DecodeWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	if d.skipIndex {
+		if payload, err = skipMessageIndexes(payload); err != nil {
+			return nil, err
+		}
+	}
+	return d.Decode(id, payload)
+}
+
+// skipMessageIndexes drops the varint encoded message index array that
+// precedes protobuf payloads
+func skipMessageIndexes(payload []byte) ([]byte, error) {
+	count, n := binary.Varint(payload)
+	if n <= 0 {
+		return nil, server.NewError(server.ErrorBadRequest, "invalid protobuf message index", nil)
+	}
+	payload = payload[n:]
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(payload); n <= 0 {
+			return nil, server.NewError(server.ErrorBadRequest, "invalid protobuf message index", nil)
+		}
+		payload = payload[n:]
+	}
+	return payload, nil
+}
@@ -0,0 +1,259 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeSerializer encodes values as "<topic>:<value>"
+type fakeSerializer struct {
+	calls int
+}
+
+func (s *fakeSerializer) Serialize(topic string, v interface{}) ([]byte, error) {
+	s.calls++
+	str, ok := v.(string)
+	if !ok {
+		return nil, errors.New("fake serializer only handles strings")
+	}
+	return []byte(topic + ":" + str), nil
+}
+
+// fakeDeserializer reverses fakeSerializer
+type fakeDeserializer struct{}
+
+func (fakeDeserializer) Deserialize(topic string, data []byte) (interface{}, error) {
+	value, ok := strings.CutPrefix(string(data), topic+":")
+	if !ok {
+		return nil, errors.New("unexpected payload")
+	}
+	return value, nil
+}
+
+// fakeRegistry serves a fixed schema ID
+type fakeRegistry struct {
+	id       int
+	subjects []string
+}
+
+func (r *fakeRegistry) LatestSchemaID(subject string) (int, error) {
+	r.subjects = append(r.subjects, subject)
+	return r.id, nil
+}
+
+func (r *fakeRegistry) Schema(int) (string, error) { return "", nil }
+
+func TestBaseProducer_UsesSerializer(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	ser := &fakeSerializer{}
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+	bp.SetSerializer(ser)
+
+	if err := bp.SendMessage("paid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ser.calls != 1 {
+		t.Fatalf("expected serializer to be called once, got %d", ser.calls)
+	}
+	value, _ := sent.Value.Encode()
+	if string(value) != "orders:paid" {
+		t.Fatalf("unexpected value %s", value)
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+func TestBaseProducer_SerializerError(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+	bp.SetSerializer(&fakeSerializer{})
+
+	if err := bp.SendMessage(42); err == nil {
+		t.Fatal("expected serializer error")
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer expectations not met: %v", err)
+	}
+}
+
+func TestConsumer_DecodesWithDeserializer(t *testing.T) {
+	var got []interface{}
+	h := &consumerGroupHandler{
+		handler: decodeWith(fakeDeserializer{}, func(msg *sarama.ConsumerMessage, value interface{}) error {
+			got = append(got, value)
+			return nil
+		}),
+	}
+	sess := &fakeSession{ctx: context.Background()}
+	msg := &sarama.ConsumerMessage{Topic: "orders", Offset: 1, Value: []byte("orders:paid")}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(msg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "paid" {
+		t.Fatalf("expected decoded value paid, got %v", got)
+	}
+}
+
+func TestConsumer_DeserializeErrorGoesThroughPolicy(t *testing.T) {
+	called := false
+	var policyErr error
+	h := &consumerGroupHandler{
+		handler: decodeWith(fakeDeserializer{}, func(*sarama.ConsumerMessage, interface{}) error {
+			called = true
+			return nil
+		}),
+		policy: ErrorPolicy{OnError: func(_ *sarama.ConsumerMessage, err error) ErrorAction {
+			policyErr = err
+			return Skip()
+		}},
+	}
+	sess := &fakeSession{ctx: context.Background()}
+	msg := &sarama.ConsumerMessage{Topic: "orders", Offset: 1, Value: []byte("garbage")}
+
+	if err := h.ConsumeClaim(sess, newFakeClaim(msg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if called {
+		t.Fatal("handler should not run for undecodable messages")
+	}
+	if policyErr == nil || len(sess.marked) != 1 {
+		t.Fatalf("expected skipped message via policy, err=%v marked=%v", policyErr, sess.marked)
+	}
+}
+
+func TestDecodeWith_DefaultsToJSON(t *testing.T) {
+	var got interface{}
+	handler := decodeWith(nil, func(_ *sarama.ConsumerMessage, value interface{}) error {
+		got = value
+		return nil
+	})
+	if err := handler(&sarama.ConsumerMessage{Value: []byte(`{"status":"paid"}`)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, ok := got.(map[string]interface{})
+	if !ok || m["status"] != "paid" {
+		t.Fatalf("unexpected decoded value %#v", got)
+	}
+}
+
+func TestWireFormat_RoundTrip(t *testing.T) {
+	framed := EncodeWireFormat(258, []byte("payload"))
+	if framed[0] != 0 || framed[1] != 0 || framed[2] != 0 || framed[3] != 1 || framed[4] != 2 {
+		t.Fatalf("unexpected header % x", framed[:5])
+	}
+
+	id, payload, err := DecodeWireFormat(framed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 258 || string(payload) != "payload" {
+		t.Fatalf("got id %d payload %q", id, payload)
+	}
+
+	if _, _, err := DecodeWireFormat([]byte{1, 0, 0, 0, 1}); err == nil {
+		t.Fatal("expected error for wrong magic byte")
+	}
+	if _, _, err := DecodeWireFormat([]byte{0, 0}); err == nil {
+		t.Fatal("expected error for short message")
+	}
+}
+
+func TestAvroSerializer_FramesPayload(t *testing.T) {
+	registry := &fakeRegistry{id: 7}
+	ser := NewAvroSerializer(registry, func(v interface{}) ([]byte, error) {
+		return []byte(v.(string)), nil
+	})
+
+	data, err := ser.Serialize("orders", "avro-bytes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(registry.subjects) != 1 || registry.subjects[0] != "orders-value" {
+		t.Fatalf("unexpected subject lookups %v", registry.subjects)
+	}
+
+	de := NewAvroDeserializer(func(schemaID int, payload []byte) (interface{}, error) {
+		if schemaID != 7 {
+			t.Fatalf("expected schema id 7, got %d", schemaID)
+		}
+		return string(payload), nil
+	})
+	value, err := de.Deserialize("orders", data)
+	if err != nil || value != "avro-bytes" {
+		t.Fatalf("got %v, %v", value, err)
+	}
+}
+
+func TestProtobufSerializer_RoundTrip(t *testing.T) {
+	ser := NewProtobufSerializer(&fakeRegistry{id: 3})
+	data, err := ser.Serialize("orders", wrapperspb.String("paid"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// magic byte, schema ID, then the single-byte message index
+	if data[4] != 3 || data[5] != 0 {
+		t.Fatalf("unexpected framing % x", data[:6])
+	}
+
+	de := NewProtobufDeserializer(func() proto.Message { return &wrapperspb.StringValue{} })
+	value, err := de.Deserialize("orders", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := value.(*wrapperspb.StringValue).GetValue(); got != "paid" {
+		t.Fatalf("expected paid, got %q", got)
+	}
+
+	if _, err := ser.Serialize("orders", "not a proto"); err == nil {
+		t.Fatal("expected error for non-proto value")
+	}
+}
+
+func TestSchemaRegistryClient_CachesLookups(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/subjects/orders-value/versions/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"subject":"orders-value","version":1,"id":12,"schema":"\"string\""}`))
+	}))
+	defer srv.Close()
+
+	client := NewSchemaRegistryClient(SchemaRegistryConfig{URL: srv.URL + "/"})
+	for i := 0; i < 2; i++ {
+		id, err := client.LatestSchemaID("orders-value")
+		if err != nil || id != 12 {
+			t.Fatalf("got %d, %v", id, err)
+		}
+	}
+	schema, err := client.Schema(12)
+	if err != nil || schema != `"string"` {
+		t.Fatalf("got %q, %v", schema, err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 registry request, got %d", requests)
+	}
+
+	if _, err := client.LatestSchemaID("missing-value"); err == nil {
+		t.Fatal("expected error for unknown subject")
+	}
+}
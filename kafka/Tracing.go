@@ -0,0 +1,142 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"github.com/bignyap/go-utilities/otel/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of kafka spans
+const TracerName = "github.com/bignyap/go-utilities/kafka"
+
+// ContextHandlerFunc is a HandlerFunc that receives the message's trace context
+type ContextHandlerFunc func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+// SetTracing wraps each send in a kafka.produce span and injects its context
+// into the message headers with the global propagator. Must be called before
+// sending. SendRaw messages are sent as-is.
+func (bp *BaseProducer) SetTracing(provider api.Provider) {
+	bp.tracer = provider.Tracer(TracerName)
+}
+
+// traceSend runs send inside a kafka.produce span when tracing is enabled
+func (bp *BaseProducer) traceSend(ctx context.Context, pm *sarama.ProducerMessage, send func() error) error {
+	if bp.tracer == nil {
+		return send()
+	}
+	if pm.Topic == "" {
+		pm.Topic = bp.topic
+	}
+	ctx, span := bp.tracer.Start(ctx, "kafka.produce",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", pm.Topic),
+		),
+	)
+	defer span.End()
+
+	InjectTraceContext(ctx, pm)
+	err := send()
+	api.RecordError(ctx, err)
+	return err
+}
+
+// TracingHandler continues the producer's trace for each message: it
+// extracts the trace context from the headers, starts a kafka.consume span
+// and passes its context to handler
+func TracingHandler(provider api.Provider, handler ContextHandlerFunc) HandlerFunc {
+	tracer := provider.Tracer(TracerName)
+	return func(msg *sarama.ConsumerMessage) error {
+		ctx, span := tracer.Start(TraceContext(context.Background(), msg), "kafka.consume",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "kafka"),
+				attribute.String("messaging.destination", msg.Topic),
+				attribute.Int("messaging.partition", int(msg.Partition)),
+				attribute.Int64("messaging.offset", msg.Offset),
+			),
+		)
+		defer span.End()
+
+		err := handler(ctx, msg)
+		api.RecordError(ctx, err)
+		return err
+	}
+}
+
+// InjectTraceContext writes ctx's trace context into msg's headers,
+// replacing headers of the same name
+func InjectTraceContext(ctx context.Context, msg *sarama.ProducerMessage) {
+	otel.GetTextMapPropagator().Inject(ctx, producerCarrier{msg})
+}
+
+// TraceContext returns ctx with the trace context carried in msg's headers
+func TraceContext(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, consumerCarrier(msg.Headers))
+}
+
+// producerCarrier adapts producer message headers to a TextMapCarrier
+type producerCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+var _ propagation.TextMapCarrier = producerCarrier{}
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// consumerCarrier adapts consumer message headers to a read-only TextMapCarrier
+type consumerCarrier []*sarama.RecordHeader
+
+var _ propagation.TextMapCarrier = consumerCarrier{}
+
+func (c consumerCarrier) Get(key string) string {
+	for _, h := range c {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerCarrier) Set(string, string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, h := range c {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
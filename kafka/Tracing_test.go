@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	otelapi "github.com/bignyap/go-utilities/otel/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerProvider is a minimal otel api.Provider recording spans
+type tracerProvider struct {
+	tp *sdktrace.TracerProvider
+}
+
+func (p tracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return p.tp.Tracer(name, opts...)
+}
+
+func (p tracerProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return noop.NewMeterProvider().Meter(name, opts...)
+}
+
+func (p tracerProvider) Shutdown(ctx context.Context) error { return p.tp.Shutdown(ctx) }
+
+func TestTracing_ProduceAndConsumeContinueTrace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(otelapi.Propagator())
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	spans := tracetest.NewSpanRecorder()
+	provider := tracerProvider{tp: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))}
+
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+	bp := &BaseProducer{producer: producer, topic: "orders"}
+	bp.SetTracing(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if err := bp.SendMessageContext(ctx, map[string]string{"status": "paid"}); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	consumed := &sarama.ConsumerMessage{Topic: "orders", Offset: 7, Value: []byte(`{}`)}
+	var traceparent string
+	for _, h := range sent.Headers {
+		if string(h.Key) == "traceparent" {
+			traceparent = string(h.Value)
+		}
+		consumed.Headers = append(consumed.Headers, &sarama.RecordHeader{Key: h.Key, Value: h.Value})
+	}
+	traceID := parent.SpanContext().TraceID().String()
+	if !strings.Contains(traceparent, traceID) {
+		t.Fatalf("expected a traceparent header for trace %s, got %q", traceID, traceparent)
+	}
+
+	var handlerSpan trace.SpanContext
+	handler := TracingHandler(provider, func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil
+	})
+	if err := handler(consumed); err != nil {
+		t.Fatal(err)
+	}
+	if handlerSpan.TraceID().String() != traceID {
+		t.Fatalf("expected the consumer to continue trace %s, got %s", traceID, handlerSpan.TraceID())
+	}
+
+	ended := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans.Ended() {
+		ended[s.Name()] = s
+	}
+	produce, consume := ended["kafka.produce"], ended["kafka.consume"]
+	if produce == nil || consume == nil {
+		t.Fatalf("expected produce and consume spans, got %v", ended)
+	}
+	if produce.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("expected kafka.produce to be a child of the caller's span")
+	}
+	if consume.Parent().SpanID() != produce.SpanContext().SpanID() || !consume.Parent().IsRemote() {
+		t.Fatal("expected kafka.consume to be a remote child of kafka.produce")
+	}
+}
+
+func TestTracing_ConsumeErrorIsRecorded(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := tracerProvider{tp: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))}
+
+	handler := TracingHandler(provider, func(context.Context, *sarama.ConsumerMessage) error { return errHandler })
+	if err := handler(testMessage(1)); err != errHandler {
+		t.Fatalf("expected the handler error to be returned, got %v", err)
+	}
+	if ended := spans.Ended(); len(ended) != 1 || ended[0].Status().Description != errHandler.Error() {
+		t.Fatalf("expected one span with the error status, got %v", ended)
+	}
+}
@@ -0,0 +1,84 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/bignyap/go-utilities/counter"
+	"github.com/bignyap/go-utilities/server"
+	"github.com/bignyap/go-utilities/websocket"
+)
+
+// HTTPServer serves s in the background and shuts it down gracefully.
+// Add it last so it stops first and no new requests reach the other components.
+func HTTPServer(s *server.HTTPServer) Component {
+	return Component{
+		Name:  "http-server",
+		Start: func(context.Context) error { return s.Listen() },
+		Stop:  s.Shutdown,
+	}
+}
+
+// Hub runs the hub's event loop and closes every client on stop
+func Hub(h *websocket.Hub) Component {
+	return Component{
+		Name: "ws-hub",
+		Start: func(context.Context) error {
+			go h.Run()
+			return nil
+		},
+		Stop: h.Shutdown,
+	}
+}
+
+// Consumer runs a blocking consume loop, such as a bound BaseConsumer.Start,
+// until stop, then closes the consumer and waits for the loop to return
+//
+//	lifecycle.Consumer("orders", func(ctx context.Context) error {
+//		return consumer.Start(ctx, "orders", handler)
+//	}, consumer)
+func Consumer(name string, run func(ctx context.Context) error, closer io.Closer) Component {
+	var (
+		cancel context.CancelFunc
+		done   chan error
+	)
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			// The loop outlives Start's ctx and is canceled by Stop
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			done = make(chan error, 1)
+			go func() { done <- run(runCtx) }()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			closeErr := closer.Close()
+			select {
+			case err := <-done:
+				if errors.Is(err, context.Canceled) {
+					err = nil
+				}
+				return errors.Join(err, closeErr)
+			case <-ctx.Done():
+				return errors.Join(ctx.Err(), closeErr)
+			}
+		},
+	}
+}
+
+// CounterWorker runs the worker loop and flushes pending counts on stop.
+// Add it before the components that increment it so it stops after them.
+func CounterWorker(cw *counter.CounterWorker) Component {
+	return Component{
+		Name: "counter-worker",
+		Start: func(ctx context.Context) error {
+			// Flushes must keep working after Start's ctx is done
+			go cw.Start(context.WithoutCancel(ctx))
+			return nil
+		},
+		Stop: cw.Shutdown,
+	}
+}
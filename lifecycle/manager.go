@@ -0,0 +1,162 @@
+// Package lifecycle starts application components in order and stops them in
+// reverse, so e.g. the HTTP server stops accepting requests before the
+// consumers and workers behind it are closed.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/api"
+)
+
+// DefaultStopTimeout bounds each component's Stop unless overridden
+const DefaultStopTimeout = 10 * time.Second
+
+// Component is a unit managed by a Manager
+type Component struct {
+	Name string
+	// Start must not block; long-running work belongs in a goroutine.
+	// A nil Start is a no-op.
+	Start func(ctx context.Context) error
+	// Stop is given a context bounded by StopTimeout. A nil Stop is a no-op.
+	Stop func(ctx context.Context) error
+	// StopTimeout overrides the manager's stop timeout for this component
+	StopTimeout time.Duration
+}
+
+// Manager starts components in the order they were added and stops them in
+// reverse order
+type Manager struct {
+	mu          sync.Mutex
+	components  []Component
+	started     int
+	stopTimeout time.Duration
+	logger      api.Logger
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithLogger sets the logger used to report start and stop progress
+func WithLogger(logger api.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// WithStopTimeout sets the default per-component stop timeout
+func WithStopTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.stopTimeout = d
+	}
+}
+
+// NewManager creates a Manager with no components
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{stopTimeout: DefaultStopTimeout}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = &api.DefaultLogger{}
+	}
+	m.logger = m.logger.WithComponent("lifecycle")
+	return m
+}
+
+// Add appends components. They start after those already added and stop before them.
+func (m *Manager) Add(components ...Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, components...)
+}
+
+// Start starts every component in order. If one fails, the components already
+// started are stopped in reverse and the start error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components[m.started:]
+	m.mu.Unlock()
+
+	for _, c := range components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				m.logger.Error(ctx, "Component failed to start", err, api.String("component", c.Name))
+				startErr := fmt.Errorf("lifecycle: start %s: %w", c.Name, err)
+				return errors.Join(startErr, m.Stop(context.Background()))
+			}
+		}
+		m.mu.Lock()
+		m.started++
+		m.mu.Unlock()
+		m.logger.Info(ctx, "Component started", api.String("component", c.Name))
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Each Stop gets its own
+// timeout; a component that fails or times out is reported in the returned
+// error and does not keep the rest from stopping.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components[:m.started]
+	m.started = 0
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if c.Stop == nil {
+			continue
+		}
+		if err := m.stopOne(ctx, c); err != nil {
+			m.logger.Error(ctx, "Component failed to stop", err, api.String("component", c.Name))
+			errs = append(errs, fmt.Errorf("lifecycle: stop %s: %w", c.Name, err))
+			continue
+		}
+		m.logger.Info(ctx, "Component stopped", api.String("component", c.Name))
+	}
+	return errors.Join(errs...)
+}
+
+// stopOne runs c.Stop, giving up once its timeout passes even if Stop ignores ctx
+func (m *Manager) stopOne(ctx context.Context, c Component) error {
+	timeout := c.StopTimeout
+	if timeout <= 0 {
+		timeout = m.stopTimeout
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- c.Stop(stopCtx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-stopCtx.Done():
+		return stopCtx.Err()
+	}
+}
+
+// Run starts every component, waits for ctx to be canceled or for SIGINT or
+// SIGTERM, then stops them
+func (m *Manager) Run(ctx context.Context) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-sigCtx.Done()
+
+	m.logger.Info(context.Background(), "Shutdown signal received")
+	return m.Stop(context.Background())
+}
@@ -0,0 +1,157 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects start/stop calls in the order they happen
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func (r *recorder) component(name string, startErr, stopErr error) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			r.record("stop " + name)
+			return stopErr
+		},
+	}
+}
+
+func TestManager_StartStopOrder(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager()
+	m.Add(rec.component("counter", nil, nil), rec.component("hub", nil, nil))
+	m.Add(rec.component("server", nil, nil))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	want := []string{
+		"start counter", "start hub", "start server",
+		"stop server", "stop hub", "stop counter",
+	}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestManager_StopErrorDoesNotBlockOthers(t *testing.T) {
+	rec := &recorder{}
+	boom := errors.New("boom")
+	m := NewManager(WithStopTimeout(time.Second))
+	m.Add(
+		rec.component("first", nil, nil),
+		Component{
+			Name: "hung",
+			Stop: func(context.Context) error {
+				rec.record("stop hung")
+				select {} // ignores ctx
+			},
+			StopTimeout: 20 * time.Millisecond,
+		},
+		rec.component("failing", nil, boom),
+	)
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	err := m.Stop(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected stop error to wrap boom, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected stop error to report the timeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "failing") || !strings.Contains(err.Error(), "hung") {
+		t.Fatalf("error should name the components: %v", err)
+	}
+
+	want := []string{"start first", "start failing", "stop failing", "stop hung", "stop first"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestManager_StartFailureStopsStarted(t *testing.T) {
+	rec := &recorder{}
+	boom := errors.New("boom")
+	m := NewManager()
+	m.Add(
+		rec.component("a", nil, nil),
+		rec.component("b", boom, nil),
+		rec.component("c", nil, nil),
+	)
+
+	if err := m.Start(context.Background()); !errors.Is(err, boom) {
+		t.Fatalf("expected start error, got %v", err)
+	}
+
+	want := []string{"start a", "start b", "stop a"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+
+	// Nothing is left running, so a second Stop is a no-op
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if got := rec.get(); len(got) != len(want) {
+		t.Fatalf("unexpected calls after Stop: %v", got)
+	}
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestConsumer_StopCancelsLoopAndCloses(t *testing.T) {
+	closed := false
+	c := Consumer("orders", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, closerFunc(func() error {
+		closed = true
+		return nil
+	}))
+
+	m := NewManager()
+	m.Add(c)
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !closed {
+		t.Fatal("consumer was not closed")
+	}
+}
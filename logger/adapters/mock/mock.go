@@ -2,27 +2,51 @@ package mock
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/bignyap/go-utilities/logger/api"
 )
 
-// Mock implements the Logger interface for testing purposes
+// Level is the severity a LogEntry was recorded at
+type Level string
+
+const (
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+	LevelFatal Level = "fatal"
+)
+
+// Mock implements the Logger interface for testing purposes. Loggers derived
+// with WithFields, WithComponent and the like keep their own fields but record
+// into the same store, so the root logger sees every message.
 type Mock struct {
+	once      sync.Once
+	rec       *recorder
+	component string
+	fields    []api.Field
+	traceID   string
+}
+
+// recorder holds the messages of a logger and all loggers derived from it
+type recorder struct {
 	mu             sync.Mutex
+	entries        []LogEntry
 	debugMessages  []LogEntry
 	infoMessages   []LogEntry
 	warnMessages   []LogEntry
 	errorMessages  []LogEntry
 	fatalMessages  []LogEntry
-	component      string
-	fields         []api.Field
-	traceID        string
 	lastFatalError error
 }
 
-// LogEntry represents a logged message
+// LogEntry represents a logged message. Fields holds the logger's own
+// fields (from WithFields) followed by those passed to the call.
 type LogEntry struct {
+	Level   Level
 	Message string
 	Error   error
 	Fields  []api.Field
@@ -30,113 +54,102 @@ type LogEntry struct {
 
 // NewMockLogger creates a new mock logger
 func NewMockLogger() *Mock {
-	return &Mock{
-		debugMessages: []LogEntry{},
-		infoMessages:  []LogEntry{},
-		warnMessages:  []LogEntry{},
-		errorMessages: []LogEntry{},
-		fatalMessages: []LogEntry{},
-		fields:        []api.Field{},
+	return &Mock{rec: &recorder{}, fields: []api.Field{}}
+}
+
+// store returns the shared recorder, creating it for a zero-value Mock
+func (m *Mock) store() *recorder {
+	m.once.Do(func() {
+		if m.rec == nil {
+			m.rec = &recorder{}
+		}
+	})
+	return m.rec
+}
+
+// record appends an entry to its level's messages and to the ordered log
+func (m *Mock) record(level Level, msg string, err error, fields []api.Field) {
+	entry := LogEntry{
+		Level:   level,
+		Message: msg,
+		Error:   err,
+		Fields:  append(slices.Clone(m.fields), fields...),
+	}
+
+	r := m.store()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	switch level {
+	case LevelDebug:
+		r.debugMessages = append(r.debugMessages, entry)
+	case LevelInfo:
+		r.infoMessages = append(r.infoMessages, entry)
+	case LevelWarn:
+		r.warnMessages = append(r.warnMessages, entry)
+	case LevelError:
+		r.errorMessages = append(r.errorMessages, entry)
+	case LevelFatal:
+		r.lastFatalError = err
+		r.fatalMessages = append(r.fatalMessages, entry)
 	}
 }
 
 // Debug logs a debug message
 func (m *Mock) Debug(ctx context.Context, msg string, fields ...api.Field) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.debugMessages = append(m.debugMessages, LogEntry{
-		Message: msg,
-		Fields:  fields,
-	})
+	m.record(LevelDebug, msg, nil, fields)
 }
 
 // Info logs an info message
 func (m *Mock) Info(ctx context.Context, msg string, fields ...api.Field) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.infoMessages = append(m.infoMessages, LogEntry{
-		Message: msg,
-		Fields:  fields,
-	})
+	m.record(LevelInfo, msg, nil, fields)
 }
 
 // Warn logs a warning message
 func (m *Mock) Warn(ctx context.Context, msg string, fields ...api.Field) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.warnMessages = append(m.warnMessages, LogEntry{
-		Message: msg,
-		Fields:  fields,
-	})
+	m.record(LevelWarn, msg, nil, fields)
 }
 
 // Error logs an error message
 func (m *Mock) Error(ctx context.Context, msg string, err error, fields ...api.Field) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.errorMessages = append(m.errorMessages, LogEntry{
-		Message: msg,
-		Error:   err,
-		Fields:  fields,
-	})
+	m.record(LevelError, msg, err, fields)
 }
 
 // Fatal logs a fatal message
 func (m *Mock) Fatal(ctx context.Context, msg string, err error, fields ...api.Field) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.lastFatalError = err
-	m.fatalMessages = append(m.fatalMessages, LogEntry{
-		Message: msg,
-		Error:   err,
-		Fields:  fields,
-	})
+	m.record(LevelFatal, msg, err, fields)
 	// Note: In a real logger this would exit the program
 	// For testing we just record it
 }
 
+// child returns a logger with a copy of m's fields that records into m's store
+func (m *Mock) child() *Mock {
+	return &Mock{
+		rec:       m.store(),
+		component: m.component,
+		fields:    slices.Clone(m.fields),
+		traceID:   m.traceID,
+	}
+}
+
 // WithTraceID returns a logger with trace ID set
 func (m *Mock) WithTraceID(traceID string) api.Logger {
-	newLogger := &Mock{
-		debugMessages: m.debugMessages,
-		infoMessages:  m.infoMessages,
-		warnMessages:  m.warnMessages,
-		errorMessages: m.errorMessages,
-		fatalMessages: m.fatalMessages,
-		component:     m.component,
-		fields:        m.fields,
-		traceID:       traceID,
-	}
+	newLogger := m.child()
+	newLogger.traceID = traceID
 	return newLogger
 }
 
 // WithFields returns a logger with fields set
 func (m *Mock) WithFields(fields ...api.Field) api.Logger {
-	newLogger := &Mock{
-		debugMessages: m.debugMessages,
-		infoMessages:  m.infoMessages,
-		warnMessages:  m.warnMessages,
-		errorMessages: m.errorMessages,
-		fatalMessages: m.fatalMessages,
-		component:     m.component,
-		fields:        append(m.fields, fields...),
-		traceID:       m.traceID,
-	}
+	newLogger := m.child()
+	newLogger.fields = append(newLogger.fields, fields...)
 	return newLogger
 }
 
 // WithComponent returns a logger with component name set
 func (m *Mock) WithComponent(component string) api.Logger {
-	newLogger := &Mock{
-		debugMessages: m.debugMessages,
-		infoMessages:  m.infoMessages,
-		warnMessages:  m.warnMessages,
-		errorMessages: m.errorMessages,
-		fatalMessages: m.fatalMessages,
-		component:     component,
-		fields:        m.fields,
-		traceID:       m.traceID,
-	}
+	newLogger := m.child()
+	newLogger.component = component
 	return newLogger
 }
 
@@ -163,59 +176,101 @@ func (m *Mock) ToContext(ctx context.Context) context.Context {
 
 // GetDebugMessages returns all logged debug messages
 func (m *Mock) GetDebugMessages() []LogEntry {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.debugMessages
+	return m.messages(func(r *recorder) []LogEntry { return r.debugMessages })
 }
 
 // GetInfoMessages returns all logged info messages
 func (m *Mock) GetInfoMessages() []LogEntry {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.infoMessages
+	return m.messages(func(r *recorder) []LogEntry { return r.infoMessages })
 }
 
 // GetWarnMessages returns all logged warning messages
 func (m *Mock) GetWarnMessages() []LogEntry {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.warnMessages
+	return m.messages(func(r *recorder) []LogEntry { return r.warnMessages })
 }
 
 // GetErrorMessages returns all logged error messages
 func (m *Mock) GetErrorMessages() []LogEntry {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.errorMessages
+	return m.messages(func(r *recorder) []LogEntry { return r.errorMessages })
 }
 
 // GetFatalMessages returns all logged fatal messages
 func (m *Mock) GetFatalMessages() []LogEntry {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.fatalMessages
+	return m.messages(func(r *recorder) []LogEntry { return r.fatalMessages })
+}
+
+// messages returns a copy of the list picked from the store
+func (m *Mock) messages(pick func(*recorder) []LogEntry) []LogEntry {
+	r := m.store()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(pick(r))
 }
 
 // LastFatalError returns the last fatal error
 func (m *Mock) LastFatalError() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.lastFatalError
+	r := m.store()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastFatalError
 }
 
-// Clear clears all logged messages
+// Clear clears all logged messages, including those of related loggers
 func (m *Mock) Clear() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.debugMessages = []LogEntry{}
-	m.infoMessages = []LogEntry{}
-	m.warnMessages = []LogEntry{}
-	m.errorMessages = []LogEntry{}
-	m.fatalMessages = []LogEntry{}
-	m.lastFatalError = nil
+	r := m.store()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+	r.debugMessages = nil
+	r.infoMessages = nil
+	r.warnMessages = nil
+	r.errorMessages = nil
+	r.fatalMessages = nil
+	r.lastFatalError = nil
 }
 
-// Clear clears all logged messages
+// AllEntries returns every logged message in the order it was logged
+func (m *Mock) AllEntries() []LogEntry {
+	return m.messages(func(r *recorder) []LogEntry { return r.entries })
+}
+
+// ContainsMessage reports whether a message at level contains substr
+func (m *Mock) ContainsMessage(level Level, substr string) bool {
+	for _, entry := range m.AllEntries() {
+		if entry.Level == level && strings.Contains(entry.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldValue returns the value of key in the most recent message at level
+// that has it
+func (m *Mock) FieldValue(level Level, key string) (interface{}, bool) {
+	entries := m.AllEntries()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Level != level {
+			continue
+		}
+		fields := entries[i].Fields
+		for j := len(fields) - 1; j >= 0; j-- {
+			if fields[j].Key == key {
+				return fields[j].Value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// AddField returns a logger with the field appended, like WithFields
 func (m *Mock) AddField(key string, value interface{}) api.Logger {
-	return m
+	return m.WithFields(api.Field{Key: key, Value: value})
+}
+
+// WithError returns a logger with err recorded as the "error" field
+func (m *Mock) WithError(err error) api.Logger {
+	if err == nil {
+		return m
+	}
+	return m.WithFields(api.ErrorField(err))
 }
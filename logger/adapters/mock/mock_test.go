@@ -0,0 +1,150 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bignyap/go-utilities/logger/api"
+)
+
+func TestMock_AllEntriesKeepsOrderAcrossLevels(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockLogger()
+
+	m.Info(ctx, "starting")
+	m.Debug(ctx, "loaded config")
+	m.Warn(ctx, "slow dependency")
+	m.Error(ctx, "request failed", errors.New("boom"))
+	m.Info(ctx, "stopped")
+
+	entries := m.AllEntries()
+	want := []struct {
+		level Level
+		msg   string
+	}{
+		{LevelInfo, "starting"},
+		{LevelDebug, "loaded config"},
+		{LevelWarn, "slow dependency"},
+		{LevelError, "request failed"},
+		{LevelInfo, "stopped"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, w := range want {
+		if entries[i].Level != w.level || entries[i].Message != w.msg {
+			t.Errorf("entry %d = %s %q, want %s %q", i, entries[i].Level, entries[i].Message, w.level, w.msg)
+		}
+	}
+	if len(m.GetInfoMessages()) != 2 {
+		t.Fatalf("expected 2 info messages, got %d", len(m.GetInfoMessages()))
+	}
+
+	m.Clear()
+	if len(m.AllEntries()) != 0 {
+		t.Fatal("expected Clear to remove the ordered entries")
+	}
+}
+
+func TestMock_Assertions(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockLogger()
+
+	m.Info(ctx, "user created", api.String("user_id", "u1"))
+	m.Info(ctx, "user updated", api.String("user_id", "u2"))
+	m.WithFields(api.String("component", "billing")).Error(ctx, "charge failed", nil)
+
+	if !m.ContainsMessage(LevelInfo, "created") {
+		t.Fatal("expected an info message containing \"created\"")
+	}
+	if m.ContainsMessage(LevelError, "created") {
+		t.Fatal("expected no error message containing \"created\"")
+	}
+	if v, ok := m.FieldValue(LevelInfo, "user_id"); !ok || v != "u2" {
+		t.Fatalf("expected the latest user_id u2, got %v (ok=%v)", v, ok)
+	}
+	if _, ok := m.FieldValue(LevelWarn, "user_id"); ok {
+		t.Fatal("expected no warn field")
+	}
+}
+
+func TestMock_ChildLoggersShareEntries(t *testing.T) {
+	ctx := context.Background()
+	parent := NewMockLogger()
+	parent.Info(ctx, "parent one")
+
+	base := parent.WithFields(api.String("a", "1")).(*Mock)
+	children := []*Mock{
+		base.WithFields(api.String("b", "2")).(*Mock),
+		base.WithFields(api.String("c", "3")).(*Mock),
+		parent.WithComponent("worker").(*Mock),
+		parent.WithTraceID("trace-1").(*Mock),
+	}
+	for _, child := range children {
+		child.Info(ctx, "child message")
+	}
+	parent.Info(ctx, "parent two")
+
+	entries := parent.AllEntries()
+	if len(entries) != 6 || entries[5].Message != "parent two" {
+		t.Fatalf("expected the parent to see its own and its children's messages, got %+v", entries)
+	}
+	if len(children[2].GetInfoMessages()) != 6 {
+		t.Fatal("expected children to see the same entries as the parent")
+	}
+
+	// Siblings derived from the same logger must not share a fields backing array
+	if fields := entries[1].Fields; len(fields) != 2 || fields[1].Key != "b" {
+		t.Fatalf("expected fields a, b, got %v", fields)
+	}
+	if fields := entries[2].Fields; len(fields) != 2 || fields[1].Key != "c" {
+		t.Fatalf("expected fields a, c, got %v", fields)
+	}
+	if len(entries[5].Fields) != 0 {
+		t.Fatalf("expected the parent's fields to be unchanged, got %v", entries[5].Fields)
+	}
+}
+
+func TestMock_ZeroValueIsUsable(t *testing.T) {
+	m := &Mock{}
+	m.WithComponent("x").Warn(context.Background(), "ok")
+	m.Warn(context.Background(), "ok")
+	if !m.ContainsMessage(LevelWarn, "ok") {
+		t.Fatal("expected the zero-value mock to record messages")
+	}
+}
+
+func TestMock_AddFieldRecordsField(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockLogger()
+
+	child := m.AddField("k", "v").AddField("n", 1)
+	child.Info(ctx, "msg")
+
+	entries := child.(*Mock).AllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	fields := entries[0].Fields
+	if len(fields) != 2 || fields[0] != api.String("k", "v") || fields[1] != api.Int("n", 1) {
+		t.Fatalf("expected the added fields to be recorded, got %v", fields)
+	}
+	m.Info(ctx, "parent")
+	if entries := m.AllEntries(); len(entries) != 2 || len(entries[1].Fields) != 0 {
+		t.Fatalf("expected AddField not to add fields to the parent logger, got %+v", entries)
+	}
+}
+
+func TestMock_WithError(t *testing.T) {
+	m := NewMockLogger()
+	if m.WithError(nil) != m {
+		t.Fatal("expected WithError(nil) to return the same logger")
+	}
+
+	child := m.WithError(errors.New("boom")).(*Mock)
+	child.Warn(context.Background(), "degraded")
+	if v, ok := child.FieldValue(LevelWarn, "error"); !ok || v != "boom" {
+		t.Fatalf("expected the error field, got %v (ok=%v)", v, ok)
+	}
+}
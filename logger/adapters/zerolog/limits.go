@@ -0,0 +1,70 @@
+package zerolog
+
+import (
+	"unicode/utf8"
+
+	"github.com/bignyap/go-utilities/logger/api"
+)
+
+const (
+	fieldsTruncatedKey = "fields_truncated"
+	truncationMarker   = "…"
+)
+
+// fieldLimits caps structured fields; zero values mean unlimited
+type fieldLimits struct {
+	maxFields     int
+	maxValueBytes int
+}
+
+// apply truncates oversized values and drops fields beyond the count limit,
+// given the number of fields already attached to the logger. It returns the
+// kept fields and how many were dropped.
+func (fl fieldLimits) apply(fields []api.Field, attached int) ([]api.Field, int) {
+	if fl.maxFields <= 0 && fl.maxValueBytes <= 0 {
+		return fields, 0
+	}
+
+	dropped := 0
+	if fl.maxFields > 0 {
+		room := max(fl.maxFields-attached, 0)
+		if len(fields) > room {
+			dropped = len(fields) - room
+			fields = fields[:room]
+		}
+	}
+	if fl.maxValueBytes <= 0 {
+		return fields, dropped
+	}
+
+	out := make([]api.Field, len(fields))
+	for i, f := range fields {
+		out[i] = api.Field{Key: f.Key, Value: fl.truncateValue(f.Value)}
+	}
+	return out, dropped
+}
+
+func (fl fieldLimits) truncateValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		if len(val) > fl.maxValueBytes {
+			return truncateUTF8(val, fl.maxValueBytes) + truncationMarker
+		}
+	case []byte:
+		if len(val) > fl.maxValueBytes {
+			return truncateUTF8(string(val), fl.maxValueBytes) + truncationMarker
+		}
+	}
+	return v
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
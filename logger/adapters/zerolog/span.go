@@ -0,0 +1,91 @@
+package zerolog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/api"
+	"github.com/bignyap/go-utilities/logger/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMaxSpanAttributes  = 32
+	defaultMaxSpanValueLength = 256
+)
+
+func spanFieldDefaults(opts config.SpanFieldOptions) config.SpanFieldOptions {
+	if opts.MaxAttributes <= 0 {
+		opts.MaxAttributes = defaultMaxSpanAttributes
+	}
+	if opts.MaxValueLength <= 0 {
+		opts.MaxValueLength = defaultMaxSpanValueLength
+	}
+	return opts
+}
+
+// addSpanAttributes mirrors the logger's accumulated fields and the call's fields
+// onto the span in ctx, if mirroring is enabled and the span is recording
+func (l *Logger) addSpanAttributes(ctx context.Context, fields []api.Field) {
+	if !l.spanFields.Enabled || ctx == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	all := make([]api.Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+	if len(all) > l.spanFields.MaxAttributes {
+		all = all[:l.spanFields.MaxAttributes]
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(all))
+	for _, f := range all {
+		attrs = append(attrs, fieldToAttribute(f, l.spanFields.MaxValueLength))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// fieldToAttribute converts a log field to a span attribute of the matching type
+func fieldToAttribute(f api.Field, maxLen int) attribute.KeyValue {
+	switch v := f.Value.(type) {
+	case string:
+		return attribute.String(f.Key, truncate(v, maxLen))
+	case bool:
+		return attribute.Bool(f.Key, v)
+	case int:
+		return attribute.Int(f.Key, v)
+	case int32:
+		return attribute.Int64(f.Key, int64(v))
+	case int64:
+		return attribute.Int64(f.Key, v)
+	case float32:
+		return attribute.Float64(f.Key, float64(v))
+	case float64:
+		return attribute.Float64(f.Key, v)
+	case time.Duration:
+		return attribute.Int64(f.Key, v.Milliseconds())
+	case []string:
+		return attribute.StringSlice(f.Key, v)
+	case error:
+		return attribute.String(f.Key, truncate(v.Error(), maxLen))
+	case fmt.Stringer:
+		return attribute.String(f.Key, truncate(v.String(), maxLen))
+	case nil:
+		return attribute.String(f.Key, "")
+	default:
+		return attribute.String(f.Key, truncate(fmt.Sprintf("%v", v), maxLen))
+	}
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen]
+}
@@ -3,8 +3,10 @@ package zerolog
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/bignyap/go-utilities/logger/api"
@@ -14,9 +16,13 @@ import (
 
 // Logger implements the Logger interface using zerolog
 type Logger struct {
-	log       zerolog.Logger
-	component string
-	fields    []api.Field
+	log        zerolog.Logger
+	component  string
+	fields     []api.Field
+	spanFields config.SpanFieldOptions
+	limits     fieldLimits
+	// noExit logs Fatal messages without exiting the process
+	noExit bool
 }
 
 // NewZerologger creates a new zerolog-based logger
@@ -26,20 +32,27 @@ func NewZerologger(cfg config.LogConfig) (*Logger, error) {
 	zerolog.SetGlobalLevel(level)
 
 	// Configure output writers
-	writers, err := setupWriters(cfg)
-	if err != nil {
-		return nil, err
-	}
-
 	var writer io.Writer
-	if len(writers) == 1 {
-		writer = writers[0]
+	if len(cfg.Sinks) > 0 {
+		sinks, err := setupSinks(cfg)
+		if err != nil {
+			return nil, err
+		}
+		writer = sinks
 	} else {
-		writer = io.MultiWriter(writers...)
+		writers, err := setupWriters(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(writers) == 1 {
+			writer = writers[0]
+		} else {
+			writer = io.MultiWriter(writers...)
+		}
 	}
 
 	var logger zerolog.Logger
-	if cfg.Format == "pretty" && cfg.Environment == "dev" {
+	if len(cfg.Sinks) == 0 && cfg.Format == "pretty" && cfg.Environment == "dev" {
 		consoleWriter := zerolog.ConsoleWriter{Out: writer, TimeFormat: "15:04:05"}
 		logger = zerolog.New(consoleWriter).With().Timestamp().Logger()
 	} else {
@@ -50,13 +63,19 @@ func NewZerologger(cfg config.LogConfig) (*Logger, error) {
 		logger = logger.With().Interface(k, v).Logger()
 	}
 
-	return &Logger{log: logger}, nil
+	return &Logger{
+		log:        logger,
+		spanFields: spanFieldDefaults(cfg.SpanFields),
+		limits:     fieldLimits{maxFields: cfg.MaxFields, maxValueBytes: cfg.MaxFieldValueBytes},
+		noExit:     cfg.ExitOnFatal != nil && !*cfg.ExitOnFatal,
+	}, nil
 }
 
 func (l *Logger) Debug(ctx context.Context, msg string, fields ...api.Field) {
 	event := l.log.Debug()
 	l.addContextFields(ctx, event)
 	l.addFields(event, fields)
+	l.addSpanAttributes(ctx, fields)
 	event.Msg(msg)
 }
 
@@ -64,6 +83,7 @@ func (l *Logger) Info(ctx context.Context, msg string, fields ...api.Field) {
 	event := l.log.Info()
 	l.addContextFields(ctx, event)
 	l.addFields(event, fields)
+	l.addSpanAttributes(ctx, fields)
 	event.Msg(msg)
 }
 
@@ -71,6 +91,7 @@ func (l *Logger) Warn(ctx context.Context, msg string, fields ...api.Field) {
 	event := l.log.Warn()
 	l.addContextFields(ctx, event)
 	l.addFields(event, fields)
+	l.addSpanAttributes(ctx, fields)
 	event.Msg(msg)
 }
 
@@ -81,16 +102,22 @@ func (l *Logger) Error(ctx context.Context, msg string, err error, fields ...api
 		event = event.Err(err)
 	}
 	l.addFields(event, fields)
+	l.addSpanAttributes(ctx, fields)
 	event.Msg(msg)
 }
 
 func (l *Logger) Fatal(ctx context.Context, msg string, err error, fields ...api.Field) {
 	event := l.log.Fatal()
+	if l.noExit {
+		// WithLevel logs at fatal level without calling os.Exit
+		event = l.log.WithLevel(zerolog.FatalLevel)
+	}
 	l.addContextFields(ctx, event)
 	if err != nil {
 		event = event.Err(err)
 	}
 	l.addFields(event, fields)
+	l.addSpanAttributes(ctx, fields)
 	event.Msg(msg)
 }
 
@@ -106,21 +133,27 @@ func (l *Logger) WithFields(fields ...api.Field) api.Logger {
 	if len(fields) == 0 {
 		return l
 	}
+	// Fields attached here count towards every message's limit
+	fields, dropped := l.limits.apply(fields, len(l.fields))
 	ctx := l.log.With()
 	for _, f := range fields {
 		ctx = ctx.Interface(f.Key, f.Value)
 	}
-	newLog := ctx.Logger()
-	newFields := append(l.fields, fields...)
-	return &Logger{log: newLog, component: l.component, fields: newFields}
+	if dropped > 0 {
+		ctx = ctx.Int(fieldsTruncatedKey, dropped)
+	}
+	child := l.cloneWith(ctx.Logger())
+	child.fields = append(l.fields, fields...)
+	return child
 }
 
 func (l *Logger) WithComponent(component string) api.Logger {
 	if component == "" {
 		return l
 	}
-	newLog := l.log.With().Str("component", component).Logger()
-	return &Logger{log: newLog, component: component, fields: l.fields}
+	child := l.cloneWith(l.log.With().Str("component", component).Logger())
+	child.component = component
+	return child
 }
 
 func (l *Logger) ToContext(ctx context.Context) context.Context {
@@ -135,9 +168,14 @@ func (l *Logger) ToContext(ctx context.Context) context.Context {
 }
 
 func (l *Logger) AddField(key string, value interface{}) api.Logger {
-	newLog := l.log.With().Interface(key, value).Logger()
-	newFields := append(l.fields, api.Field{Key: key, Value: value})
-	return &Logger{log: newLog, component: l.component, fields: newFields}
+	return l.WithFields(api.Field{Key: key, Value: value})
+}
+
+func (l *Logger) WithError(err error) api.Logger {
+	if err == nil {
+		return l
+	}
+	return l.WithFields(api.ErrorField(err))
 }
 
 // addContextFields extracts trace_id and other metadata from context and adds to the log event
@@ -155,13 +193,17 @@ func (l *Logger) addFields(event *zerolog.Event, fields []api.Field) {
 	if l.component != "" {
 		event.Str("component", l.component)
 	}
+	fields, dropped := l.limits.apply(fields, len(l.fields))
 	for _, f := range fields {
 		event.Interface(f.Key, f.Value)
 	}
+	if dropped > 0 {
+		event.Int(fieldsTruncatedKey, dropped)
+	}
 }
 
 func (l *Logger) cloneWith(newLog zerolog.Logger) *Logger {
-	return &Logger{log: newLog, component: l.component, fields: l.fields}
+	return &Logger{log: newLog, component: l.component, fields: l.fields, spanFields: l.spanFields, limits: l.limits, noExit: l.noExit}
 }
 
 func parseLevel(level string) zerolog.Level {
@@ -199,6 +241,54 @@ func setupWriters(cfg config.LogConfig) ([]io.Writer, error) {
 	return writers, nil
 }
 
+// setupSinks builds a writer that tees each log line to every configured sink,
+// rendering it in that sink's format
+func setupSinks(cfg config.LogConfig) (zerolog.LevelWriter, error) {
+	writers := make([]io.Writer, 0, len(cfg.Sinks))
+	for i, sink := range cfg.Sinks {
+		out, err := sinkOutput(sink, cfg.FileOptions)
+		if err != nil {
+			return nil, fmt.Errorf("log sink %d: %w", i, err)
+		}
+		switch sink.Format {
+		case "pretty":
+			out = zerolog.ConsoleWriter{Out: out, TimeFormat: "15:04:05"}
+		case "console":
+			out = zerolog.ConsoleWriter{Out: out, TimeFormat: "15:04:05", NoColor: true}
+		}
+		writers = append(writers, out)
+	}
+	return zerolog.MultiLevelWriter(writers...), nil
+}
+
+// sinkOutput resolves the destination writer for a sink
+func sinkOutput(sink config.SinkConfig, fileOpts config.FileOptions) (io.Writer, error) {
+	if sink.Writer != nil {
+		return sink.Writer, nil
+	}
+	switch sink.Output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "file":
+		path := sink.Path
+		if path == "" {
+			path = filepath.Join(fileOpts.Directory, fileOpts.Filename)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unknown output %q", sink.Output)
+	}
+}
+
 // MemoryWriter is useful for testing
 type MemoryWriter struct {
 	Buffer *bytes.Buffer
@@ -0,0 +1,291 @@
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/logger/api"
+	"github.com/bignyap/go-utilities/logger/config"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestLogger(buf *bytes.Buffer, cfg config.LogConfig) *Logger {
+	return &Logger{
+		log:        zerolog.New(&MemoryWriter{Buffer: buf}),
+		spanFields: spanFieldDefaults(cfg.SpanFields),
+		limits:     fieldLimits{maxFields: cfg.MaxFields, maxValueBytes: cfg.MaxFieldValueBytes},
+	}
+}
+
+func spanAttributes(t *testing.T, recorder *tracetest.SpanRecorder) map[attribute.Key]attribute.Value {
+	t.Helper()
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 ended span, got %d", len(spans))
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestLogger_MirrorsFieldsOntoSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf, config.LogConfig{
+		SpanFields: config.SpanFieldOptions{Enabled: true, MaxValueLength: 5},
+	}).WithFields(api.String("tenant_id", "t1"))
+
+	logger.Info(ctx, "processing",
+		api.String("order_id", "order-123456"),
+		api.Int("items", 3),
+		api.Bool("paid", true),
+	)
+	span.End()
+
+	attrs := spanAttributes(t, recorder)
+	if got := attrs["tenant_id"].AsString(); got != "t1" {
+		t.Errorf("tenant_id = %q, want t1", got)
+	}
+	if got := attrs["order_id"].AsString(); got != "order" {
+		t.Errorf("order_id = %q, want truncated value %q", got, "order")
+	}
+	if got := attrs["items"].AsInt64(); got != 3 {
+		t.Errorf("items = %d, want 3", got)
+	}
+	if got := attrs["paid"].AsBool(); !got {
+		t.Errorf("paid = %v, want true", got)
+	}
+	if !strings.Contains(buf.String(), `"order_id":"order-123456"`) {
+		t.Errorf("log output should keep the full value, got %s", buf.String())
+	}
+}
+
+func TestLogger_SpanAttributeCap(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf, config.LogConfig{
+		SpanFields: config.SpanFieldOptions{Enabled: true, MaxAttributes: 2},
+	})
+	logger.Info(ctx, "capped", api.Int("a", 1), api.Int("b", 2), api.Int("c", 3))
+	span.End()
+
+	attrs := spanAttributes(t, recorder)
+	if len(attrs) != 2 {
+		t.Fatalf("expected 2 attributes, got %d: %v", len(attrs), attrs)
+	}
+	if _, ok := attrs["c"]; ok {
+		t.Errorf("attribute beyond the cap should be dropped")
+	}
+}
+
+func TestLogger_SpanMirroringDisabled(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	var buf bytes.Buffer
+	newTestLogger(&buf, config.LogConfig{}).Info(ctx, "plain", api.String("k", "v"))
+	span.End()
+
+	if attrs := spanAttributes(t, recorder); len(attrs) != 0 {
+		t.Fatalf("expected no attributes when disabled, got %v", attrs)
+	}
+}
+
+func TestNewZerologger_MultipleSinks(t *testing.T) {
+	// Capture stdout so the console sink can be inspected
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	logPath := filepath.Join(t.TempDir(), "logs", "app.log")
+	logger, err := NewZerologger(config.LogConfig{
+		Level: "info",
+		Sinks: []config.SinkConfig{
+			{Format: "console", Output: "stdout"},
+			{Format: "json", Output: "file", Path: logPath},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	os.Stdout = stdout
+
+	logger.Info(context.Background(), "order placed", api.String("order_id", "o-1"))
+	w.Close()
+
+	console, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	consoleOut := string(console)
+	if !strings.Contains(consoleOut, "INF") || !strings.Contains(consoleOut, "order placed") ||
+		!strings.Contains(consoleOut, "order_id=o-1") {
+		t.Errorf("stdout is not console formatted: %q", consoleOut)
+	}
+	if json.Valid(bytes.TrimSpace(console)) {
+		t.Errorf("stdout should not be JSON: %q", consoleOut)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("file output is not JSON: %v: %q", err, data)
+	}
+	if entry["message"] != "order placed" || entry["order_id"] != "o-1" || entry["level"] != "info" {
+		t.Errorf("unexpected JSON entry: %v", entry)
+	}
+}
+
+func TestNewZerologger_UnknownSinkOutput(t *testing.T) {
+	_, err := NewZerologger(config.LogConfig{
+		Sinks: []config.SinkConfig{{Format: "json", Output: "syslog"}},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown sink output")
+	}
+}
+
+func decodeEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	return entry
+}
+
+func TestLogger_TruncatesLargeFieldValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf, config.LogConfig{MaxFieldValueBytes: 8})
+
+	logger.Info(context.Background(), "big",
+		api.String("payload", strings.Repeat("x", 100)),
+		api.Any("raw", []byte("0123456789")),
+		api.String("short", "ok"),
+		api.String("unicode", "ééééé"),
+	)
+
+	entry := decodeEntry(t, &buf)
+	if entry["payload"] != "xxxxxxxx…" {
+		t.Fatalf("unexpected payload %q", entry["payload"])
+	}
+	if entry["raw"] != "01234567…" {
+		t.Fatalf("unexpected raw %q", entry["raw"])
+	}
+	if entry["short"] != "ok" {
+		t.Fatalf("short value should be untouched, got %q", entry["short"])
+	}
+	// Cut on a rune boundary: 4 two-byte runes fit in 8 bytes
+	if entry["unicode"] != "éééé…" {
+		t.Fatalf("unexpected unicode value %q", entry["unicode"])
+	}
+	if _, ok := entry["fields_truncated"]; ok {
+		t.Fatal("no fields should have been dropped")
+	}
+}
+
+func TestLogger_CapsFieldCount(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf, config.LogConfig{MaxFields: 3})
+
+	logger.WithFields(api.String("service", "orders")).Info(context.Background(), "many",
+		api.Int("a", 1), api.Int("b", 2), api.Int("c", 3), api.Int("d", 4),
+	)
+
+	entry := decodeEntry(t, &buf)
+	for _, key := range []string{"service", "a", "b"} {
+		if _, ok := entry[key]; !ok {
+			t.Fatalf("expected field %q to be kept: %v", key, entry)
+		}
+	}
+	for _, key := range []string{"c", "d"} {
+		if _, ok := entry[key]; ok {
+			t.Fatalf("expected field %q to be dropped: %v", key, entry)
+		}
+	}
+	if entry["fields_truncated"] != float64(2) {
+		t.Fatalf("expected fields_truncated=2, got %v", entry["fields_truncated"])
+	}
+}
+
+func TestLogger_NoLimitsByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf, config.LogConfig{})
+
+	long := strings.Repeat("y", 1000)
+	logger.Info(context.Background(), "plain", api.String("payload", long), api.Int("a", 1))
+
+	entry := decodeEntry(t, &buf)
+	if entry["payload"] != long || entry["a"] != float64(1) {
+		t.Fatalf("fields should be untouched without limits: %v", entry)
+	}
+}
+
+func TestLogger_WithError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf, config.LogConfig{})
+
+	if logger.WithError(nil) != api.Logger(logger) {
+		t.Fatal("expected WithError(nil) to return the same logger")
+	}
+
+	logger.WithError(errors.New("connection refused")).Warn(context.Background(), "retrying")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	if entry["error"] != "connection refused" {
+		t.Fatalf("expected the error field, got %v", entry)
+	}
+}
+
+func TestLogger_FatalWithoutExit(t *testing.T) {
+	var buf bytes.Buffer
+	exit := false
+	logger, err := NewZerologger(config.LogConfig{
+		Level:       "debug",
+		ExitOnFatal: &exit,
+		Sinks:       []config.SinkConfig{{Format: "json", Writer: &buf}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Returning from Fatal at all shows the process was not terminated
+	logger.WithComponent("worker").Fatal(context.Background(), "cannot continue", errors.New("boom"))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "fatal" || entry["error"] != "boom" || entry["message"] != "cannot continue" {
+		t.Fatalf("unexpected fatal entry %v", entry)
+	}
+}
@@ -23,6 +23,9 @@ type Logger interface {
 	WithFields(fields ...Field) Logger
 	WithComponent(component string) Logger
 	AddField(key string, value interface{}) Logger
+	// WithError returns a child logger carrying err as the "error" field.
+	// A nil err returns the logger unchanged.
+	WithError(err error) Logger
 
 	// Context integration
 	ToContext(ctx context.Context) context.Context
@@ -114,4 +117,5 @@ func (d *DefaultLogger) WithFields(fields ...Field) Logger             { return
 func (d *DefaultLogger) WithTraceID(traceID string) Logger             { return d }
 func (d *DefaultLogger) WithComponent(component string) Logger         { return d }
 func (d *DefaultLogger) AddField(key string, value interface{}) Logger { return d }
+func (d *DefaultLogger) WithError(err error) Logger                    { return d }
 func (d *DefaultLogger) ToContext(ctx context.Context) context.Context { return ctx }
@@ -21,6 +21,53 @@ type LogConfig struct {
 
 	// Fields contains default fields to add to all log messages
 	Fields map[string]interface{}
+
+	// SpanFields mirrors logged fields onto the active trace span as attributes
+	SpanFields SpanFieldOptions
+
+	// MaxFields caps the structured fields per log message; extra fields are
+	// dropped and counted in a "fields_truncated" field (0 = unlimited)
+	MaxFields int
+
+	// MaxFieldValueBytes caps string and []byte field values, which are cut
+	// and marked with an ellipsis (0 = unlimited)
+	MaxFieldValueBytes int
+
+	// ExitOnFatal controls whether Fatal exits the process after logging.
+	// nil means true; set it to false in tests to log at fatal level only.
+	ExitOnFatal *bool
+
+	// Sinks writes every message to several outputs, each with its own format.
+	// When set, Format and Output are ignored.
+	Sinks []SinkConfig
+}
+
+// SinkConfig describes a single log output
+type SinkConfig struct {
+	// Format determines the output format (json, console, pretty)
+	Format string
+
+	// Output determines where logs are written (stdout, stderr, file)
+	Output string
+
+	// Path is the file to write to when Output is "file".
+	// Defaults to FileOptions.Directory/FileOptions.Filename.
+	Path string
+
+	// Writer, if set, is used instead of Output
+	Writer io.Writer
+}
+
+// SpanFieldOptions configures mirroring of log fields onto trace spans
+type SpanFieldOptions struct {
+	// Enabled turns on span attribute mirroring
+	Enabled bool
+
+	// MaxAttributes caps how many fields are attached per log call (default 32)
+	MaxAttributes int
+
+	// MaxValueLength caps the length of string attribute values (default 256)
+	MaxValueLength int
 }
 
 // FileOptions configures file-based logging
@@ -24,6 +24,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
 	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -46,6 +47,31 @@ func parseEndpointURL(endpoint string) (hostPort string, isHTTPS bool) {
 	return hostPort, isHTTPS
 }
 
+// grpcTarget returns the host:port and transport credentials for an OTLP gRPC
+// exporter. The endpoint may be host:port or a URL; with a URL, an http
+// scheme means plaintext and https means TLS, unless Insecure or TLS
+// settings say otherwise. Nil credentials keep the exporter's default TLS.
+func grpcTarget(cfg config.ExporterConfig) (string, credentials.TransportCredentials, error) {
+	hostPort, isHTTPS := parseEndpointURL(cfg.Endpoint)
+	hasScheme := strings.Contains(cfg.Endpoint, "://")
+
+	if cfg.Insecure || (hasScheme && !isHTTPS && !cfg.TLS.IsSet()) {
+		return hostPort, insecure.NewCredentials(), nil
+	}
+
+	tlsCfg, err := exporterTLSConfig(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	if tlsCfg != nil {
+		return hostPort, credentials.NewTLS(tlsCfg), nil
+	}
+	if isHTTPS {
+		return hostPort, credentials.NewClientTLSFromCert(nil, ""), nil
+	}
+	return hostPort, nil, nil
+}
+
 // OtelProvider implements the api.Provider interface using OpenTelemetry SDK
 type OtelProvider struct {
 	config         config.OtelConfig
@@ -91,6 +117,9 @@ func NewOtelProvider(cfg config.OtelConfig) (*OtelProvider, error) {
 		otel.SetMeterProvider(mp)
 	}
 
+	// Propagate trace context and baggage across service boundaries
+	otel.SetTextMapPropagator(api.Propagator())
+
 	return provider, nil
 }
 
@@ -141,7 +170,7 @@ func (p *OtelProvider) createTracerProvider() (*sdktrace.TracerProvider, error)
 	sampler := p.createSampler()
 
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithBatcher(exporter, batchOptions(p.config.TraceExporter)...),
 		sdktrace.WithResource(p.resource),
 		sdktrace.WithSampler(sampler),
 	)
@@ -149,6 +178,25 @@ func (p *OtelProvider) createTracerProvider() (*sdktrace.TracerProvider, error)
 	return tp, nil
 }
 
+// batchOptions maps the configured batch settings onto the span processor,
+// leaving SDK defaults for unset values
+func batchOptions(cfg config.ExporterConfig) []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if cfg.BatchMaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(cfg.BatchMaxQueueSize))
+	}
+	if cfg.BatchMaxExportSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(cfg.BatchMaxExportSize))
+	}
+	if cfg.BatchTimeout > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(cfg.BatchTimeout))
+	}
+	if cfg.ExportTimeout > 0 {
+		opts = append(opts, sdktrace.WithExportTimeout(cfg.ExportTimeout))
+	}
+	return opts
+}
+
 // createTraceExporter creates a trace exporter based on configuration
 func (p *OtelProvider) createTraceExporter() (sdktrace.SpanExporter, error) {
 	switch p.config.TraceExporter.Type {
@@ -186,24 +234,52 @@ func (p *OtelProvider) createTraceExporter() (sdktrace.SpanExporter, error) {
 
 	case config.ExporterTypeOTLP:
 		// Standard OTLP uses gRPC
-		endpoint := p.config.TraceExporter.Endpoint
+		endpoint, creds, err := grpcTarget(p.config.TraceExporter)
+		if err != nil {
+			return nil, err
+		}
 
 		opts := []otlptracegrpc.Option{
 			otlptracegrpc.WithEndpoint(endpoint),
 		}
 
-		// Add insecure option if specified
-		if p.config.TraceExporter.Insecure {
-			opts = append(opts, otlptracegrpc.WithTLSCredentials(insecure.NewCredentials()))
+		// Plaintext, the configured CA and client certificate, or TLS from an https endpoint
+		if creds != nil {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(creds))
 		}
 
-		// Add custom headers
-		if len(p.config.TraceExporter.Headers) > 0 {
-			opts = append(opts, otlptracegrpc.WithHeaders(p.config.TraceExporter.Headers))
+		// Add custom headers and the bearer token
+		if headers := exporterHeaders(p.config.TraceExporter); len(headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(headers))
 		}
 
 		return otlptracegrpc.New(context.Background(), opts...)
 
+	case config.ExporterTypeOTLPHTTP:
+		// Standard OTLP over HTTP, e.g. a Jaeger or OTel collector on :4318
+		endpoint := p.config.TraceExporter.Endpoint
+
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if strings.Contains(endpoint, "://") {
+			opts = []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+		}
+
+		// Add insecure option if specified, or the configured CA and client certificate
+		if p.config.TraceExporter.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else if tlsCfg, err := exporterTLSConfig(p.config.TraceExporter); err != nil {
+			return nil, err
+		} else if tlsCfg != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		}
+
+		// Add custom headers and the bearer token
+		if headers := exporterHeaders(p.config.TraceExporter); len(headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(headers))
+		}
+
+		return otlptracehttp.New(context.Background(), opts...)
+
 	default:
 		return nil, fmt.Errorf("unsupported trace exporter type: %s", p.config.TraceExporter.Type)
 	}
@@ -216,16 +292,27 @@ func (p *OtelProvider) createMeterProvider() (*sdkmetric.MeterProvider, error) {
 		return nil, err
 	}
 
+	readerOpts := []sdkmetric.PeriodicReaderOption{sdkmetric.WithInterval(p.metricInterval())}
+	if p.config.MetricExporter.ExportTimeout > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithTimeout(p.config.MetricExporter.ExportTimeout))
+	}
+
 	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
-			sdkmetric.WithInterval(10*time.Second),
-		)),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
 		sdkmetric.WithResource(p.resource),
 	)
 
 	return mp, nil
 }
 
+// metricInterval returns the configured metric export interval or the default
+func (p *OtelProvider) metricInterval() time.Duration {
+	if p.config.MetricInterval > 0 {
+		return p.config.MetricInterval
+	}
+	return config.DefaultMetricInterval
+}
+
 // createMetricExporter creates a metric exporter based on configuration
 func (p *OtelProvider) createMetricExporter() (sdkmetric.Exporter, error) {
 	switch p.config.MetricExporter.Type {
@@ -263,24 +350,52 @@ func (p *OtelProvider) createMetricExporter() (sdkmetric.Exporter, error) {
 
 	case config.ExporterTypeOTLP:
 		// Standard OTLP uses gRPC
-		endpoint := p.config.MetricExporter.Endpoint
+		endpoint, creds, err := grpcTarget(p.config.MetricExporter)
+		if err != nil {
+			return nil, err
+		}
 
 		opts := []otlpmetricgrpc.Option{
 			otlpmetricgrpc.WithEndpoint(endpoint),
 		}
 
-		// Add insecure option if specified
-		if p.config.MetricExporter.Insecure {
-			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(insecure.NewCredentials()))
+		// Plaintext, the configured CA and client certificate, or TLS from an https endpoint
+		if creds != nil {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(creds))
 		}
 
-		// Add custom headers
-		if len(p.config.MetricExporter.Headers) > 0 {
-			opts = append(opts, otlpmetricgrpc.WithHeaders(p.config.MetricExporter.Headers))
+		// Add custom headers and the bearer token
+		if headers := exporterHeaders(p.config.MetricExporter); len(headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(headers))
 		}
 
 		return otlpmetricgrpc.New(context.Background(), opts...)
 
+	case config.ExporterTypeOTLPHTTP:
+		// Standard OTLP over HTTP
+		endpoint := p.config.MetricExporter.Endpoint
+
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint)}
+		if strings.Contains(endpoint, "://") {
+			opts = []otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(endpoint)}
+		}
+
+		// Add insecure option if specified, or the configured CA and client certificate
+		if p.config.MetricExporter.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else if tlsCfg, err := exporterTLSConfig(p.config.MetricExporter); err != nil {
+			return nil, err
+		} else if tlsCfg != nil {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
+		}
+
+		// Add custom headers and the bearer token
+		if headers := exporterHeaders(p.config.MetricExporter); len(headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(headers))
+		}
+
+		return otlpmetrichttp.New(context.Background(), opts...)
+
 	default:
 		return nil, fmt.Errorf("unsupported metric exporter type: %s", p.config.MetricExporter.Type)
 	}
@@ -288,16 +403,7 @@ func (p *OtelProvider) createMetricExporter() (sdkmetric.Exporter, error) {
 
 // createSampler creates a sampler based on configuration
 func (p *OtelProvider) createSampler() sdktrace.Sampler {
-	switch p.config.Sampling.Type {
-	case config.SamplingTypeAlwaysOn:
-		return sdktrace.AlwaysSample()
-	case config.SamplingTypeAlwaysOff:
-		return sdktrace.NeverSample()
-	case config.SamplingTypeTraceID:
-		return sdktrace.TraceIDRatioBased(p.config.Sampling.Ratio)
-	default:
-		return sdktrace.AlwaysSample()
-	}
+	return newSampler(p.config.Sampling)
 }
 
 // Tracer returns a tracer for creating spans
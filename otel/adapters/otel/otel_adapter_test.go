@@ -0,0 +1,202 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/otel/config"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestBatchOptions(t *testing.T) {
+	var got sdktrace.BatchSpanProcessorOptions
+	for _, opt := range batchOptions(config.ExporterConfig{
+		BatchMaxQueueSize:  100,
+		BatchMaxExportSize: 10,
+		BatchTimeout:       time.Second,
+		ExportTimeout:      2 * time.Second,
+	}) {
+		opt(&got)
+	}
+
+	if got.MaxQueueSize != 100 || got.MaxExportBatchSize != 10 ||
+		got.BatchTimeout != time.Second || got.ExportTimeout != 2*time.Second {
+		t.Fatalf("unexpected batch options %+v", got)
+	}
+	if opts := batchOptions(config.ExporterConfig{}); len(opts) != 0 {
+		t.Fatalf("expected SDK defaults when unset, got %d options", len(opts))
+	}
+}
+
+func TestOTLPHTTPExporter_ExportsWithConfiguredBatchTimeout(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requests <- r:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.EnableMetrics = false
+	cfg.TraceExporter = config.ExporterConfig{
+		Type:     config.ExporterTypeOTLPHTTP,
+		Endpoint: collector.URL + "/v1/traces",
+		Insecure: true,
+		// Far below the 5s SDK default, so an export without a flush proves it applied
+		BatchTimeout: 20 * time.Millisecond,
+	}
+
+	provider, err := NewOtelProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewOtelProvider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	select {
+	case r := <-requests:
+		if r.URL.Path != "/v1/traces" {
+			t.Fatalf("unexpected export path %q", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Fatalf("expected an OTLP HTTP protobuf export, got content type %q", ct)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("span was not exported within the configured batch timeout")
+	}
+}
+
+func TestExporterConfigValidate_OTLPHTTP(t *testing.T) {
+	if err := (&config.ExporterConfig{Type: config.ExporterTypeOTLPHTTP}).Validate(); err == nil {
+		t.Fatal("expected an error without an endpoint")
+	}
+	if err := (&config.ExporterConfig{Type: config.ExporterTypeOTLPHTTP, Endpoint: "collector:4318"}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (&config.ExporterConfig{Type: config.ExporterTypeConsole, BatchTimeout: -time.Second}).Validate(); err == nil {
+		t.Fatal("expected an error for a negative batch timeout")
+	}
+}
+
+func TestMetricInterval(t *testing.T) {
+	p := &OtelProvider{config: config.OtelConfig{}}
+	if got := p.metricInterval(); got != config.DefaultMetricInterval {
+		t.Fatalf("expected the default interval, got %v", got)
+	}
+	p.config.MetricInterval = 15 * time.Second
+	if got := p.metricInterval(); got != 15*time.Second {
+		t.Fatalf("expected 15s, got %v", got)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.MetricInterval = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a negative metric interval")
+	}
+}
+
+func TestOTLPHTTPMetrics_ExportAtConfiguredInterval(t *testing.T) {
+	paths := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case paths <- r.URL.Path:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.EnableTraces = false
+	// Far below the 10s default, so an export without a flush proves it applied
+	cfg.MetricInterval = 20 * time.Millisecond
+	cfg.MetricExporter = config.ExporterConfig{
+		Type:     config.ExporterTypeOTLPHTTP,
+		Endpoint: collector.URL + "/v1/metrics",
+		Insecure: true,
+	}
+
+	provider, err := NewOtelProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewOtelProvider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	counter, err := provider.Meter("test").Int64Counter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(context.Background(), 1)
+
+	select {
+	case path := <-paths:
+		if path != "/v1/metrics" {
+			t.Fatalf("unexpected export path %q", path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("metrics were not exported at the configured interval")
+	}
+}
+
+func TestGRPCTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.ExporterConfig
+		endpoint string
+		protocol string // "" means the exporter's default credentials
+	}{
+		{"http URL is plaintext", config.ExporterConfig{Endpoint: "http://collector:4317"}, "collector:4317", "insecure"},
+		{"https URL is TLS", config.ExporterConfig{Endpoint: "https://collector:4317"}, "collector:4317", "tls"},
+		{"host:port keeps the default", config.ExporterConfig{Endpoint: "collector:4317"}, "collector:4317", ""},
+		{"insecure wins over https", config.ExporterConfig{Endpoint: "https://collector:4317", Insecure: true}, "collector:4317", "insecure"},
+		{"insecure host:port", config.ExporterConfig{Endpoint: "collector:4317", Insecure: true}, "collector:4317", "insecure"},
+		{"TLS settings win over http", config.ExporterConfig{Endpoint: "http://collector:4317", TLS: config.TLSConfig{ServerNameOverride: "collector.internal"}}, "collector:4317", "tls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, creds, err := grpcTarget(tt.cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if endpoint != tt.endpoint {
+				t.Fatalf("endpoint = %q, want %q", endpoint, tt.endpoint)
+			}
+			protocol := ""
+			if creds != nil {
+				protocol = creds.Info().SecurityProtocol
+			}
+			if protocol != tt.protocol {
+				t.Fatalf("credentials = %q, want %q", protocol, tt.protocol)
+			}
+		})
+	}
+}
+
+func TestNewOtelProvider_InstallsBaggagePropagator(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	provider, err := NewOtelProvider(config.OtelConfig{
+		Resource: config.ResourceConfig{ServiceName: "test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	fields := otel.GetTextMapPropagator().Fields()
+	if !slices.Contains(fields, "traceparent") || !slices.Contains(fields, "baggage") {
+		t.Fatalf("expected trace context and baggage fields, got %v", fields)
+	}
+}
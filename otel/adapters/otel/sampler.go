@@ -0,0 +1,70 @@
+package otel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bignyap/go-utilities/otel/api"
+	"github.com/bignyap/go-utilities/otel/config"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newSampler builds the sampler described by cfg
+func newSampler(cfg config.SamplingConfig) sdktrace.Sampler {
+	var base sdktrace.Sampler
+	switch cfg.Type {
+	case config.SamplingTypeAlwaysOff:
+		base = sdktrace.NeverSample()
+	case config.SamplingTypeTraceID, config.SamplingTypeParentBased:
+		base = sdktrace.TraceIDRatioBased(cfg.Ratio)
+	default:
+		base = sdktrace.AlwaysSample()
+	}
+
+	if len(cfg.RouteOverrides) > 0 {
+		base = newRouteSampler(cfg.RouteOverrides, base)
+	}
+	if cfg.Type == config.SamplingTypeParentBased {
+		return sdktrace.ParentBased(base)
+	}
+	return base
+}
+
+// routeSampler samples spans by their http.route attribute, falling back to
+// another sampler for routes without an override
+type routeSampler struct {
+	routes   map[string]sdktrace.Sampler
+	fallback sdktrace.Sampler
+	desc     string
+}
+
+func newRouteSampler(overrides map[string]float64, fallback sdktrace.Sampler) *routeSampler {
+	s := &routeSampler{routes: make(map[string]sdktrace.Sampler, len(overrides)), fallback: fallback}
+
+	routes := make([]string, 0, len(overrides))
+	for route, ratio := range overrides {
+		s.routes[route] = sdktrace.TraceIDRatioBased(ratio)
+		routes = append(routes, fmt.Sprintf("%s=%g", route, ratio))
+	}
+	sort.Strings(routes)
+	s.desc = fmt.Sprintf("RouteSampler{%s,fallback:%s}", strings.Join(routes, ","), fallback.Description())
+	return s
+}
+
+func (s *routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, attr := range p.Attributes {
+		if string(attr.Key) != api.HTTPRouteKey {
+			continue
+		}
+		if sampler, ok := s.routes[attr.Value.AsString()]; ok {
+			return sampler.ShouldSample(p)
+		}
+		break
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s *routeSampler) Description() string {
+	return s.desc
+}
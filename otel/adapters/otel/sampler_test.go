@@ -0,0 +1,95 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bignyap/go-utilities/otel/api"
+	"github.com/bignyap/go-utilities/otel/config"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func samplingParams(ctx context.Context, route string) sdktrace.SamplingParameters {
+	var attrs []attribute.KeyValue
+	if route != "" {
+		attrs = append(attrs, attribute.String(api.HTTPRouteKey, route))
+	}
+	return sdktrace.SamplingParameters{
+		ParentContext: ctx,
+		TraceID:       trace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1},
+		Name:          "GET " + route,
+		Kind:          trace.SpanKindServer,
+		Attributes:    attrs,
+	}
+}
+
+func withRemoteParent(sampled bool) context.Context {
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+	return trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: flags,
+		Remote:     true,
+	}))
+}
+
+func TestSampler_ParentBasedRespectsParent(t *testing.T) {
+	sampler := newSampler(config.SamplingConfig{Type: config.SamplingTypeParentBased, Ratio: 0})
+
+	if got := sampler.ShouldSample(samplingParams(withRemoteParent(true), "/orders")).Decision; got != sdktrace.RecordAndSample {
+		t.Fatalf("expected a sampled parent to be followed, got %v", got)
+	}
+	if got := sampler.ShouldSample(samplingParams(withRemoteParent(false), "/orders")).Decision; got != sdktrace.Drop {
+		t.Fatalf("expected an unsampled parent to be followed, got %v", got)
+	}
+	if got := sampler.ShouldSample(samplingParams(context.Background(), "/orders")).Decision; got != sdktrace.Drop {
+		t.Fatalf("expected a root span to use the 0 ratio, got %v", got)
+	}
+}
+
+func TestSampler_RouteOverrides(t *testing.T) {
+	for _, samplingType := range []config.SamplingType{config.SamplingTypeTraceID, config.SamplingTypeParentBased} {
+		sampler := newSampler(config.SamplingConfig{
+			Type:           samplingType,
+			Ratio:          0,
+			RouteOverrides: map[string]float64{"/checkout": 1, "/healthz": 0},
+		})
+
+		if got := sampler.ShouldSample(samplingParams(context.Background(), "/checkout")).Decision; got != sdktrace.RecordAndSample {
+			t.Fatalf("%s: expected the overridden route to be sampled, got %v", samplingType, got)
+		}
+		if got := sampler.ShouldSample(samplingParams(context.Background(), "/orders")).Decision; got != sdktrace.Drop {
+			t.Fatalf("%s: expected other routes to use the 0 ratio, got %v", samplingType, got)
+		}
+	}
+
+	// An override can also drop a route that would otherwise always be sampled
+	sampler := newSampler(config.SamplingConfig{Type: config.SamplingTypeAlwaysOn, RouteOverrides: map[string]float64{"/healthz": 0}})
+	if got := sampler.ShouldSample(samplingParams(context.Background(), "/healthz")).Decision; got != sdktrace.Drop {
+		t.Fatalf("expected the health check to be dropped, got %v", got)
+	}
+}
+
+func TestSamplingConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.SamplingConfig
+		wantErr bool
+	}{
+		{"empty type", config.SamplingConfig{}, false},
+		{"parent based", config.SamplingConfig{Type: config.SamplingTypeParentBased, Ratio: 0.5}, false},
+		{"parent based ratio out of range", config.SamplingConfig{Type: config.SamplingTypeParentBased, Ratio: 2}, true},
+		{"unknown type", config.SamplingConfig{Type: "sometimes"}, true},
+		{"override out of range", config.SamplingConfig{RouteOverrides: map[string]float64{"/a": -1}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package otel
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/bignyap/go-utilities/otel/config"
+)
+
+// exporterTLSConfig builds the TLS config for an OTLP exporter, or returns nil
+// to keep the exporter's default TLS against the system roots
+func exporterTLSConfig(cfg config.ExporterConfig) (*tls.Config, error) {
+	if !cfg.TLS.IsSet() {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.TLS.ServerNameOverride,
+	}
+
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.TLS.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+// exporterHeaders returns the configured headers plus the bearer token, if any
+func exporterHeaders(cfg config.ExporterConfig) map[string]string {
+	if cfg.BearerToken == "" {
+		return cfg.Headers
+	}
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	headers["authorization"] = "Bearer " + cfg.BearerToken
+	return headers
+}
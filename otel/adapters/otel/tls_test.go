@@ -0,0 +1,176 @@
+package otel
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/otel/config"
+)
+
+// writeClientCertificate writes a self-signed client certificate and key into dir
+func writeClientCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "otel-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// writeCA writes the collector's certificate as a CA bundle
+func writeCA(t *testing.T, dir string, cert *x509.Certificate) string {
+	t.Helper()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return caFile
+}
+
+func TestExporterTLSConfig(t *testing.T) {
+	if cfg, err := exporterTLSConfig(config.ExporterConfig{}); err != nil || cfg != nil {
+		t.Fatalf("expected no TLS config when unset, got %v, %v", cfg, err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCertificate(t, dir)
+	collector := httptest.NewTLSServer(http.NotFoundHandler())
+	defer collector.Close()
+
+	cfg, err := exporterTLSConfig(config.ExporterConfig{TLS: config.TLSConfig{
+		CAFile:             writeCA(t, dir, collector.Certificate()),
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		ServerNameOverride: "collector.internal",
+	}})
+	if err != nil {
+		t.Fatalf("exporterTLSConfig: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 || cfg.ServerName != "collector.internal" {
+		t.Fatalf("unexpected TLS config roots=%v certs=%d server=%q", cfg.RootCAs, len(cfg.Certificates), cfg.ServerName)
+	}
+
+	if _, err := exporterTLSConfig(config.ExporterConfig{TLS: config.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}}); err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+}
+
+func TestExporterHeaders_BearerToken(t *testing.T) {
+	headers := map[string]string{"x-tenant": "acme"}
+	got := exporterHeaders(config.ExporterConfig{Headers: headers, BearerToken: "s3cret"})
+
+	if got["authorization"] != "Bearer s3cret" || got["x-tenant"] != "acme" {
+		t.Fatalf("unexpected headers %v", got)
+	}
+	if _, ok := headers["authorization"]; ok {
+		t.Fatal("the configured headers must not be modified")
+	}
+}
+
+func TestOTLPHTTPExporter_MutualTLS(t *testing.T) {
+	type export struct {
+		auth        string
+		clientCerts int
+	}
+	exports := make(chan export, 1)
+	collector := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case exports <- export{auth: r.Header.Get("Authorization"), clientCerts: len(r.TLS.PeerCertificates)}:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	collector.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	collector.StartTLS()
+	defer collector.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCertificate(t, dir)
+
+	cfg := config.DefaultConfig()
+	cfg.EnableMetrics = false
+	cfg.TraceExporter = config.ExporterConfig{
+		Type:         config.ExporterTypeOTLPHTTP,
+		Endpoint:     collector.URL + "/v1/traces",
+		BearerToken:  "s3cret",
+		BatchTimeout: 20 * time.Millisecond,
+		TLS: config.TLSConfig{
+			CAFile:   writeCA(t, dir, collector.Certificate()),
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+	}
+
+	provider, err := NewOtelProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewOtelProvider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	select {
+	case got := <-exports:
+		if got.auth != "Bearer s3cret" {
+			t.Fatalf("expected the bearer token, got %q", got.auth)
+		}
+		if got.clientCerts != 1 {
+			t.Fatalf("expected the client certificate, got %d", got.clientCerts)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("span was not exported over mutual TLS")
+	}
+}
+
+func TestExporterConfigValidate_TLS(t *testing.T) {
+	base := config.ExporterConfig{Type: config.ExporterTypeOTLP, Endpoint: "collector:4317"}
+
+	partial := base
+	partial.TLS.CertFile = "client.crt"
+	if err := partial.Validate(); err == nil {
+		t.Fatal("expected an error for a cert file without a key file")
+	}
+
+	conflicting := base
+	conflicting.Insecure = true
+	conflicting.TLS.CAFile = "ca.pem"
+	if err := conflicting.Validate(); err == nil {
+		t.Fatal("expected an error for TLS settings with insecure")
+	}
+}
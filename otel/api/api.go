@@ -108,6 +108,26 @@ func RecordError(ctx context.Context, err error, opts ...trace.EventOption) {
 	}
 }
 
+// RecordErrorWithCode records an error in the current span, setting the span
+// status to Error only for 5xx responses. Client errors (4xx) are recorded as
+// events but leave the status unset, per OTel HTTP server semantics.
+// An httpStatus of 0 is treated as an infrastructure error.
+func RecordErrorWithCode(ctx context.Context, err error, httpStatus int, opts ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, opts...)
+	if IsErrorStatus(httpStatus) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// IsErrorStatus reports whether an HTTP status code should mark a server span as failed
+func IsErrorStatus(httpStatus int) bool {
+	return httpStatus == 0 || httpStatus >= 500
+}
+
 // SetSpanAttributes sets attributes on the current span
 func SetSpanAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
 	span := trace.SpanFromContext(ctx)
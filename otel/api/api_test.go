@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordWithCode(t *testing.T, httpStatus int) sdktrace.ReadOnlySpan {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	RecordErrorWithCode(ctx, errors.New("boom"), httpStatus)
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 ended span, got %d", len(spans))
+	}
+	return spans[0]
+}
+
+func TestRecordErrorWithCode_ClientErrorLeavesStatusUnset(t *testing.T) {
+	span := recordWithCode(t, http.StatusNotFound)
+
+	if span.Status().Code != codes.Unset {
+		t.Fatalf("expected unset status for 404, got %v", span.Status().Code)
+	}
+	if len(span.Events()) != 1 {
+		t.Fatalf("expected the error to be recorded as an event, got %d events", len(span.Events()))
+	}
+}
+
+func TestRecordErrorWithCode_ServerErrorSetsStatus(t *testing.T) {
+	span := recordWithCode(t, http.StatusInternalServerError)
+
+	if span.Status().Code != codes.Error {
+		t.Fatalf("expected error status for 500, got %v", span.Status().Code)
+	}
+	if span.Status().Description != "boom" {
+		t.Fatalf("unexpected status description %q", span.Status().Description)
+	}
+}
+
+func TestIsErrorStatus(t *testing.T) {
+	cases := map[int]bool{
+		0:                              true,
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+	}
+	for status, want := range cases {
+		if got := IsErrorStatus(status); got != want {
+			t.Errorf("IsErrorStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
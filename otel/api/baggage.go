@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator returns the propagator installed by NewOtelProvider: W3C trace
+// context plus W3C baggage
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+}
+
+// WithBaggage returns a context whose baggage holds values in addition to
+// any baggage already on ctx. Entries that are not valid W3C baggage are
+// skipped.
+func WithBaggage(ctx context.Context, values map[string]string) context.Context {
+	bag := baggage.FromContext(ctx)
+	for key, value := range values {
+		member, err := baggage.NewMemberRaw(key, value)
+		if err != nil {
+			continue
+		}
+		if next, err := bag.SetMember(member); err == nil {
+			bag = next
+		}
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// BaggageValue returns the baggage value for key, or "" if it is not set
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
+
+// BaggageAttributes returns the baggage entries named by keys as span
+// attributes. Keys missing from the baggage are omitted.
+func BaggageAttributes(ctx context.Context, keys ...string) []attribute.KeyValue {
+	bag := baggage.FromContext(ctx)
+	var attrs []attribute.KeyValue
+	for _, key := range keys {
+		if member := bag.Member(key); member.Key() != "" {
+			attrs = append(attrs, attribute.String(key, member.Value()))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestWithBaggage_MergesAndSkipsInvalid(t *testing.T) {
+	ctx := WithBaggage(context.Background(), map[string]string{"tenant_id": "t1"})
+	ctx = WithBaggage(ctx, map[string]string{"user_id": "u1", "": "x"})
+
+	if got := BaggageValue(ctx, "tenant_id"); got != "t1" {
+		t.Fatalf("expected tenant_id t1, got %q", got)
+	}
+	if got := BaggageValue(ctx, "user_id"); got != "u1" {
+		t.Fatalf("expected user_id u1, got %q", got)
+	}
+	if got := BaggageValue(ctx, ""); got != "" {
+		t.Fatalf("expected the empty key to be skipped, got %q", got)
+	}
+}
+
+func TestBaggageAttributes(t *testing.T) {
+	ctx := WithBaggage(context.Background(), map[string]string{"tenant_id": "t1", "user_id": "u1"})
+
+	attrs := BaggageAttributes(ctx, "tenant_id", "missing")
+	if len(attrs) != 1 || attrs[0] != attribute.String("tenant_id", "t1") {
+		t.Fatalf("expected only tenant_id, got %v", attrs)
+	}
+}
+
+func TestPropagator_CarriesBaggageAndTraceContext(t *testing.T) {
+	useRecorder(t)
+	ctx := WithBaggage(context.Background(), map[string]string{"tenant_id": "t1"})
+
+	var header http.Header
+	_ = WithSpan(ctx, "outbound", func(ctx context.Context) error {
+		header = http.Header{}
+		Propagator().Inject(ctx, propagation.HeaderCarrier(header))
+		return nil
+	})
+	if header.Get("traceparent") == "" || header.Get("baggage") != "tenant_id=t1" {
+		t.Fatalf("expected traceparent and baggage headers, got %v", header)
+	}
+
+	inbound := Propagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+	if got := BaggageValue(inbound, "tenant_id"); got != "t1" {
+		t.Fatalf("expected baggage to survive propagation, got %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricRegistry creates instruments on first use and returns the cached
+// instrument for repeated names, so callers need not declare them up front
+type MetricRegistry struct {
+	meter metric.Meter
+
+	mu          sync.Mutex
+	instruments map[MetricType]map[string]any
+}
+
+// NewMetricRegistry creates a registry backed by meter
+func NewMetricRegistry(meter metric.Meter) *MetricRegistry {
+	return &MetricRegistry{
+		meter:       meter,
+		instruments: make(map[MetricType]map[string]any),
+	}
+}
+
+// Counter returns the int64 counter called name, creating it with opts on first use
+func (r *MetricRegistry) Counter(name string, opts ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return lookup(r, MetricTypeCounter, name, func() (metric.Int64Counter, error) {
+		return r.meter.Int64Counter(name, opts...)
+	})
+}
+
+// UpDownCounter returns the int64 up-down counter called name, creating it with opts on first use
+func (r *MetricRegistry) UpDownCounter(name string, opts ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	return lookup(r, MetricTypeUpDownCounter, name, func() (metric.Int64UpDownCounter, error) {
+		return r.meter.Int64UpDownCounter(name, opts...)
+	})
+}
+
+// Histogram returns the float64 histogram called name, creating it with opts on first use
+func (r *MetricRegistry) Histogram(name string, opts ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return lookup(r, MetricTypeHistogram, name, func() (metric.Float64Histogram, error) {
+		return r.meter.Float64Histogram(name, opts...)
+	})
+}
+
+// lookup returns the cached instrument of the given type, creating it if needed.
+// Failed creations are not cached, so a later call can retry.
+func lookup[T any](r *MetricRegistry, kind MetricType, name string, create func() (T, error)) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.instruments[kind][name]; ok {
+		return cached.(T), nil
+	}
+
+	instrument, err := create()
+	if err != nil {
+		return instrument, err
+	}
+	if r.instruments[kind] == nil {
+		r.instruments[kind] = make(map[string]any)
+	}
+	r.instruments[kind][name] = instrument
+	return instrument, nil
+}
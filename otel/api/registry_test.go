@@ -0,0 +1,48 @@
+package api
+
+import (
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestMetricRegistry_ReturnsCachedInstruments(t *testing.T) {
+	registry := NewMetricRegistry(sdkmetric.NewMeterProvider().Meter("test"))
+
+	first, err := registry.Counter("http.requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := registry.Counter("http.requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("expected the same counter for a repeated name")
+	}
+
+	h1, err := registry.Histogram("http.duration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := registry.Histogram("http.duration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Fatal("expected the same histogram for a repeated name")
+	}
+
+	other, err := registry.Counter("http.errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == first {
+		t.Fatal("expected a distinct counter for a different name")
+	}
+
+	// The same name may be used by a different instrument type
+	if _, err := registry.UpDownCounter("http.requests"); err != nil {
+		t.Fatal(err)
+	}
+}
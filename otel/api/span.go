@@ -0,0 +1,23 @@
+package api
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used by WithSpan
+const TracerName = "github.com/bignyap/go-utilities/otel/api"
+
+// WithSpan runs fn inside a new span from the global tracer provider. An error
+// returned by fn is recorded on the span, sets its status to Error and is
+// returned unchanged.
+func WithSpan(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...trace.SpanStartOption) error {
+	ctx, span := otel.Tracer(TracerName).Start(ctx, name, opts...)
+	defer span.End()
+
+	err := fn(ctx)
+	RecordError(ctx, err)
+	return err
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useRecorder installs a global tracer provider that records spans for the test
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestWithSpan_RecordsError(t *testing.T) {
+	recorder := useRecorder(t)
+	boom := errors.New("boom")
+
+	err := WithSpan(context.Background(), "load-user", func(ctx context.Context) error {
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			t.Error("fn should receive a context carrying the span")
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected fn's error to be returned, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 ended span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "load-user" {
+		t.Fatalf("unexpected span name %q", span.Name())
+	}
+	if span.Status().Code != codes.Error || span.Status().Description != "boom" {
+		t.Fatalf("expected error status, got %+v", span.Status())
+	}
+	if len(span.Events()) != 1 {
+		t.Fatalf("expected the error to be recorded as an event, got %d events", len(span.Events()))
+	}
+}
+
+func TestWithSpan_Success(t *testing.T) {
+	recorder := useRecorder(t)
+
+	if err := WithSpan(context.Background(), "ok", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Unset || len(spans[0].Events()) != 0 {
+		t.Fatalf("expected one clean span, got %+v", spans)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 // ExporterType defines the type of exporter to use
@@ -11,6 +12,7 @@ type ExporterType string
 const (
 	ExporterTypeConsole    ExporterType = "console"
 	ExporterTypeOTLP       ExporterType = "otlp"
+	ExporterTypeOTLPHTTP   ExporterType = "otlp-http"
 	ExporterTypeElasticAPM ExporterType = "elastic-apm"
 )
 
@@ -21,6 +23,9 @@ const (
 	SamplingTypeAlwaysOn  SamplingType = "always-on"
 	SamplingTypeAlwaysOff SamplingType = "always-off"
 	SamplingTypeTraceID   SamplingType = "traceid-ratio"
+	// SamplingTypeParentBased follows the parent span's decision and
+	// ratio-samples root spans
+	SamplingTypeParentBased SamplingType = "parentbased-traceid-ratio"
 )
 
 // OtelConfig is the main configuration for OpenTelemetry
@@ -42,8 +47,14 @@ type OtelConfig struct {
 
 	// Enable/disable metrics
 	EnableMetrics bool
+
+	// MetricInterval is how often metrics are exported (default 10s when zero)
+	MetricInterval time.Duration
 }
 
+// DefaultMetricInterval is used when MetricInterval is not set
+const DefaultMetricInterval = 10 * time.Second
+
 // ResourceConfig contains service resource attributes
 type ResourceConfig struct {
 	// ServiceName is the name of the service
@@ -64,20 +75,58 @@ type ResourceConfig struct {
 
 // ExporterConfig contains exporter configuration
 type ExporterConfig struct {
-	// Type is the exporter type (console, otlp, elastic-apm)
+	// Type is the exporter type (console, otlp, otlp-http, elastic-apm)
 	Type ExporterType
 
-	// Endpoint is the exporter endpoint (for OTLP and Elastic APM)
+	// Endpoint is the exporter endpoint (for OTLP). For otlp-http it may be
+	// host:port or a full URL such as http://jaeger:4318/v1/traces. For otlp
+	// (gRPC) a URL's scheme selects plaintext (http) or TLS (https).
 	Endpoint string
 
 	// Headers are additional headers to send with exports
 	Headers map[string]string
 
-	// Insecure disables TLS for gRPC connections
+	// Insecure disables TLS for OTLP connections
 	Insecure bool
 
+	// BearerToken, if set, is sent as an "authorization: Bearer <token>" header
+	BearerToken string
+
+	// TLS configures the CA and client certificate for secure OTLP collectors
+	TLS TLSConfig
+
 	// ElasticAPMConfig contains Elastic APM specific configuration
 	ElasticAPM ElasticAPMConfig
+
+	// BatchMaxQueueSize is the span queue size before spans are dropped (traces only)
+	BatchMaxQueueSize int
+
+	// BatchMaxExportSize is the maximum number of spans per export (traces only)
+	BatchMaxExportSize int
+
+	// BatchTimeout is the longest a span waits before its batch is exported (traces only)
+	BatchTimeout time.Duration
+
+	// ExportTimeout bounds each export call
+	ExportTimeout time.Duration
+}
+
+// TLSConfig contains TLS settings for OTLP exporters
+type TLSConfig struct {
+	// CAFile is a PEM bundle used to verify the collector instead of the system roots
+	CAFile string
+
+	// CertFile and KeyFile are the client certificate and key for mTLS
+	CertFile string
+	KeyFile  string
+
+	// ServerNameOverride replaces the host name verified against the collector certificate
+	ServerNameOverride string
+}
+
+// IsSet reports whether any TLS setting is configured
+func (t TLSConfig) IsSet() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerNameOverride != ""
 }
 
 // ElasticAPMConfig contains Elastic APM specific configuration
@@ -103,8 +152,15 @@ type SamplingConfig struct {
 	// Type is the sampling type
 	Type SamplingType
 
-	// Ratio is the sampling ratio (0.0 to 1.0) for traceid-ratio sampling
+	// Ratio is the sampling ratio (0.0 to 1.0) for traceid-ratio and
+	// parentbased-traceid-ratio sampling
 	Ratio float64
+
+	// RouteOverrides sets the sampling ratio for spans whose http.route
+	// attribute matches a key, e.g. {"/checkout": 1.0} to always sample
+	// checkouts. Other spans use Type. With parent-based sampling the
+	// overrides apply to root spans only.
+	RouteOverrides map[string]float64
 }
 
 // Validate validates the configuration
@@ -125,17 +181,52 @@ func (c *OtelConfig) Validate() error {
 		}
 	}
 
-	if c.Sampling.Type == SamplingTypeTraceID {
-		if c.Sampling.Ratio < 0 || c.Sampling.Ratio > 1 {
+	if c.MetricInterval < 0 {
+		return fmt.Errorf("metric interval must not be negative")
+	}
+
+	if err := c.Sampling.Validate(); err != nil {
+		return fmt.Errorf("sampling config invalid: %w", err)
+	}
+
+	return nil
+}
+
+// Validate validates the sampling configuration. An empty Type means always-on.
+func (s *SamplingConfig) Validate() error {
+	switch s.Type {
+	case "", SamplingTypeAlwaysOn, SamplingTypeAlwaysOff:
+	case SamplingTypeTraceID, SamplingTypeParentBased:
+		if s.Ratio < 0 || s.Ratio > 1 {
 			return fmt.Errorf("sampling ratio must be between 0.0 and 1.0")
 		}
+	default:
+		return fmt.Errorf("unknown sampling type: %s", s.Type)
 	}
 
+	for route, ratio := range s.RouteOverrides {
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("sampling ratio for route %s must be between 0.0 and 1.0", route)
+		}
+	}
 	return nil
 }
 
 // Validate validates the exporter configuration
 func (e *ExporterConfig) Validate() error {
+	if e.BatchMaxQueueSize < 0 || e.BatchMaxExportSize < 0 || e.BatchTimeout < 0 || e.ExportTimeout < 0 {
+		return fmt.Errorf("batch and export settings must not be negative")
+	}
+	if e.BatchMaxQueueSize > 0 && e.BatchMaxExportSize > e.BatchMaxQueueSize {
+		return fmt.Errorf("batch max export size must not exceed the max queue size")
+	}
+	if (e.TLS.CertFile == "") != (e.TLS.KeyFile == "") {
+		return fmt.Errorf("TLS cert file and key file must be set together")
+	}
+	if e.Insecure && e.TLS.IsSet() {
+		return fmt.Errorf("TLS settings cannot be combined with insecure")
+	}
+
 	switch e.Type {
 	case ExporterTypeConsole:
 		// Console exporter doesn't need additional validation
@@ -145,6 +236,11 @@ func (e *ExporterConfig) Validate() error {
 			return fmt.Errorf("OTLP endpoint is required")
 		}
 		return nil
+	case ExporterTypeOTLPHTTP:
+		if e.Endpoint == "" {
+			return fmt.Errorf("OTLP HTTP endpoint is required")
+		}
+		return nil
 	case ExporterTypeElasticAPM:
 		if e.ElasticAPM.ServerURL == "" {
 			return fmt.Errorf("Elastic APM server URL is required")
@@ -178,8 +274,9 @@ func DefaultConfig() OtelConfig {
 			Type:  SamplingTypeAlwaysOn,
 			Ratio: 1.0,
 		},
-		EnableTraces:  true,
-		EnableMetrics: true,
+		EnableTraces:   true,
+		EnableMetrics:  true,
+		MetricInterval: DefaultMetricInterval,
 	}
 }
 
@@ -0,0 +1,34 @@
+package initialize
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bignyap/go-utilities/otel/api"
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+)
+
+// StartRuntimeMetrics registers Go runtime metrics (goroutines, GC, heap and
+// scheduler) against the provider's meter. They are exported with the
+// provider's other metrics until it is shut down.
+func StartRuntimeMetrics(provider api.Provider) error {
+	if provider == nil {
+		return errors.New("telemetry provider is required for runtime metrics")
+	}
+	if err := runtime.Start(runtime.WithMeterProvider(meterProvider{provider: provider})); err != nil {
+		return fmt.Errorf("failed to start runtime metrics: %w", err)
+	}
+	return nil
+}
+
+// meterProvider adapts an api.Provider to metric.MeterProvider
+type meterProvider struct {
+	embedded.MeterProvider
+	provider api.Provider
+}
+
+func (m meterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return m.provider.Meter(name, opts...)
+}
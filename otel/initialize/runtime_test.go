@@ -0,0 +1,61 @@
+package initialize
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// readerProvider is an api.Provider whose metrics are collected on demand
+type readerProvider struct {
+	meters *sdkmetric.MeterProvider
+}
+
+func (p readerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return tracenoop.NewTracerProvider().Tracer(name, opts...)
+}
+
+func (p readerProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.meters.Meter(name, opts...)
+}
+
+func (p readerProvider) Shutdown(ctx context.Context) error {
+	return p.meters.Shutdown(ctx)
+}
+
+func TestStartRuntimeMetrics_RegistersRuntimeInstruments(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := readerProvider{meters: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))}
+	defer provider.Shutdown(context.Background())
+
+	if err := StartRuntimeMetrics(provider); err != nil {
+		t.Fatalf("StartRuntimeMetrics: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			names[m.Name] = true
+		}
+	}
+	for _, want := range []string{"go.goroutine.count", "go.memory.used"} {
+		if !names[want] {
+			t.Errorf("expected runtime metric %q, got %v", want, names)
+		}
+	}
+}
+
+func TestStartRuntimeMetrics_NilProvider(t *testing.T) {
+	if err := StartRuntimeMetrics(nil); err == nil {
+		t.Fatal("expected an error for a nil provider")
+	}
+}
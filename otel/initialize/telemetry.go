@@ -26,10 +26,11 @@ type TelemetryConfig struct {
 //   - OTEL_SERVICE_NAME: Service name for telemetry (default: TelemetryConfig.ServiceName)
 //   - OTEL_SERVICE_VERSION: Service version (default: "1.0.0")
 //   - OTEL_SERVICE_ENVIRONMENT: Environment name (default: "dev")
-//   - OTEL_SAMPLING_TYPE: Sampling type - "traceid" or "always" (default: "traceid")
+//   - OTEL_SAMPLING_TYPE: Sampling type - "traceid", "parentbased" or "always", or a config.SamplingType (default: "traceid")
 //   - OTEL_SAMPLING_RATIO: Sampling ratio 0.0-1.0 (default: 1.0)
 //   - ELASTIC_APM_SERVER_URL: Elastic APM server URL (default: "http://apm-server:8200")
 //   - ELASTIC_APM_SECRET_TOKEN: Elastic APM secret token (default: "")
+//   - OTEL_ENABLE_RUNTIME_METRICS: Export Go runtime metrics when metrics are enabled (default: false)
 //
 // Returns nil provider if both traces and metrics are disabled.
 func InitializeTelemetryFromEnv(cfg TelemetryConfig) (api.Provider, error) {
@@ -58,7 +59,7 @@ func InitializeTelemetryFromEnv(cfg TelemetryConfig) (api.Provider, error) {
 	if enableTraces {
 		samplingRatio, _ := strconv.ParseFloat(getEnvOrDefault("OTEL_SAMPLING_RATIO", "1.0"), 64)
 		otelCfg.Sampling = config.SamplingConfig{
-			Type:  config.SamplingType(getEnvOrDefault("OTEL_SAMPLING_TYPE", "traceid")),
+			Type:  samplingType(getEnvOrDefault("OTEL_SAMPLING_TYPE", "traceid")),
 			Ratio: samplingRatio,
 		}
 
@@ -90,9 +91,31 @@ func InitializeTelemetryFromEnv(cfg TelemetryConfig) (api.Provider, error) {
 		return nil, fmt.Errorf("failed to create telemetry provider: %w", err)
 	}
 
+	enableRuntime, _ := strconv.ParseBool(getEnvOrDefault("OTEL_ENABLE_RUNTIME_METRICS", "false"))
+	if enableMetrics && enableRuntime {
+		if err := StartRuntimeMetrics(provider); err != nil {
+			_ = provider.Shutdown(context.Background())
+			return nil, err
+		}
+	}
+
 	return provider, nil
 }
 
+// samplingType maps the short OTEL_SAMPLING_TYPE names onto sampling types
+func samplingType(name string) config.SamplingType {
+	switch name {
+	case "traceid":
+		return config.SamplingTypeTraceID
+	case "parentbased":
+		return config.SamplingTypeParentBased
+	case "always":
+		return config.SamplingTypeAlwaysOn
+	default:
+		return config.SamplingType(name)
+	}
+}
+
 // ShutdownTelemetry gracefully shuts down the telemetry provider.
 // It's safe to call with a nil provider.
 func ShutdownTelemetry(provider api.Provider) error {
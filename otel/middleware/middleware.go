@@ -2,14 +2,18 @@ package middleware
 
 import (
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/bignyap/go-utilities/otel/api"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -24,16 +28,89 @@ func OtelMiddlewareWithConfig(serviceName string, provider api.Provider, opts ..
 	return otelgin.Middleware(serviceName, opts...)
 }
 
+// UnmatchedRoute is the route label for requests that match no registered route,
+// so unknown paths do not each become their own label
+const UnmatchedRoute = "unmatched"
+
+// Option configures CustomSpanMiddleware and MetricsMiddleware
+type Option func(*options)
+
+type options struct {
+	excludedPaths []string
+	baggageKeys   []string
+}
+
+// WithExcludedPaths skips instrumentation for matching request paths, e.g.
+// health checks. A pattern ending in * matches by prefix ("/debug/*");
+// other patterns are matched with path.Match ("/healthz", "/api/*/ping").
+func WithExcludedPaths(patterns ...string) Option {
+	return func(o *options) {
+		o.excludedPaths = append(o.excludedPaths, patterns...)
+	}
+}
+
+// WithBaggageAttributes copies the named baggage entries of the inbound
+// request onto the server span as attributes, e.g. "tenant_id". It applies
+// to CustomSpanMiddleware only.
+func WithBaggageAttributes(keys ...string) Option {
+	return func(o *options) {
+		o.baggageKeys = append(o.baggageKeys, keys...)
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// excluded reports whether requestPath matches an excluded pattern
+func (o *options) excluded(requestPath string) bool {
+	for _, pattern := range o.excludedPaths {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(requestPath, prefix) {
+			return true
+		}
+		if matched, _ := path.Match(pattern, requestPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// routeLabel returns the matched route template, or UnmatchedRoute
+func routeLabel(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return UnmatchedRoute
+}
+
 // CustomSpanMiddleware creates a custom span for each request with additional attributes
-func CustomSpanMiddleware(provider api.Provider) gin.HandlerFunc {
+func CustomSpanMiddleware(provider api.Provider, opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts)
 	return func(c *gin.Context) {
+		if o.excluded(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
 		tracer := provider.Tracer("gin-http-server")
+		route := routeLabel(c)
+
+		// Continue the caller's trace and baggage from the request headers,
+		// unless an outer middleware such as otelgin already started a span
+		parent := c.Request.Context()
+		if !trace.SpanContextFromContext(parent).IsValid() {
+			parent = otel.GetTextMapPropagator().Extract(parent, propagation.HeaderCarrier(c.Request.Header))
+		}
 
-		ctx, span := tracer.Start(c.Request.Context(), fmt.Sprintf("%s %s", c.Request.Method, c.FullPath()),
+		ctx, span := tracer.Start(parent, fmt.Sprintf("%s %s", c.Request.Method, route),
 			trace.WithSpanKind(trace.SpanKindServer),
 			trace.WithAttributes(
 				attribute.String(api.HTTPMethodKey, c.Request.Method),
-				attribute.String(api.HTTPRouteKey, c.FullPath()),
+				attribute.String(api.HTTPRouteKey, route),
 				attribute.String(api.HTTPTargetKey, c.Request.URL.Path),
 				attribute.String(api.HTTPHostKey, c.Request.Host),
 				attribute.String(api.HTTPSchemeKey, c.Request.URL.Scheme),
@@ -42,6 +119,10 @@ func CustomSpanMiddleware(provider api.Provider) gin.HandlerFunc {
 		)
 		defer span.End()
 
+		if len(o.baggageKeys) > 0 {
+			span.SetAttributes(api.BaggageAttributes(ctx, o.baggageKeys...)...)
+		}
+
 		// Store the context with span in the Gin context
 		c.Request = c.Request.WithContext(ctx)
 
@@ -62,7 +143,8 @@ func CustomSpanMiddleware(provider api.Provider) gin.HandlerFunc {
 }
 
 // MetricsMiddleware records HTTP metrics for each request
-func MetricsMiddleware(provider api.Provider) gin.HandlerFunc {
+func MetricsMiddleware(provider api.Provider, opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts)
 	meter := provider.Meter("gin-http-server")
 
 	// Create metrics
@@ -83,11 +165,17 @@ func MetricsMiddleware(provider api.Provider) gin.HandlerFunc {
 	)
 
 	return func(c *gin.Context) {
+		if o.excluded(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		route := routeLabel(c)
+
 		// Increment active requests
 		activeRequests.Add(c.Request.Context(), 1,
 			metric.WithAttributes(
 				attribute.String("http.method", c.Request.Method),
-				attribute.String("http.route", c.FullPath()),
+				attribute.String("http.route", route),
 			),
 		)
 
@@ -103,7 +191,7 @@ func MetricsMiddleware(provider api.Provider) gin.HandlerFunc {
 		// Common attributes
 		attrs := metric.WithAttributes(
 			attribute.String("http.method", c.Request.Method),
-			attribute.String("http.route", c.FullPath()),
+			attribute.String("http.route", route),
 			attribute.Int("http.status_code", c.Writer.Status()),
 		)
 
@@ -115,7 +203,7 @@ func MetricsMiddleware(provider api.Provider) gin.HandlerFunc {
 		activeRequests.Add(c.Request.Context(), -1,
 			metric.WithAttributes(
 				attribute.String("http.method", c.Request.Method),
-				attribute.String("http.route", c.FullPath()),
+				attribute.String("http.route", route),
 			),
 		)
 	}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bignyap/go-utilities/otel/api"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testProvider records spans and collects metrics on demand
+type testProvider struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+	tp     *sdktrace.TracerProvider
+	mp     *sdkmetric.MeterProvider
+}
+
+func newTestProvider() *testProvider {
+	p := &testProvider{spans: tracetest.NewSpanRecorder(), reader: sdkmetric.NewManualReader()}
+	p.tp = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p.spans))
+	p.mp = sdkmetric.NewMeterProvider(sdkmetric.WithReader(p.reader))
+	return p
+}
+
+func (p *testProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return p.tp.Tracer(name, opts...)
+}
+
+func (p *testProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.mp.Meter(name, opts...)
+}
+
+func (p *testProvider) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// requestRoutes returns the http.route of every request counted so far
+func (p *testProvider) requestRoutes(t *testing.T) []string {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := p.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var routes []string
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != "http.server.requests" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				route, _ := dp.Attributes.Value(attribute.Key("http.route"))
+				for i := int64(0); i < dp.Value; i++ {
+					routes = append(routes, route.AsString())
+				}
+			}
+		}
+	}
+	return routes
+}
+
+func newTestRouter(p *testProvider, opts ...Option) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CustomSpanMiddleware(p, opts...), MetricsMiddleware(p, opts...))
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/debug/vars", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func serve(router *gin.Engine, target string) {
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+func TestMiddleware_ExcludedPathsAreNotInstrumented(t *testing.T) {
+	p := newTestProvider()
+	router := newTestRouter(p, WithExcludedPaths("/healthz", "/debug/*"))
+
+	serve(router, "/healthz")
+	serve(router, "/debug/vars")
+	if spans := p.spans.Ended(); len(spans) != 0 {
+		t.Fatalf("expected no spans for excluded paths, got %d", len(spans))
+	}
+	if routes := p.requestRoutes(t); len(routes) != 0 {
+		t.Fatalf("expected no metrics for excluded paths, got %v", routes)
+	}
+
+	serve(router, "/users/42")
+	spans := p.spans.Ended()
+	if len(spans) != 1 || spans[0].Name() != "GET /users/:id" {
+		t.Fatalf("expected one span named after the route template, got %d", len(spans))
+	}
+	if routes := p.requestRoutes(t); len(routes) != 1 || routes[0] != "/users/:id" {
+		t.Fatalf("unexpected routes %v", routes)
+	}
+}
+
+func TestMiddleware_UnmatchedRouteLabel(t *testing.T) {
+	p := newTestProvider()
+	router := newTestRouter(p)
+
+	serve(router, "/no/such/path")
+	serve(router, "/another/missing/path")
+
+	spans := p.spans.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		if span.Name() != "GET "+UnmatchedRoute {
+			t.Fatalf("unexpected span name %q", span.Name())
+		}
+	}
+	if routes := p.requestRoutes(t); len(routes) != 2 || routes[0] != UnmatchedRoute || routes[1] != UnmatchedRoute {
+		t.Fatalf("expected both 404s under %q, got %v", UnmatchedRoute, routes)
+	}
+}
+
+func TestCustomSpanMiddleware_BaggageAttributes(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(api.Propagator())
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	p := newTestProvider()
+	router := newTestRouter(p, WithBaggageAttributes("tenant_id", "user_id"))
+
+	var handlerTenant string
+	router.GET("/orders", func(c *gin.Context) {
+		handlerTenant = api.BaggageValue(c.Request.Context(), "tenant_id")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("baggage", "tenant_id=t1,session=s1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := p.spans.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected the span to continue the inbound trace, got %s", got)
+	}
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	if v, ok := attrs.Value("tenant_id"); !ok || v.AsString() != "t1" {
+		t.Fatalf("expected tenant_id baggage as an attribute, got %v", spans[0].Attributes())
+	}
+	if attrs.HasValue("user_id") || attrs.HasValue("session") {
+		t.Fatalf("expected only configured, present keys, got %v", spans[0].Attributes())
+	}
+	if handlerTenant != "t1" {
+		t.Fatalf("expected the baggage to reach the handler context, got %q", handlerTenant)
+	}
+}
+
+func TestCustomSpanMiddleware_NestsUnderExistingSpan(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(api.Propagator())
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	p := newTestProvider()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var outer trace.SpanContext
+	// Stands in for otelgin, which has already continued the inbound trace
+	router.Use(func(c *gin.Context) {
+		ctx, span := p.Tracer("outer").Start(c.Request.Context(), "outer")
+		defer span.End()
+		outer = span.SpanContext()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}, CustomSpanMiddleware(p))
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := p.spans.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if got := spans[0].Parent().SpanID(); got != outer.SpanID() {
+		t.Fatalf("expected the span to nest under the outer span %s, got parent %s", outer.SpanID(), got)
+	}
+}
@@ -0,0 +1,88 @@
+// Package paginate provides opaque pagination cursors shared across packages
+package paginate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or its signature does not match
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Option configures cursor encoding and decoding
+type Option func(*options)
+
+type options struct {
+	secret []byte
+}
+
+// WithSecret signs cursors with HMAC-SHA256 so clients cannot forge them.
+// Decoding with a secret rejects unsigned or tampered cursors.
+func WithSecret(secret []byte) Option {
+	return func(o *options) {
+		o.secret = secret
+	}
+}
+
+var encoding = base64.RawURLEncoding
+
+// EncodeCursor serializes v as base64url JSON, appending ".<signature>" when signed
+func EncodeCursor(v any, opts ...Option) (string, error) {
+	o := applyOptions(opts)
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	cursor := encoding.EncodeToString(data)
+	if o.secret == nil {
+		return cursor, nil
+	}
+	return cursor + "." + encoding.EncodeToString(sign(o.secret, cursor)), nil
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor into dst
+func DecodeCursor(s string, dst any, opts ...Option) error {
+	o := applyOptions(opts)
+
+	payload, sig, signed := strings.Cut(s, ".")
+	if o.secret != nil {
+		if !signed {
+			return fmt.Errorf("%w: missing signature", ErrInvalidCursor)
+		}
+		got, err := encoding.DecodeString(sig)
+		if err != nil || !hmac.Equal(got, sign(o.secret, payload)) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+		}
+	} else if signed {
+		return fmt.Errorf("%w: unexpected signature", ErrInvalidCursor)
+	}
+
+	data, err := encoding.DecodeString(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return nil
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func sign(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
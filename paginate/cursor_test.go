@@ -0,0 +1,91 @@
+package paginate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pageCursor struct {
+	LastID    int64     `json:"last_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Direction string    `json:"direction"`
+}
+
+func testCursor() pageCursor {
+	return pageCursor{LastID: 42, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Direction: "next"}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	encoded, err := EncodeCursor(testCursor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(encoded, "+/=.") {
+		t.Fatalf("expected an unsigned URL-safe cursor, got %q", encoded)
+	}
+
+	var decoded pageCursor
+	if err := DecodeCursor(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != testCursor() {
+		t.Fatalf("expected %+v, got %+v", testCursor(), decoded)
+	}
+}
+
+func TestCursor_SignedRoundTrip(t *testing.T) {
+	secret := WithSecret([]byte("cursor-secret"))
+	encoded, err := EncodeCursor(testCursor(), secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded pageCursor
+	if err := DecodeCursor(encoded, &decoded, secret); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != testCursor() {
+		t.Fatalf("expected %+v, got %+v", testCursor(), decoded)
+	}
+}
+
+func TestCursor_RejectsTampered(t *testing.T) {
+	secret := WithSecret([]byte("cursor-secret"))
+	encoded, _ := EncodeCursor(testCursor(), secret)
+	payload, sig, _ := strings.Cut(encoded, ".")
+
+	forged := testCursor()
+	forged.LastID = 1
+	forgedPayload, _ := EncodeCursor(forged)
+
+	tests := map[string]string{
+		"swapped payload": forgedPayload + "." + sig,
+		"altered sig":     payload + "." + strings.Repeat("A", len(sig)),
+		"missing sig":     payload,
+		"garbage":         "!!!." + sig,
+	}
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			var decoded pageCursor
+			if err := DecodeCursor(cursor, &decoded, secret); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+
+	var decoded pageCursor
+	if err := DecodeCursor(encoded, &decoded, WithSecret([]byte("other"))); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for another secret, got %v", err)
+	}
+}
+
+func TestCursor_RejectsMalformed(t *testing.T) {
+	var decoded pageCursor
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24"} {
+		if err := DecodeCursor(cursor, &decoded); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor for %q, got %v", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLockNotAcquired is returned when the lock is held by someone else
+	ErrLockNotAcquired = errors.New("redis lock: not acquired")
+	// ErrLockNotHeld is returned when releasing or extending a lock whose
+	// token expired or was taken over by another holder
+	ErrLockNotHeld = errors.New("redis lock: not held")
+)
+
+// DefaultLockRetry is the AcquireLockWait interval used when retry is not positive
+const DefaultLockRetry = 100 * time.Millisecond
+
+// Both scripts only touch the key while it still holds our token
+var (
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Lock is a held lock on a single key, identified by a random token
+type Lock struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+}
+
+// AcquireLock takes the lock on key for ttl using SET NX PX. It returns
+// ErrLockNotAcquired without waiting if the lock is held.
+func (c *Client) AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	client := c.Get()
+	token := uuid.NewString()
+
+	ok, err := client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+	return &Lock{client: client, key: key, token: token}, nil
+}
+
+// AcquireLockWait retries AcquireLock every retry interval until the lock is
+// taken or ctx is done. A retry of zero or less uses DefaultLockRetry.
+func (c *Client) AcquireLockWait(ctx context.Context, key string, ttl, retry time.Duration) (*Lock, error) {
+	if retry <= 0 {
+		retry = DefaultLockRetry
+	}
+	ticker := time.NewTicker(retry)
+	defer ticker.Stop()
+
+	for {
+		lock, err := c.AcquireLock(ctx, key, ttl)
+		if !errors.Is(err, ErrLockNotAcquired) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Key returns the locked key
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Release deletes the lock if it is still ours. Once the token has expired
+// it returns ErrLockNotHeld and leaves any new holder's lock in place.
+func (l *Lock) Release(ctx context.Context) error {
+	n, err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Extend resets the lock's TTL if it is still ours
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	n, err := extendScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
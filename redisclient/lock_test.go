@@ -0,0 +1,232 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// lockHook serves the commands used by Lock from memory, with a settable
+// clock for expiry, so no Redis server is needed
+type lockHook struct {
+	mu     sync.Mutex
+	now    time.Time
+	values map[string]string
+	expiry map[string]time.Time
+}
+
+func newLockHook() *lockHook {
+	return &lockHook{
+		now:    time.Unix(0, 0),
+		values: make(map[string]string),
+		expiry: make(map[string]time.Time),
+	}
+}
+
+func (h *lockHook) advance(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.now = h.now.Add(d)
+}
+
+// get returns the live value of key, dropping it if it has expired
+func (h *lockHook) get(key string) (string, bool) {
+	if exp, ok := h.expiry[key]; ok && !h.now.Before(exp) {
+		delete(h.values, key)
+		delete(h.expiry, key)
+	}
+	v, ok := h.values[key]
+	return v, ok
+}
+
+func (h *lockHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(context.Context, string, string) (net.Conn, error) {
+		return nil, errors.New("lockHook: dialing is not supported")
+	}
+}
+
+func (h *lockHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (h *lockHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		args := cmd.Args()
+		switch cmd.Name() {
+		case "set": // set key value px|ex n nx
+			key, value := args[1].(string), args[2].(string)
+			ttl := time.Duration(args[4].(int64)) * time.Millisecond
+			if args[3] == "ex" {
+				ttl = time.Duration(args[4].(int64)) * time.Second
+			}
+			if _, held := h.get(key); held {
+				cmd.(*redis.BoolCmd).SetVal(false)
+				return nil
+			}
+			h.values[key] = value
+			h.expiry[key] = h.now.Add(ttl)
+			cmd.(*redis.BoolCmd).SetVal(true)
+		case "evalsha": // evalsha sha 1 key token [ttl]
+			sha, key, token := args[1].(string), args[3].(string), args[4].(string)
+			if v, ok := h.get(key); !ok || v != token {
+				cmd.(*redis.Cmd).SetVal(int64(0))
+				return nil
+			}
+			switch sha {
+			case releaseScript.Hash():
+				delete(h.values, key)
+				delete(h.expiry, key)
+			case extendScript.Hash():
+				h.expiry[key] = h.now.Add(time.Duration(args[5].(int64)) * time.Millisecond)
+			}
+			cmd.(*redis.Cmd).SetVal(int64(1))
+		default:
+			cmd.SetErr(errors.New("lockHook: unsupported command " + cmd.Name()))
+		}
+		return nil
+	}
+}
+
+func newLockTestClient(t *testing.T) (*Client, *lockHook) {
+	t.Helper()
+	client, err := newClient(RedisConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hook := newLockHook()
+	client.AddHook(hook)
+	c := wrap(client, RedisConfig{})
+	t.Cleanup(func() { c.Close() })
+	return c, hook
+}
+
+func TestLock_SecondAcquireFailsUntilReleased(t *testing.T) {
+	c, _ := newLockTestClient(t)
+	ctx := context.Background()
+
+	lock, err := c.AcquireLock(ctx, "jobs:leader", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	if _, err := c.AcquireLock(ctx, "jobs:leader", time.Minute); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("expected ErrLockNotAcquired while held, got %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := c.AcquireLock(ctx, "jobs:leader", time.Minute); err != nil {
+		t.Fatalf("expected acquire to succeed after release, got %v", err)
+	}
+}
+
+func TestLock_AcquireAfterExpiry(t *testing.T) {
+	c, hook := newLockTestClient(t)
+	ctx := context.Background()
+
+	if _, err := c.AcquireLock(ctx, "dedup:42", time.Second); err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	hook.advance(2 * time.Second)
+	if _, err := c.AcquireLock(ctx, "dedup:42", time.Second); err != nil {
+		t.Fatalf("expected acquire to succeed after expiry, got %v", err)
+	}
+}
+
+func TestLock_ReleaseAfterExpiryKeepsNewHolder(t *testing.T) {
+	c, hook := newLockTestClient(t)
+	ctx := context.Background()
+
+	stale, err := c.AcquireLock(ctx, "jobs:leader", time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	hook.advance(2 * time.Second)
+	if _, err := c.AcquireLock(ctx, "jobs:leader", time.Minute); err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+
+	if err := stale.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expected ErrLockNotHeld, got %v", err)
+	}
+	if err := stale.Extend(ctx, time.Minute); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expected ErrLockNotHeld from Extend, got %v", err)
+	}
+	if _, err := c.AcquireLock(ctx, "jobs:leader", time.Minute); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("stale release must not free the new holder's lock, got %v", err)
+	}
+}
+
+func TestLock_Extend(t *testing.T) {
+	c, hook := newLockTestClient(t)
+	ctx := context.Background()
+
+	lock, err := c.AcquireLock(ctx, "jobs:leader", time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	if err := lock.Extend(ctx, time.Minute); err != nil {
+		t.Fatalf("Extend: %v", err)
+	}
+	hook.advance(2 * time.Second)
+	if _, err := c.AcquireLock(ctx, "jobs:leader", time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("expected the extended lock to still be held, got %v", err)
+	}
+}
+
+func TestLock_AcquireLockWait(t *testing.T) {
+	c, _ := newLockTestClient(t)
+	ctx := context.Background()
+
+	held, err := c.AcquireLock(ctx, "jobs:leader", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := c.AcquireLockWait(timeoutCtx, "jobs:leader", time.Minute, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		held.Release(ctx)
+	}()
+	lock, err := c.AcquireLockWait(ctx, "jobs:leader", time.Minute, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("AcquireLockWait: %v", err)
+	}
+	if lock.Key() != "jobs:leader" {
+		t.Fatalf("unexpected key %q", lock.Key())
+	}
+}
+
+func TestLock_AcquireLockWaitDefaultsRetry(t *testing.T) {
+	c, _ := newLockTestClient(t)
+	ctx := context.Background()
+
+	held, err := c.AcquireLock(ctx, "jobs:leader", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		held.Release(ctx)
+	}()
+
+	// A zero interval must not panic in time.NewTicker
+	lock, err := c.AcquireLockWait(ctx, "jobs:leader", time.Minute, 0)
+	if err != nil {
+		t.Fatalf("AcquireLockWait: %v", err)
+	}
+	lock.Release(ctx)
+}
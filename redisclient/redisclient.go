@@ -2,13 +2,22 @@ package redisclient
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bignyap/go-utilities/logger/api"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisConfig struct {
+	// URL is a redis:// or rediss:// URL as handed out by managed providers.
+	// When set it replaces Addr/Addrs, Password and DB.
+	URL             string   `json:"url" env:"REDIS_URL"`
 	UseCluster      bool     `json:"use_cluster" env:"REDIS_USE_CLUSTER"`
 	Addrs           []string `json:"addrs" env:"REDIS_ADDRS"` // For cluster
 	Addr            string   `json:"addr" env:"REDIS_ADDR"`   // For single-node
@@ -16,6 +25,16 @@ type RedisConfig struct {
 	DB              int      `json:"db" env:"REDIS_DB"`
 	PoolSize        int      `json:"pool_size" env:"REDIS_POOL_SIZE"`
 	EnableTelemetry bool     `json:"enable_telemetry" env:"REDIS_ENABLE_TELEMETRY"` // Enable OpenTelemetry tracing
+	// UseTLS connects over TLS with a default config verifying the server host
+	UseTLS bool `json:"use_tls" env:"REDIS_USE_TLS"`
+	// TLS overrides the TLS config, e.g. to add a private CA or client certificate
+	TLS *tls.Config `json:"-"`
+	// HealthCheckInterval enables a background ping at this interval; zero disables it
+	HealthCheckInterval time.Duration `json:"health_check_interval" env:"REDIS_HEALTH_CHECK_INTERVAL"`
+	// HealthCheckFailures is the number of consecutive failed background pings
+	// before IsHealthy reports false (default 1). go-redis redials broken
+	// connections itself, so the client is never replaced.
+	HealthCheckFailures int `json:"health_check_failures" env:"REDIS_HEALTH_CHECK_FAILURES"`
 }
 
 func DefaultConfig() RedisConfig {
@@ -42,42 +61,236 @@ func (c *RedisConfig) applyDefaults() {
 	}
 }
 
-func New(ctx context.Context, cfg RedisConfig) (redis.UniversalClient, error) {
+// tlsConfig returns the TLS config to use, or nil for plain connections.
+// urlTLS is the config derived from a rediss:// URL.
+func (c *RedisConfig) tlsConfig(serverName string, urlTLS *tls.Config) *tls.Config {
+	switch {
+	case c.TLS != nil:
+		return c.TLS
+	case urlTLS != nil:
+		return urlTLS
+	case c.UseTLS:
+		return &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName}
+	default:
+		return nil
+	}
+}
 
+// newClient builds the client without connecting
+func newClient(cfg RedisConfig) (redis.UniversalClient, error) {
 	cfg.applyDefaults()
 
-	var client redis.UniversalClient
 	if cfg.UseCluster {
-		client = redis.NewClusterClient(&redis.ClusterOptions{
+		opts := &redis.ClusterOptions{
 			Addrs:    cfg.Addrs,
 			Password: cfg.Password,
 			PoolSize: cfg.PoolSize,
-		})
-	} else {
-		client = redis.NewClient(&redis.Options{
-			Addr:     cfg.Addr,
-			Password: cfg.Password,
-			DB:       cfg.DB,
-			PoolSize: cfg.PoolSize,
-		})
+		}
+		if cfg.URL != "" {
+			parsed, err := redis.ParseClusterURL(cfg.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redis cluster URL: %w", err)
+			}
+			if parsed.PoolSize == 0 {
+				parsed.PoolSize = cfg.PoolSize
+			}
+			opts = parsed
+		}
+		// An empty server name makes crypto/tls verify each node's own host
+		opts.TLSConfig = cfg.tlsConfig("", opts.TLSConfig)
+		return redis.NewClusterClient(opts), nil
+	}
+
+	opts := &redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
+	}
+	if cfg.URL != "" {
+		parsed, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis URL: %w", err)
+		}
+		if parsed.PoolSize == 0 {
+			parsed.PoolSize = cfg.PoolSize
+		}
+		opts = parsed
+	}
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		host = opts.Addr
+	}
+	opts.TLSConfig = cfg.tlsConfig(host, opts.TLSConfig)
+	return redis.NewClient(opts), nil
+}
+
+// instrument adds OpenTelemetry instrumentation if enabled
+func instrument(client redis.UniversalClient, cfg RedisConfig) error {
+	if !cfg.EnableTelemetry {
+		return nil
+	}
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return err
+	}
+	return redisotel.InstrumentMetrics(client)
+}
+
+// Client owns one Redis connection pool. Processes can hold several, e.g.
+// a cache cluster and a separate rate-limit instance.
+type Client struct {
+	client  redis.UniversalClient
+	cfg     RedisConfig
+	logger  api.Logger
+	healthy atomic.Bool
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	doneCh   chan struct{}
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithLogger sets the logger used to report health transitions
+func WithLogger(logger api.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient connects to Redis and verifies the connection with a ping. With
+// cfg.HealthCheckInterval set, it also starts a background health check that
+// runs until Close.
+func NewClient(ctx context.Context, cfg RedisConfig, opts ...ClientOption) (*Client, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctxTimeout).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if err := instrument(client, cfg); err != nil {
+		client.Close()
 		return nil, err
 	}
 
-	// Add OpenTelemetry instrumentation if enabled
-	if cfg.EnableTelemetry {
-		if err := redisotel.InstrumentTracing(client); err != nil {
-			return nil, err
+	c := wrap(client, cfg, opts...)
+	c.healthy.Store(true)
+	if cfg.HealthCheckInterval > 0 {
+		c.startHealthCheck()
+	}
+	return c, nil
+}
+
+func wrap(client redis.UniversalClient, cfg RedisConfig, opts ...ClientOption) *Client {
+	c := &Client{
+		client: client,
+		cfg:    cfg,
+		stopCh: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.logger == nil {
+		c.logger = &api.DefaultLogger{}
+	}
+	c.logger = c.logger.WithComponent("redis")
+	return c
+}
+
+// Get returns the underlying client, e.g. for counter.NewCounterWorker.
+// It stays the same for the Client's lifetime.
+func (c *Client) Get() redis.UniversalClient {
+	return c.client
+}
+
+// Ping checks the connection and records the outcome for IsHealthy
+func (c *Client) Ping(ctx context.Context) error {
+	err := c.client.Ping(ctx).Err()
+	c.setHealthy(ctx, err)
+	return err
+}
+
+// IsHealthy reports whether the last ping succeeded
+func (c *Client) IsHealthy() bool {
+	return c.healthy.Load()
+}
+
+// setHealthy records a ping outcome, logging transitions only
+func (c *Client) setHealthy(ctx context.Context, err error) {
+	healthy := err == nil
+	if c.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		c.logger.Info(ctx, "Redis connection recovered")
+	} else {
+		c.logger.Error(ctx, "Redis connection unhealthy", err)
+	}
+}
+
+func (c *Client) startHealthCheck() {
+	c.doneCh = make(chan struct{})
+	go c.healthCheck(c.cfg.HealthCheckInterval, c.cfg.HealthCheckFailures)
+}
+
+// healthCheck pings every interval and marks the client unhealthy after
+// maxFailures consecutive failures. Recovery is left to the go-redis pool,
+// which redials broken connections on use.
+func (c *Client) healthCheck(interval time.Duration, maxFailures int) {
+	defer close(c.doneCh)
+	maxFailures = max(maxFailures, 1)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := c.client.Ping(ctx).Err()
+		if err == nil {
+			failures = 0
+		} else {
+			failures++
 		}
-		if err := redisotel.InstrumentMetrics(client); err != nil {
-			return nil, err
+		if err == nil || failures >= maxFailures {
+			c.setHealthy(ctx, err)
 		}
+		cancel()
+	}
+}
+
+// Close stops the health check and closes the connection pool. Other
+// Clients are unaffected.
+func (c *Client) Close() error {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	if c.doneCh != nil {
+		<-c.doneCh
 	}
+	return c.client.Close()
+}
 
-	return client, nil
+// New connects to Redis and returns the underlying client. It is kept for
+// callers that do not need the Client wrapper, and never starts the health
+// check since nothing could observe or stop it.
+func New(ctx context.Context, cfg RedisConfig) (redis.UniversalClient, error) {
+	cfg.HealthCheckInterval = 0
+	c, err := NewClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c.Get(), nil
 }
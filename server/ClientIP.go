@@ -0,0 +1,40 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP returns the address of the client that made the request. The
+// X-Forwarded-For and X-Real-IP headers are only honored when
+// Config.ForwardedByClientIP is set and the request came from one of
+// Config.TrustedProxies; otherwise the connection's remote address is used,
+// so clients can't spoof their address by sending the headers themselves.
+func ClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// applyTrustedProxies configures how router derives ClientIP from cfg
+func applyTrustedProxies(router *gin.Engine, cfg *Config) error {
+	router.ForwardedByClientIP = cfg.ForwardedByClientIP
+	router.RemoteIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+	// gin trusts every proxy by default, so an empty list must be explicit
+	return router.SetTrustedProxies(cfg.TrustedProxies)
+}
+
+// validateTrustedProxy checks that proxy is an IP address or CIDR range
+func validateTrustedProxy(proxy string) error {
+	if strings.Contains(proxy, "/") {
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			return fmt.Errorf("invalid server config: trusted proxy %q is not a valid CIDR", proxy)
+		}
+		return nil
+	}
+	if net.ParseIP(proxy) == nil {
+		return fmt.Errorf("invalid server config: trusted proxy %q is not a valid IP address", proxy)
+	}
+	return nil
+}
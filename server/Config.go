@@ -2,10 +2,16 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/bignyap/go-utilities/logger/api"
+	"github.com/caarlos0/env"
 	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
 )
 
 // Server defines the HTTP server contract
@@ -26,13 +32,26 @@ const (
 
 // Config defines runtime configuration
 type Config struct {
-	Port            string
-	Environment     string
-	Version         string
-	MaxRequestSize  int64
-	EnableProfiling bool
-	ShutdownTimeout time.Duration
-	ServerType      ServerType
+	Port            string        `json:"port" yaml:"port" env:"SERVER_PORT"`
+	Environment     string        `json:"environment" yaml:"environment" env:"SERVER_ENVIRONMENT"`
+	Version         string        `json:"version" yaml:"version" env:"SERVER_VERSION"`
+	MaxRequestSize  int64         `json:"max_request_size" yaml:"max_request_size" env:"SERVER_MAX_REQUEST_SIZE"`
+	EnableProfiling bool          `json:"enable_profiling" yaml:"enable_profiling" env:"SERVER_ENABLE_PROFILING"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	ServerType      ServerType    `json:"server_type" yaml:"server_type" env:"SERVER_TYPE"`
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set
+	TLSCertFile string `json:"tls_cert_file" yaml:"tls_cert_file" env:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile  string `json:"tls_key_file" yaml:"tls_key_file" env:"SERVER_TLS_KEY_FILE"`
+	// TLSCertReload picks up rotated certificate files on the next handshake
+	TLSCertReload bool `json:"tls_cert_reload" yaml:"tls_cert_reload" env:"SERVER_TLS_CERT_RELOAD"`
+	// DisableNoRouteHandlers keeps gin's plaintext 404 and 405 responses, for
+	// applications that register their own NoRoute and NoMethod handlers
+	DisableNoRouteHandlers bool `json:"disable_no_route_handlers" yaml:"disable_no_route_handlers" env:"SERVER_DISABLE_NO_ROUTE_HANDLERS"`
+	// ForwardedByClientIP derives ClientIP from X-Forwarded-For and X-Real-IP
+	// for requests arriving from TrustedProxies (IPs or CIDR ranges). Other
+	// requests, and all requests when it is unset, use the remote address.
+	ForwardedByClientIP bool     `json:"forwarded_by_client_ip" yaml:"forwarded_by_client_ip" env:"SERVER_FORWARDED_BY_CLIENT_IP"`
+	TrustedProxies      []string `json:"trusted_proxies" yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
 }
 
 func DefaultConfig(serverType ServerType) *Config {
@@ -47,6 +66,111 @@ func DefaultConfig(serverType ServerType) *Config {
 	}
 }
 
+// LoadConfig builds a Config from the defaults, overlaid by the JSON or YAML
+// file at path (skipped if path is empty or the file does not exist), then
+// by SERVER_* environment variables. The result is validated.
+// Durations may be written as strings such as "15s" in either file format.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig(ServerHTTP)
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadConfigFromEnv builds a Config from the defaults overlaid by SERVER_*
+// environment variables (SERVER_PORT, SERVER_ENVIRONMENT or its short form
+// SERVER_ENV, SERVER_VERSION, SERVER_MAX_REQUEST_SIZE,
+// SERVER_SHUTDOWN_TIMEOUT, ...). The result is validated.
+func LoadConfigFromEnv() (*Config, error) {
+	cfg := DefaultConfig(ServerHTTP)
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadConfigFromFile builds a Config from the defaults overlaid by the JSON
+// or YAML file at path, which must exist. Environment variables are ignored.
+// The result is validated.
+func LoadConfigFromFile(path string) (*Config, error) {
+	cfg := DefaultConfig(ServerHTTP)
+	if err := cfg.loadFile(path); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the JSON or YAML file at path. A missing file returns an
+// error wrapping os.ErrNotExist.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	// JSON is valid YAML, so one decoder handles both formats
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv overlays SERVER_* environment variables
+func (c *Config) loadEnv() error {
+	if err := env.Parse(c); err != nil {
+		return fmt.Errorf("failed to load server config from env: %w", err)
+	}
+	if _, ok := os.LookupEnv("SERVER_ENVIRONMENT"); !ok {
+		if environment, ok := os.LookupEnv("SERVER_ENV"); ok {
+			c.Environment = environment
+		}
+	}
+	return nil
+}
+
+// Validate checks that the config can be used to start a server
+func (c *Config) Validate() error {
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid server config: port %q must be a number between 1 and 65535", c.Port)
+	}
+	if c.ServerType != ServerHTTP && c.ServerType != ServerGRPC {
+		return fmt.Errorf("invalid server config: unsupported server type %q", c.ServerType)
+	}
+	if c.MaxRequestSize <= 0 {
+		return fmt.Errorf("invalid server config: max request size must be positive")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid server config: shutdown timeout must be positive")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("invalid server config: TLS cert and key files must be set together")
+	}
+	if c.TLSCertReload && c.TLSCertFile == "" {
+		return fmt.Errorf("invalid server config: TLS cert reload requires cert and key files")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if err := validateTrustedProxy(proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Handler allows for modular startup and teardown
 type Handler interface {
 	Setup(server Server) error
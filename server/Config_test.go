@@ -0,0 +1,158 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_FileOverridesDefaults(t *testing.T) {
+	path := writeConfigFile(t, "server.yaml", `
+port: "9090"
+environment: staging
+shutdown_timeout: 30s
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != "9090" || cfg.Environment != "staging" || cfg.ShutdownTimeout != 30*time.Second {
+		t.Fatalf("expected file values, got %+v", cfg)
+	}
+	// Fields missing from the file keep their defaults
+	defaults := DefaultConfig(ServerHTTP)
+	if cfg.MaxRequestSize != defaults.MaxRequestSize || cfg.Version != defaults.Version || cfg.ServerType != ServerHTTP {
+		t.Fatalf("expected defaults for unset fields, got %+v", cfg)
+	}
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, "server.json", `{"port": "9090", "environment": "staging", "version": "1.2.0", "shutdown_timeout": "30s"}`)
+	t.Setenv("SERVER_PORT", "7070")
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "5s")
+	t.Setenv("SERVER_ENABLE_PROFILING", "true")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != "7070" || cfg.ShutdownTimeout != 5*time.Second || !cfg.EnableProfiling {
+		t.Fatalf("expected env values, got %+v", cfg)
+	}
+	if cfg.Environment != "staging" || cfg.Version != "1.2.0" {
+		t.Fatalf("expected file values where env is unset, got %+v", cfg)
+	}
+}
+
+func TestLoadConfig_MissingFileUsesDefaults(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig(ServerHTTP)) {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	if _, err := LoadConfig(writeConfigFile(t, "bad.yaml", "port: [")); err == nil {
+		t.Fatal("expected a parse error")
+	}
+
+	t.Setenv("SERVER_PORT", "not-a-port")
+	if _, err := LoadConfig(""); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("SERVER_PORT", "9443")
+	t.Setenv("SERVER_ENV", "prod")
+	t.Setenv("SERVER_VERSION", "2.1.0")
+	t.Setenv("SERVER_MAX_REQUEST_SIZE", "1048576")
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "45s")
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.10")
+
+	cfg, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := *DefaultConfig(ServerHTTP)
+	want.Port, want.Environment, want.Version = "9443", "prod", "2.1.0"
+	want.MaxRequestSize, want.ShutdownTimeout = 1<<20, 45*time.Second
+	want.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.10"}
+	if !reflect.DeepEqual(*cfg, want) {
+		t.Fatalf("expected %+v, got %+v", want, *cfg)
+	}
+
+	// The full name wins over the short alias
+	t.Setenv("SERVER_ENVIRONMENT", "staging")
+	if cfg, err = LoadConfigFromEnv(); err != nil || cfg.Environment != "staging" {
+		t.Fatalf("expected SERVER_ENVIRONMENT to win, got %+v, %v", cfg, err)
+	}
+
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "0s")
+	if _, err := LoadConfigFromEnv(); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+func TestLoadConfigFromFile(t *testing.T) {
+	t.Setenv("SERVER_PORT", "7070")
+	path := writeConfigFile(t, "server.json", `{"port": "9090", "environment": "staging", "max_request_size": 2048, "shutdown_timeout": "30s"}`)
+
+	cfg, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != "9090" || cfg.Environment != "staging" || cfg.MaxRequestSize != 2048 || cfg.ShutdownTimeout != 30*time.Second {
+		t.Fatalf("expected file values and no env overrides, got %+v", cfg)
+	}
+	if cfg.Version != "dev" || cfg.ServerType != ServerHTTP {
+		t.Fatalf("expected defaults for unset fields, got %+v", cfg)
+	}
+
+	if _, err := LoadConfigFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	if _, err := LoadConfigFromFile(writeConfigFile(t, "bad.yaml", `port: "0"`)); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"port out of range", func(c *Config) { c.Port = "70000" }},
+		{"unknown server type", func(c *Config) { c.ServerType = "udp" }},
+		{"zero request size", func(c *Config) { c.MaxRequestSize = 0 }},
+		{"zero shutdown timeout", func(c *Config) { c.ShutdownTimeout = 0 }},
+		{"invalid trusted proxy", func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/33"} }},
+	}
+	if err := DefaultConfig(ServerGRPC).Validate(); err != nil {
+		t.Fatalf("expected defaults to be valid, got %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(ServerHTTP)
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("expected a validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/api"
+	"github.com/gin-gonic/gin"
+)
+
+// Paths mounted by WithDrainEndpoints
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+	DrainPath   = "/admin/drain"
+)
+
+// drainState tracks whether the server is draining ahead of a shutdown
+type drainState struct {
+	draining atomic.Bool
+	once     sync.Once
+	done     chan struct{} // closed once the drain's shutdown has finished
+	err      error
+}
+
+func newDrainState() *drainState {
+	return &drainState{done: make(chan struct{})}
+}
+
+// drainEndpoints holds the WithDrainEndpoints settings
+type drainEndpoints struct {
+	grace  time.Duration
+	guards []gin.HandlerFunc
+}
+
+// WithDrainEndpoints mounts GET /healthz, GET /readyz and POST /admin/drain.
+// Posting to the drain endpoint calls BeginDrain with grace. guards run
+// before the drain handler, e.g. to authenticate the caller; anyone who can
+// reach the drain endpoint can take the server out of rotation, so at least
+// one guard is required and WithDrainEndpoints panics without one.
+func WithDrainEndpoints(grace time.Duration, guards ...gin.HandlerFunc) HTTPServerOption {
+	if len(guards) == 0 {
+		panic("server: WithDrainEndpoints requires at least one guard")
+	}
+	return func(s *HTTPServer) {
+		s.drainEndpoints = &drainEndpoints{grace: grace, guards: guards}
+	}
+}
+
+func (s *HTTPServer) mountDrainEndpoints() {
+	if s.drainEndpoints == nil {
+		return
+	}
+	s.router.GET(HealthzPath, s.HealthzHandler())
+	s.router.GET(ReadyzPath, s.ReadyzHandler())
+	handlers := append(append([]gin.HandlerFunc{}, s.drainEndpoints.guards...), s.DrainHandler(s.drainEndpoints.grace))
+	s.router.POST(DrainPath, handlers...)
+}
+
+// Draining reports whether BeginDrain has been called
+func (s *HTTPServer) Draining() bool {
+	return s.drain.draining.Load()
+}
+
+// BeginDrain marks the server as not ready, keeps serving requests for
+// grace so load balancers can take it out of rotation, then shuts it down
+// within the configured ShutdownTimeout. Start returns once that shutdown
+// completes. Calls after the first are ignored.
+func (s *HTTPServer) BeginDrain(grace time.Duration) {
+	s.drain.once.Do(func() {
+		s.drain.draining.Store(true)
+
+		ctx := context.Background()
+		s.logger.Info(ctx, "Draining server", api.Duration("grace", grace))
+
+		go func() {
+			time.Sleep(grace)
+			shutdownCtx, cancel := context.WithTimeout(ctx, s.config.ShutdownTimeout)
+			defer cancel()
+			s.drain.err = s.Shutdown(shutdownCtx)
+			close(s.drain.done)
+		}()
+	})
+}
+
+// HealthzHandler reports liveness. It answers 200 while the process is up,
+// including during a drain.
+func (s *HTTPServer) HealthzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// ReadyzHandler reports readiness. It answers 503 once the server is draining.
+func (s *HTTPServer) ReadyzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.Draining() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	}
+}
+
+// DrainHandler calls BeginDrain with grace and answers 202
+func (s *HTTPServer) DrainHandler(grace time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.BeginDrain(grace)
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":   "draining",
+			"grace_ms": grace.Milliseconds(),
+		})
+	}
+}
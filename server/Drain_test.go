@@ -0,0 +1,71 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/bignyap/go-utilities/server"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveDrainRequest(s *server.HTTPServer, method, path string) int {
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Code
+}
+
+// allowAll is a drain guard that lets every caller through
+func allowAll(c *gin.Context) { c.Next() }
+
+func TestDrain_ReadyzFailsWhileRequestsAreStillServed(t *testing.T) {
+	cfg := server.DefaultConfig(server.ServerHTTP)
+	cfg.Environment = "test"
+
+	shutdown := make(chan struct{})
+	s := server.NewHTTPServer(cfg,
+		server.WithLogger(mock.NewMockLogger()),
+		server.WithDrainEndpoints(100*time.Millisecond, allowAll),
+		server.WithShutdownFunc(func() { close(shutdown) }),
+	)
+	s.Router().GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	assert.Equal(t, http.StatusOK, serveDrainRequest(s, http.MethodGet, server.ReadyzPath))
+
+	assert.Equal(t, http.StatusAccepted, serveDrainRequest(s, http.MethodPost, server.DrainPath))
+	assert.True(t, s.Draining())
+
+	// During the grace window the server is not ready but still alive and serving
+	assert.Equal(t, http.StatusServiceUnavailable, serveDrainRequest(s, http.MethodGet, server.ReadyzPath))
+	assert.Equal(t, http.StatusOK, serveDrainRequest(s, http.MethodGet, server.HealthzPath))
+	assert.Equal(t, http.StatusOK, serveDrainRequest(s, http.MethodGet, "/ping"))
+
+	select {
+	case <-shutdown:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected shutdown to begin after the grace period")
+	}
+}
+
+func TestDrain_GuardsProtectTheDrainEndpoint(t *testing.T) {
+	cfg := server.DefaultConfig(server.ServerHTTP)
+	cfg.Environment = "test"
+
+	s := server.NewHTTPServer(cfg,
+		server.WithLogger(mock.NewMockLogger()),
+		server.WithDrainEndpoints(time.Hour, func(c *gin.Context) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}),
+	)
+
+	assert.Equal(t, http.StatusUnauthorized, serveDrainRequest(s, http.MethodPost, server.DrainPath))
+	assert.False(t, s.Draining())
+	assert.Equal(t, http.StatusOK, serveDrainRequest(s, http.MethodGet, server.ReadyzPath))
+}
+
+func TestDrain_RequiresAGuard(t *testing.T) {
+	assert.Panics(t, func() { server.WithDrainEndpoints(time.Second) })
+}
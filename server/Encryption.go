@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	cryptoapi "github.com/bignyap/go-utilities/crypto/api"
+	"github.com/gin-gonic/gin"
+)
+
+// EncryptedContentType marks a request or response body as an EncryptedEnvelope
+const EncryptedContentType = "application/vnd.encrypted+json"
+
+// encryptionServiceKey is the gin context key holding the EncryptionService
+const encryptionServiceKey = "encryption_service"
+
+// EncryptedEnvelope is the wire format for encrypted bodies.
+// Byte fields are base64 encoded in JSON.
+type EncryptedEnvelope struct {
+	Ciphertext  []byte            `json:"ciphertext"`
+	WrappedDEK  []byte            `json:"wrapped_dek"`
+	KeyID       string            `json:"key_id"`
+	Algorithm   string            `json:"algorithm"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+}
+
+// NewEncryptedEnvelope wraps encrypted data for transport
+func NewEncryptedEnvelope(data *cryptoapi.EncryptedData, contentType string) EncryptedEnvelope {
+	return EncryptedEnvelope{
+		Ciphertext:  data.Ciphertext,
+		WrappedDEK:  data.WrappedDEK,
+		KeyID:       data.KeyID,
+		Algorithm:   data.Algorithm,
+		Metadata:    data.AdditionalMetadata,
+		ContentType: contentType,
+	}
+}
+
+// EncryptedData converts the envelope back into crypto service input
+func (e EncryptedEnvelope) EncryptedData() *cryptoapi.EncryptedData {
+	return &cryptoapi.EncryptedData{
+		Ciphertext:         e.Ciphertext,
+		WrappedDEK:         e.WrappedDEK,
+		KeyID:              e.KeyID,
+		Algorithm:          e.Algorithm,
+		AdditionalMetadata: e.Metadata,
+	}
+}
+
+// EncryptionProvider stores the EncryptionService in the gin context for EncryptedBody
+func (m *Middleware) EncryptionProvider(svc cryptoapi.EncryptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(encryptionServiceKey, svc)
+		c.Next()
+	}
+}
+
+// EncryptedBody transparently decrypts request bodies and encrypts response
+// bodies for the given routes (gin route patterns such as "/secrets/:id").
+//
+// Requests sent with EncryptedContentType are decrypted before the handler
+// runs. The response is encrypted when the request was encrypted or the
+// client accepts EncryptedContentType; otherwise it passes through untouched.
+// The route pattern is used as associated data, binding a ciphertext to its route.
+func (m *Middleware) EncryptedBody(routes ...string) gin.HandlerFunc {
+	routeSet := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		routeSet[route] = struct{}{}
+	}
+	rw := NewResponseWriter(m.logger)
+
+	return func(c *gin.Context) {
+		if _, ok := routeSet[c.FullPath()]; !ok {
+			c.Next()
+			return
+		}
+
+		encryptedRequest := isEncryptedContentType(c.GetHeader("Content-Type"))
+		encryptResponse := encryptedRequest || strings.Contains(c.GetHeader("Accept"), EncryptedContentType)
+		if !encryptedRequest && !encryptResponse {
+			c.Next()
+			return
+		}
+
+		svc := getEncryptionServiceFromContext(c)
+		if svc == nil {
+			rw.InternalServerError(c, fmt.Errorf("encryption service not found in context"))
+			c.Abort()
+			return
+		}
+
+		aad := c.FullPath()
+		if encryptedRequest {
+			if err := decryptRequestBody(c, svc, aad); err != nil {
+				rw.Error(c, NewError(ErrorBadRequest, "Invalid encrypted body", err))
+				c.Abort()
+				return
+			}
+		}
+
+		if !encryptResponse {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buf := &bufferedResponseWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buf
+		c.Next()
+		c.Writer = original
+
+		if buf.body.Len() == 0 {
+			original.WriteHeader(buf.status)
+			original.WriteHeaderNow()
+			return
+		}
+
+		encrypted, err := svc.EncryptMessage(c.Request.Context(), buf.body.Bytes(), aad)
+		if err != nil {
+			rw.InternalServerError(c, fmt.Errorf("failed to encrypt response: %w", err))
+			return
+		}
+		envelope := NewEncryptedEnvelope(encrypted, buf.Header().Get("Content-Type"))
+		payload, err := json.Marshal(envelope)
+		if err != nil {
+			rw.InternalServerError(c, fmt.Errorf("failed to marshal encrypted response: %w", err))
+			return
+		}
+
+		original.Header().Set("Content-Type", EncryptedContentType)
+		original.WriteHeader(buf.status)
+		_, _ = original.Write(payload)
+	}
+}
+
+// decryptRequestBody replaces the request body with its decrypted plaintext
+func decryptRequestBody(c *gin.Context, svc cryptoapi.EncryptionService, aad string) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+
+	var envelope EncryptedEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("failed to parse envelope: %w", err)
+	}
+
+	plaintext, err := svc.DecryptMessage(c.Request.Context(), envelope.EncryptedData(), aad)
+	if err != nil {
+		return err
+	}
+
+	contentType := envelope.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(plaintext))
+	c.Request.ContentLength = int64(len(plaintext))
+	c.Request.Header.Set("Content-Type", contentType)
+	return nil
+}
+
+func isEncryptedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == EncryptedContentType
+}
+
+func getEncryptionServiceFromContext(c *gin.Context) cryptoapi.EncryptionService {
+	if val, exists := c.Get(encryptionServiceKey); exists {
+		if svc, ok := val.(cryptoapi.EncryptionService); ok {
+			return svc
+		}
+	}
+	return nil
+}
+
+// bufferedResponseWriter holds back the handler's response so it can be encrypted
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.written
+}
@@ -0,0 +1,125 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bignyap/go-utilities/crypto"
+	"github.com/bignyap/go-utilities/crypto/adapters/local"
+	"github.com/bignyap/go-utilities/crypto/config"
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/bignyap/go-utilities/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEncryptedRouter(t *testing.T) (*gin.Engine, *crypto.Service) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	kms, err := local.NewLocalKMSProvider(config.LocalConfig{KeyName: "test"})
+	require.NoError(t, err)
+	svc := crypto.NewService(kms)
+
+	m := server.NewMiddleware(&mock.Mock{}, &server.Config{})
+	r := gin.New()
+	r.Use(m.EncryptionProvider(svc))
+	r.Use(m.EncryptedBody("/secrets"))
+
+	r.POST("/secrets", func(c *gin.Context) {
+		var req map[string]string
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"echo": req["secret"]})
+	})
+	r.POST("/plain", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "text/plain", body)
+	})
+	return r, svc
+}
+
+func TestEncryptedBody_RoundTrip(t *testing.T) {
+	r, svc := newEncryptedRouter(t)
+	ctx := context.Background()
+
+	encrypted, err := svc.EncryptMessage(ctx, []byte(`{"secret":"s3cr3t"}`), "/secrets")
+	require.NoError(t, err)
+	body, err := json.Marshal(server.NewEncryptedEnvelope(encrypted, "application/json"))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost, "/secrets", bytes.NewReader(body))
+	req.Header.Set("Content-Type", server.EncryptedContentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, server.EncryptedContentType, w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), "s3cr3t")
+
+	var envelope server.EncryptedEnvelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	plaintext, err := svc.DecryptMessage(ctx, envelope.EncryptedData(), "/secrets")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"echo":"s3cr3t"}`, string(plaintext))
+	assert.Contains(t, envelope.ContentType, "application/json")
+}
+
+func TestEncryptedBody_PlaintextPassthrough(t *testing.T) {
+	r, _ := newEncryptedRouter(t)
+
+	// Configured route, but the client neither sends nor accepts encrypted bodies
+	req, _ := http.NewRequest(http.MethodPost, "/secrets", bytes.NewReader([]byte(`{"secret":"open"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"echo":"open"}`, w.Body.String())
+
+	// Route that is not configured is never touched
+	req, _ = http.NewRequest(http.MethodPost, "/plain", bytes.NewReader([]byte("raw")))
+	req.Header.Set("Content-Type", server.EncryptedContentType)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "raw", w.Body.String())
+}
+
+func TestEncryptedBody_InvalidEnvelope(t *testing.T) {
+	r, _ := newEncryptedRouter(t)
+
+	req, _ := http.NewRequest(http.MethodPost, "/secrets", bytes.NewReader([]byte(`not-an-envelope`)))
+	req.Header.Set("Content-Type", server.EncryptedContentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid encrypted body")
+}
+
+func TestEncryptedBody_WrongRouteAAD(t *testing.T) {
+	r, svc := newEncryptedRouter(t)
+
+	// Ciphertext bound to a different route must be rejected
+	encrypted, err := svc.EncryptMessage(context.Background(), []byte(`{"secret":"x"}`), "/other")
+	require.NoError(t, err)
+	body, _ := json.Marshal(server.NewEncryptedEnvelope(encrypted, "application/json"))
+
+	req, _ := http.NewRequest(http.MethodPost, "/secrets", bytes.NewReader(body))
+	req.Header.Set("Content-Type", server.EncryptedContentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,6 +10,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ErrorType represents categorized error types
@@ -19,8 +24,11 @@ const (
 	ErrorBadRequest   ErrorType = 400
 	ErrorUnauthorized ErrorType = 401
 	ErrorNotFound     ErrorType = 404
+	ErrorMethod       ErrorType = 405
 	ErrorConflict     ErrorType = 409
 	ErrorLargePayload ErrorType = 413
+	ErrorMediaType    ErrorType = 415
+	ErrorTimeout      ErrorType = 504
 )
 
 // PostgreSQL error codes
@@ -76,10 +84,16 @@ func (e *InternalError) ToHttpStatusCode() int {
 		return http.StatusUnauthorized
 	case ErrorNotFound:
 		return http.StatusNotFound
+	case ErrorMethod:
+		return http.StatusMethodNotAllowed
 	case ErrorConflict:
 		return http.StatusConflict
 	case ErrorLargePayload:
 		return http.StatusRequestEntityTooLarge
+	case ErrorMediaType:
+		return http.StatusUnsupportedMediaType
+	case ErrorTimeout:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
@@ -93,18 +107,26 @@ func (e *InternalError) ToHttpMessage() string {
 		return "Unauthorized"
 	case ErrorNotFound:
 		return "Not found"
+	case ErrorMethod:
+		return "Method not allowed"
 	case ErrorConflict:
 		return e.Message
 	case ErrorLargePayload:
 		return "Payload too large"
+	case ErrorMediaType:
+		return "Unsupported media type"
+	case ErrorTimeout:
+		return "Request timed out"
 	default:
 		return "Internal server error"
 	}
 }
 
-// ToApiError converts error to API-safe structure
+// ToApiError converts error to API-safe structure. The trace ID is the active
+// OpenTelemetry span's, so clients can look it up in the APM backend, falling
+// back to the request's X-Trace-ID.
 func ToApiError(c *gin.Context, err error) *ApiError {
-	traceID := getTraceIDFromContext(c)
+	traceID := errorTraceID(c)
 
 	switch e := err.(type) {
 	case *ApiError:
@@ -119,6 +141,16 @@ func ToApiError(c *gin.Context, err error) *ApiError {
 			TraceID: traceID,
 		}
 	default:
+		// A handler that returns its context's error after the deadline
+		// answers like the Timeout middleware would
+		if errors.Is(err, context.DeadlineExceeded) {
+			timeout := NewError(ErrorTimeout, "Request timed out", err)
+			return &ApiError{
+				Code:    timeout.ToHttpStatusCode(),
+				Message: timeout.ToHttpMessage(),
+				TraceID: traceID,
+			}
+		}
 		return &ApiError{
 			Code:    http.StatusInternalServerError,
 			Message: "Internal server error",
@@ -127,6 +159,84 @@ func ToApiError(c *gin.Context, err error) *ApiError {
 	}
 }
 
+// errorTraceID prefers the active span's trace ID over the request trace ID
+func errorTraceID(c *gin.Context) string {
+	if c.Request != nil {
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			return sc.TraceID().String()
+		}
+	}
+	return getTraceIDFromContext(c)
+}
+
+// ToGRPCCode maps an ErrorType to the equivalent gRPC status code
+func (e *InternalError) ToGRPCCode() codes.Code {
+	return grpcCodeFromHTTPStatus(e.ToHttpStatusCode())
+}
+
+// ToGRPCStatus converts error to a gRPC status error. The client-safe message
+// is used as the status message, and the error reason and trace ID are
+// attached as an ErrorInfo detail. Existing gRPC status errors pass through.
+func ToGRPCStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var (
+		code    codes.Code
+		message string
+		traceID string
+	)
+	switch e := err.(type) {
+	case *ApiError:
+		code = grpcCodeFromHTTPStatus(e.Code)
+		message = e.Message
+		traceID = e.TraceID
+	case *InternalError:
+		code = e.ToGRPCCode()
+		message = e.ToHttpMessage()
+	default:
+		code = codes.Internal
+		message = "Internal server error"
+	}
+
+	st := status.New(code, message)
+	info := &errdetails.ErrorInfo{Reason: code.String()}
+	if traceID != "" {
+		info.Metadata = map[string]string{"trace_id": traceID}
+	}
+	if withDetails, detailErr := st.WithDetails(info); detailErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func grpcCodeFromHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
 func captureCallerInfo(skip int) string {
 	_, file, line, ok := runtime.Caller(skip)
 	if !ok {
@@ -1,13 +1,20 @@
 package server_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/bignyap/go-utilities/server"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewError(t *testing.T) {
@@ -29,3 +36,91 @@ func TestToApiError_Default(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, apiErr.Code)
 	assert.Equal(t, "Internal server error", apiErr.Message)
 }
+
+func TestToApiError_DeadlineExceeded(t *testing.T) {
+	c, _ := gin.CreateTestContext(nil)
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+
+	apiErr := server.ToApiError(c, fmt.Errorf("query failed: %w", context.DeadlineExceeded))
+	assert.Equal(t, http.StatusGatewayTimeout, apiErr.Code)
+	assert.Equal(t, "Request timed out", apiErr.Message)
+}
+
+func TestToGRPCStatus_ErrorTypes(t *testing.T) {
+	tests := []struct {
+		errType server.ErrorType
+		code    codes.Code
+		message string
+	}{
+		{server.ErrorBadRequest, codes.InvalidArgument, "bad input"},
+		{server.ErrorUnauthorized, codes.Unauthenticated, "Unauthorized"},
+		{server.ErrorNotFound, codes.NotFound, "Not found"},
+		{server.ErrorConflict, codes.AlreadyExists, "bad input"},
+		{server.ErrorLargePayload, codes.ResourceExhausted, "Payload too large"},
+		{server.ErrorMediaType, codes.InvalidArgument, "Unsupported media type"},
+		{server.ErrorTimeout, codes.DeadlineExceeded, "Request timed out"},
+		{server.ErrorInternal, codes.Internal, "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			err := server.ToGRPCStatus(server.NewError(tt.errType, "bad input", errors.New("db: secret detail")))
+			st, ok := status.FromError(err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+}
+
+func TestToGRPCStatus_ApiErrorPreservesTraceID(t *testing.T) {
+	err := server.ToGRPCStatus(&server.ApiError{Code: http.StatusNotFound, Message: "user not found", TraceID: "trace-123"})
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "user not found", st.Message())
+
+	details := st.Details()
+	if assert.Len(t, details, 1) {
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		assert.True(t, ok)
+		assert.Equal(t, "trace-123", info.GetMetadata()["trace_id"])
+		assert.Equal(t, codes.NotFound.String(), info.GetReason())
+	}
+}
+
+func TestToGRPCStatus_Passthrough(t *testing.T) {
+	assert.Nil(t, server.ToGRPCStatus(nil))
+
+	original := status.Error(codes.Unavailable, "try later")
+	assert.Equal(t, original, server.ToGRPCStatus(original))
+
+	st, _ := status.FromError(server.ToGRPCStatus(errors.New("boom")))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "Internal server error", st.Message())
+}
+
+func TestToApiError_PrefersActiveSpanTraceID(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c.Request.Header.Set("X-Trace-ID", "uuid-trace-id")
+	c.Set("trace_id", "uuid-trace-id")
+
+	want := span.SpanContext().TraceID().String()
+	assert.Equal(t, want, server.ToApiError(c, errors.New("boom")).TraceID)
+	assert.Equal(t, want, server.ToApiError(c, server.NewError(server.ErrorNotFound, "missing", nil)).TraceID)
+}
+
+func TestToApiError_FallsBackToRequestTraceID(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("X-Trace-ID", "uuid-trace-id")
+
+	assert.Equal(t, "uuid-trace-id", server.ToApiError(c, errors.New("boom")).TraceID)
+}
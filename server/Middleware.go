@@ -1,24 +1,41 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bignyap/go-utilities/logger/api"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Middleware struct {
-	logger api.Logger
-	config *Config
+	logger       api.Logger
+	config       *Config
+	recoveryHook RecoveryHook
+	pre          []gin.HandlerFunc
+	post         []gin.HandlerFunc
 }
 
+// RecoveryHook can enrich the error body written after a recovered panic,
+// e.g. with an incident ID. resp.Details is nil until the hook sets it.
+type RecoveryHook func(c *gin.Context, resp *ErrorResponse)
+
 func NewMiddleware(logger api.Logger, config *Config) *Middleware {
 	return &Middleware{logger: logger, config: config}
 }
@@ -76,10 +93,7 @@ func (m *Middleware) Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		traceID := c.GetHeader("X-Trace-ID")
-		if traceID == "" {
-			traceID = uuid.New().String()
-		}
+		traceID := requestTraceID(c)
 
 		// Store trace_id in Go's context.Context for logger to extract
 		ctx := context.WithValue(c.Request.Context(), api.TraceIDKey, traceID)
@@ -91,7 +105,7 @@ func (m *Middleware) Logger() gin.HandlerFunc {
 		reqLogger := m.logger.WithTraceID(traceID).WithComponent("api").
 			AddField("method", c.Request.Method).
 			AddField("path", c.Request.URL.Path).
-			AddField("client_ip", c.ClientIP()).
+			AddField("client_ip", ClientIP(c)).
 			AddField("user_agent", c.Request.UserAgent()).
 			AddField("query", redactedQuery).
 			AddField("trace_id", traceID)
@@ -162,6 +176,261 @@ func (m *Middleware) MaxBodySize(limit int64) gin.HandlerFunc {
 	}
 }
 
+// Timeout gives each request a context deadline of d and answers 504 when it
+// passes, even if the handler ignores its context. The handlers after Timeout
+// write to a buffer that is sent when they finish in time and discarded
+// otherwise. The middleware still waits for a late handler to return, since
+// gin reuses the context afterwards, so handlers should honour the deadline
+// to free their goroutine. Streaming and websocket routes must not use it.
+func (m *Middleware) Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		logger := getLoggerFromContext(c)
+		if logger == nil {
+			logger = m.logger
+		}
+		route, traceID := routeOrPath(c), errorTraceID(c)
+
+		w := c.Writer
+		tw := newTimeoutWriter(w)
+		c.Writer = tw
+
+		done := make(chan struct{})
+		var panicked interface{}
+		go func() {
+			defer close(done)
+			defer tw.finish(ctx)
+			defer func() { panicked = recover() }()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		// The outcome is decided by whether the handler finished before the
+		// deadline, not by which channel the select saw first: a handler
+		// that honours ctx returns right as the deadline passes
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && tw.timeOut() {
+			logger.WithFields(
+				api.String("route", route),
+				api.String("trace_id", traceID),
+				api.Duration("timeout", d),
+			).Warn(ctx, "Request timed out")
+			writeTimeout(w, traceID)
+		}
+		<-done
+
+		c.Writer = w
+		if panicked != nil {
+			panic(panicked)
+		}
+		tw.flush()
+	}
+}
+
+// writeTimeout sends the 504 response straight to w while the handler may
+// still be running, so it must not touch the gin.Context
+func writeTimeout(w gin.ResponseWriter, traceID string) {
+	apiErr := NewError(ErrorTimeout, "Request timed out", context.DeadlineExceeded)
+	body, _ := json.Marshal(ErrorResponse{Error: apiErr.ToHttpMessage(), TraceID: traceID})
+
+	header := w.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(apiErr.ToHttpStatusCode())
+	_, _ = w.Write(body)
+	w.Flush()
+}
+
+// timeoutWriter buffers a response until the handler finishes. Once the
+// request has timed out its writes are discarded.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	written     bool
+	finished    bool // the handler returned before the deadline
+	timedOut    bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{ResponseWriter: w, header: w.Header().Clone(), status: w.Status()}
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.written || tw.timedOut {
+		return
+	}
+	tw.status = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) WriteHeaderNow() {
+	tw.mu.Lock()
+	tw.written = true
+	tw.mu.Unlock()
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.written = true
+	return tw.body.Write(b)
+}
+
+func (tw *timeoutWriter) WriteString(s string) (int, error) {
+	return tw.Write([]byte(s))
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.status
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.written {
+		return -1
+	}
+	return tw.body.Len()
+}
+
+func (tw *timeoutWriter) Written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.written
+}
+
+// Flush is a no-op: nothing reaches the client before the handler finishes
+func (tw *timeoutWriter) Flush() {}
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("server: Timeout does not support hijacking")
+}
+
+// finish records that the handler has returned, if it did so before ctx's
+// deadline. Only then is its response sent.
+func (tw *timeoutWriter) finish(ctx context.Context) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut && ctx.Err() == nil {
+		tw.finished = true
+	}
+}
+
+// timeOut discards the buffered response and any later writes. It returns
+// false if the handler already finished in time.
+func (tw *timeoutWriter) timeOut() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.finished {
+		return false
+	}
+	tw.timedOut = true
+	tw.body.Reset()
+	return true
+}
+
+// flush copies the buffered response to the underlying writer, unless the
+// request timed out
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+
+	dst := tw.ResponseWriter.Header()
+	clear(dst)
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if tw.wroteHeader {
+		tw.ResponseWriter.WriteHeader(tw.status)
+	}
+	if tw.written {
+		tw.ResponseWriter.WriteHeaderNow()
+	}
+	if tw.body.Len() > 0 {
+		_, _ = tw.ResponseWriter.Write(tw.body.Bytes())
+	}
+}
+
+// routeOrPath returns the matched route pattern, or the raw path when no route matched
+func routeOrPath(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return c.Request.URL.Path
+}
+
+// RequireJSON rejects POST, PUT and PATCH requests with a body whose
+// Content-Type is not JSON (application/json or a +json suffix type).
+// Requests to exemptPaths (matched against the route pattern or the raw path) are skipped.
+func (m *Middleware) RequireJSON(exemptPaths ...string) gin.HandlerFunc {
+	exempt := make(map[string]struct{}, len(exemptPaths))
+	for _, p := range exemptPaths {
+		exempt[p] = struct{}{}
+	}
+	rw := NewResponseWriter(m.logger)
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		// ContentLength is -1 when the size is unknown (e.g. chunked), which may still carry a body
+		if c.Request.ContentLength == 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if _, ok := exempt[c.FullPath()]; ok {
+			c.Next()
+			return
+		}
+		if _, ok := exempt[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		if !isJSONContentType(c.GetHeader("Content-Type")) {
+			rw.BadRequest(c, "Content-Type must be application/json")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
 func (m *Middleware) PrettyLog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if m.config.Environment != "prod" {
@@ -181,7 +450,16 @@ func (m *Middleware) Profiling() gin.HandlerFunc {
 	}
 }
 
+// SetRecoveryHook sets the hook Recovery uses to enrich its error body
+func (m *Middleware) SetRecoveryHook(hook RecoveryHook) {
+	m.recoveryHook = hook
+}
+
+// Recovery turns a panic into the standard internal server error response.
+// The panic value and stack are only logged, never sent to the client.
+// http.ErrAbortHandler is re-raised so the server aborts the response.
 func (m *Middleware) Recovery() gin.HandlerFunc {
+	rw := NewResponseWriter(m.logger)
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
@@ -189,8 +467,20 @@ func (m *Middleware) Recovery() gin.HandlerFunc {
 				if logger == nil {
 					logger = m.logger
 				}
-				logger.Error(c.Request.Context(), "Recovered panic", fmt.Errorf("%v", err))
-				c.AbortWithStatus(http.StatusInternalServerError)
+				// net/http uses ErrAbortHandler to drop the connection on purpose;
+				// it is not a crash, so skip the stack and let the server handle it
+				if err == http.ErrAbortHandler {
+					logger.Debug(c.Request.Context(), "Handler aborted the response")
+					c.Abort()
+					panic(err)
+				}
+				panicErr := fmt.Errorf("panic: %v", err)
+				logger.WithFields(
+					api.String("stack", string(debug.Stack())),
+				).Error(c.Request.Context(), "Recovered panic", panicErr)
+
+				rw.writeError(c, NewError(ErrorInternal, "Internal server error", panicErr), m.recoveryHook)
+				c.Abort()
 			}
 		}()
 		c.Next()
@@ -213,11 +503,30 @@ func (m *Middleware) ErrorHandler() gin.HandlerFunc {
 	}
 }
 
+// Before adds middlewares that Apply registers ahead of the built-in stack,
+// e.g. tracing that must wrap everything else
+func (m *Middleware) Before(handlers ...gin.HandlerFunc) *Middleware {
+	m.pre = append(m.pre, handlers...)
+	return m
+}
+
+// After adds middlewares that Apply registers after the built-in stack and
+// just before the route handlers, e.g. authentication
+func (m *Middleware) After(handlers ...gin.HandlerFunc) *Middleware {
+	m.post = append(m.post, handlers...)
+	return m
+}
+
 func (m *Middleware) Apply(router *gin.Engine) {
 
 	fmt.Println("**************************************")
 	fmt.Println("Registering Middlewares:")
 
+	if len(m.pre) > 0 {
+		fmt.Printf("\tCustom (before): %d\n", len(m.pre))
+		router.Use(m.pre...)
+	}
+
 	if m.config.Environment != "prod" {
 		fmt.Println("\tPrettyLog")
 		router.Use(m.PrettyLog())
@@ -242,9 +551,26 @@ func (m *Middleware) Apply(router *gin.Engine) {
 		router.Use(m.Profiling())
 	}
 
+	if len(m.post) > 0 {
+		fmt.Printf("\tCustom (after): %d\n", len(m.post))
+		router.Use(m.post...)
+	}
+
 	fmt.Println("**************************************")
 }
 
+// requestTraceID prefers the active span's trace ID so logs and traces can be
+// correlated, then an incoming X-Trace-ID header, then a fresh UUID
+func requestTraceID(c *gin.Context) string {
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	if traceID := c.GetHeader("X-Trace-ID"); traceID != "" {
+		return traceID
+	}
+	return uuid.New().String()
+}
+
 func getLoggerFromContext(c *gin.Context) api.Logger {
 	if logger, exists := c.Get("logger"); exists {
 		if l, ok := logger.(api.Logger); ok {
@@ -1,7 +1,19 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func TestRedactSensitiveQueryParams(t *testing.T) {
@@ -57,3 +69,355 @@ func TestRedactSensitiveQueryParams(t *testing.T) {
 	}
 }
 
+func newRequireJSONRouter(exempt ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := NewMiddleware(&mock.Mock{}, &Config{})
+	r := gin.New()
+	r.Use(m.RequireJSON(exempt...))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/items", ok)
+	r.PUT("/items/:id", ok)
+	r.GET("/items", ok)
+	r.POST("/upload", ok)
+	return r
+}
+
+func TestRequireJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		contentType string
+		wantStatus  int
+	}{
+		{"json body", http.MethodPost, "/items", `{"a":1}`, "application/json", http.StatusOK},
+		{"json with charset", http.MethodPut, "/items/1", `{"a":1}`, "application/json; charset=utf-8", http.StatusOK},
+		{"json suffix type", http.MethodPost, "/items", `{"a":1}`, "application/merge-patch+json", http.StatusOK},
+		{"form body", http.MethodPost, "/items", "a=1", "application/x-www-form-urlencoded", http.StatusBadRequest},
+		{"text body", http.MethodPut, "/items/1", "hello", "text/plain", http.StatusBadRequest},
+		{"missing content type", http.MethodPost, "/items", `{"a":1}`, "", http.StatusBadRequest},
+		{"empty body", http.MethodPost, "/items", "", "", http.StatusOK},
+		{"non-mutating request", http.MethodGet, "/items", "", "text/plain", http.StatusOK},
+		{"exempt path", http.MethodPost, "/upload", "raw", "application/octet-stream", http.StatusOK},
+	}
+
+	r := newRequireJSONRouter("/upload")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(w.Body.String(), `"error":"Content-Type must be application/json"`) {
+				t.Fatalf("expected standard JSON error body, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func newRecoveryRouter(hook RecoveryHook) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := NewMiddleware(mock.NewMockLogger(), &Config{})
+	m.SetRecoveryHook(hook)
+	r := gin.New()
+	r.Use(m.Recovery())
+	r.GET("/panic", func(c *gin.Context) {
+		panic("db password is hunter2")
+	})
+	return r
+}
+
+func TestRecovery_WritesStandardErrorBody(t *testing.T) {
+	r := newRecoveryRouter(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("X-Trace-ID", "trace-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a JSON body, got %q: %v", w.Body.String(), err)
+	}
+	if body["error"] != "Internal server error" || body["trace_id"] != "trace-123" {
+		t.Fatalf("unexpected body %v", body)
+	}
+	if _, ok := body["details"]; ok {
+		t.Fatalf("expected no details without a hook, got %v", body)
+	}
+	for _, leaked := range []string{"hunter2", "goroutine", ".go:"} {
+		if strings.Contains(w.Body.String(), leaked) {
+			t.Fatalf("response leaks %q: %s", leaked, w.Body.String())
+		}
+	}
+}
+
+func TestRecovery_HookEnrichesBody(t *testing.T) {
+	r := newRecoveryRouter(func(c *gin.Context, resp *ErrorResponse) {
+		resp.Details = map[string]interface{}{"incident_id": "INC-42"}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error != "Internal server error" || body.Details["incident_id"] != "INC-42" {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Fatalf("response leaks the panic value: %s", w.Body.String())
+	}
+}
+
+func TestApply_CustomMiddlewareOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var order []string
+	record := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			_, hasTrace := c.Get("trace_id")
+			order = append(order, name+":"+map[bool]string{true: "traced", false: "untraced"}[hasTrace])
+			c.Next()
+		}
+	}
+
+	m := NewMiddleware(mock.NewMockLogger(), &Config{Environment: "prod", MaxRequestSize: 1 << 20})
+	m.Before(record("tracing")).After(record("auth"), record("tenant"))
+	r := gin.New()
+	m.Apply(r)
+	r.GET("/items", func(c *gin.Context) {
+		order = append(order, "handler")
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	// The built-in Logger sets trace_id, so "before" runs ahead of it and "after" behind it
+	want := "tracing:untraced,auth:traced,tenant:traced,handler"
+	if got := strings.Join(order, ","); got != want {
+		t.Fatalf("middleware order = %q, want %q", got, want)
+	}
+}
+
+func TestApply_AfterMiddlewarePanicIsRecovered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewMiddleware(mock.NewMockLogger(), &Config{Environment: "prod", MaxRequestSize: 1 << 20})
+	m.After(func(c *gin.Context) { panic("auth exploded") })
+	r := gin.New()
+	m.Apply(r)
+	r.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected Recovery to turn the panic into a 500, got %d", w.Code)
+	}
+}
+
+func TestLogger_UsesActiveSpanTraceID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var spanTraceID string
+	logger := mock.NewMockLogger()
+	m := NewMiddleware(logger, &Config{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx, span := tp.Tracer("test").Start(c.Request.Context(), "request")
+		defer span.End()
+		spanTraceID = span.SpanContext().TraceID().String()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	r.Use(m.Logger())
+	r.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("X-Trace-ID", "client-supplied")
+	r.ServeHTTP(w, req)
+
+	if logged, _ := logger.FieldValue(mock.LevelInfo, "trace_id"); logged != spanTraceID {
+		t.Fatalf("logged trace_id = %v, want span trace ID %s", logged, spanTraceID)
+	}
+	if got := w.Header().Get("X-Trace-ID"); got != spanTraceID {
+		t.Fatalf("X-Trace-ID = %q, want %s", got, spanTraceID)
+	}
+}
+
+func TestLogger_FallsBackWithoutSpan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := mock.NewMockLogger()
+	m := NewMiddleware(logger, &Config{})
+	r := gin.New()
+	r.Use(m.Logger())
+	r.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	traceID := w.Header().Get("X-Trace-ID")
+	if _, err := uuid.Parse(traceID); err != nil {
+		t.Fatalf("expected a UUID trace ID without a span, got %q", traceID)
+	}
+	if logged, _ := logger.FieldValue(mock.LevelInfo, "trace_id"); logged != traceID {
+		t.Fatalf("logged trace_id = %v, want %s", logged, traceID)
+	}
+}
+
+func newTimeoutRouter(d time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := NewMiddleware(mock.NewMockLogger(), &Config{})
+	r := gin.New()
+	r.Use(m.Timeout(d))
+	r.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+			c.Status(http.StatusOK)
+		}
+	})
+	r.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return r
+}
+
+func TestTimeout_SlowHandlerGets504(t *testing.T) {
+	r := newTimeoutRouter(20 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the handler to be cancelled at the deadline, took %v", elapsed)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] != "Request timed out" {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+}
+
+func TestTimeout_HandlerIgnoringContextGets504(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewMiddleware(mock.NewMockLogger(), &Config{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(m.Timeout(20 * time.Millisecond))
+	r.GET("/stuck", func(c *gin.Context) {
+		<-release
+		c.JSON(http.StatusOK, gin.H{"status": "late"})
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/stuck")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d %s", resp.StatusCode, body)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the 504 at the deadline, took %v", elapsed)
+	}
+	if !strings.Contains(string(body), "Request timed out") {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
+
+func TestTimeout_FastHandlerPassesThrough(t *testing.T) {
+	r := newTimeoutRouter(time.Second)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"ok"}` {
+		t.Fatalf("expected the handler's response untouched, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRecovery_LogsStack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := mock.NewMockLogger()
+	m := NewMiddleware(logger, &Config{})
+	r := gin.New()
+	r.Use(m.Logger(), m.Recovery())
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("X-Trace-ID", "trace-panic")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("expected a JSON 500, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got := w.Header().Get("X-Trace-ID"); got != "trace-panic" {
+		t.Fatalf("expected the X-Trace-ID header to be kept, got %q", got)
+	}
+	var body struct {
+		TraceID string `json:"trace_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.TraceID != "trace-panic" {
+		t.Fatalf("expected trace_id in the body, got %s (%v)", w.Body.String(), err)
+	}
+	if !logger.ContainsMessage(mock.LevelError, "Recovered panic") {
+		t.Fatal("expected the panic to be logged")
+	}
+	stack, _ := logger.FieldValue(mock.LevelError, "stack")
+	if s, _ := stack.(string); !strings.Contains(s, "goroutine") {
+		t.Fatalf("expected a stack field, got %q", stack)
+	}
+}
+
+func TestRecovery_ErrAbortHandlerIsReraised(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := mock.NewMockLogger()
+	m := NewMiddleware(logger, &Config{})
+	r := gin.New()
+	r.Use(m.Recovery())
+	r.GET("/abort", func(c *gin.Context) { panic(http.ErrAbortHandler) })
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Fatalf("expected ErrAbortHandler to be re-raised, got %v", err)
+		}
+		if _, ok := logger.FieldValue(mock.LevelError, "stack"); ok || logger.ContainsMessage(mock.LevelError, "Recovered panic") {
+			t.Fatal("expected no stack to be logged for ErrAbortHandler")
+		}
+	}()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageLimit = 20
+	DefaultMaxLimit  = 100
+
+	// maxOffset bounds Pagination.Offset so it fits the int32 offsets used
+	// by the database package
+	maxOffset = math.MaxInt32
+)
+
+// SortDirection is the order of a sorted list
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// Pagination is the page and sort order requested by a list endpoint
+type Pagination struct {
+	Page      int
+	Limit     int
+	Offset    int
+	SortField string
+	SortDir   SortDirection
+}
+
+// PaginationOption configures BindPagination
+type PaginationOption func(*paginationConfig)
+
+type paginationConfig struct {
+	defaultLimit int
+	maxLimit     int
+	sortFields   map[string]bool
+	defaultSort  string
+	defaultDir   SortDirection
+}
+
+// WithDefaultLimit sets the limit used when the request has none (default 20)
+func WithDefaultLimit(n int) PaginationOption {
+	return func(c *paginationConfig) {
+		c.defaultLimit = n
+	}
+}
+
+// WithMaxLimit caps the requested limit (default 100)
+func WithMaxLimit(n int) PaginationOption {
+	return func(c *paginationConfig) {
+		c.maxLimit = n
+	}
+}
+
+// WithSortFields sets the fields a request may sort by. Without it any sort
+// parameter is rejected.
+func WithSortFields(fields ...string) PaginationOption {
+	return func(c *paginationConfig) {
+		for _, f := range fields {
+			c.sortFields[f] = true
+		}
+	}
+}
+
+// WithDefaultSort sets the sort order used when the request has none
+func WithDefaultSort(field string, dir SortDirection) PaginationOption {
+	return func(c *paginationConfig) {
+		c.defaultSort = field
+		c.defaultDir = dir
+	}
+}
+
+// BindPagination reads ?page=&limit=&sort= from the request. A limit above
+// the max is capped. sort names an allowed field, prefixed with "-" for
+// descending order, e.g. sort=-created_at. Invalid values, including pages
+// whose offset would pass math.MaxInt32, return a bad request error suitable
+// for ResponseWriter.Error.
+func BindPagination(c *gin.Context, opts ...PaginationOption) (Pagination, error) {
+	cfg := paginationConfig{
+		defaultLimit: DefaultPageLimit,
+		maxLimit:     DefaultMaxLimit,
+		sortFields:   map[string]bool{},
+		defaultDir:   SortAsc,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	p := Pagination{
+		Page:      1,
+		Limit:     cfg.defaultLimit,
+		SortField: cfg.defaultSort,
+		SortDir:   cfg.defaultDir,
+	}
+
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return Pagination{}, NewError(ErrorBadRequest, "page must be a positive integer", err)
+		}
+		p.Page = page
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return Pagination{}, NewError(ErrorBadRequest, "limit must be a positive integer", err)
+		}
+		p.Limit = limit
+	}
+	if cfg.maxLimit > 0 && p.Limit > cfg.maxLimit {
+		p.Limit = cfg.maxLimit
+	}
+
+	if v := c.Query("sort"); v != "" {
+		field, dir := v, SortAsc
+		if strings.HasPrefix(v, "-") {
+			field, dir = v[1:], SortDesc
+		}
+		if !cfg.sortFields[field] {
+			return Pagination{}, NewError(ErrorBadRequest, fmt.Sprintf("cannot sort by %q", field), nil)
+		}
+		p.SortField, p.SortDir = field, dir
+	}
+
+	if p.Limit > 0 && p.Page-1 > maxOffset/p.Limit {
+		return Pagination{}, NewError(ErrorBadRequest, "page is too large", nil)
+	}
+	p.Offset = (p.Page - 1) * p.Limit
+	return p, nil
+}
+
+// PaginatedResponse is the envelope written by ResponseWriter.Paginated
+type PaginatedResponse struct {
+	Data  interface{} `json:"data"`
+	Total int64       `json:"total"`
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
+}
+
+// Paginated writes one page of a list along with the total count
+func (rw *ResponseWriter) Paginated(c *gin.Context, data interface{}, total int64, p Pagination) {
+	rw.Negotiate(c, PaginatedResponse{
+		Data:  data,
+		Total: total,
+		Page:  p.Page,
+		Limit: p.Limit,
+	})
+}
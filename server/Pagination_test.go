@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/bignyap/go-utilities/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func bindPagination(t *testing.T, query string, opts ...server.PaginationOption) (server.Pagination, error) {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+	return server.BindPagination(c, opts...)
+}
+
+func TestBindPagination_Defaults(t *testing.T) {
+	p, err := bindPagination(t, "")
+	assert.NoError(t, err)
+	assert.Equal(t, server.Pagination{Page: 1, Limit: server.DefaultPageLimit, Offset: 0, SortDir: server.SortAsc}, p)
+
+	p, err = bindPagination(t, "", server.WithDefaultLimit(50), server.WithDefaultSort("created_at", server.SortDesc))
+	assert.NoError(t, err)
+	assert.Equal(t, 50, p.Limit)
+	assert.Equal(t, "created_at", p.SortField)
+	assert.Equal(t, server.SortDesc, p.SortDir)
+}
+
+func TestBindPagination_PageAndOffset(t *testing.T) {
+	p, err := bindPagination(t, "page=3&limit=25")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, p.Page)
+	assert.Equal(t, 25, p.Limit)
+	assert.Equal(t, 50, p.Offset)
+}
+
+func TestBindPagination_CapsLimit(t *testing.T) {
+	p, err := bindPagination(t, "limit=1000")
+	assert.NoError(t, err)
+	assert.Equal(t, server.DefaultMaxLimit, p.Limit)
+
+	p, err = bindPagination(t, "page=2&limit=1000", server.WithMaxLimit(10))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, p.Limit)
+	assert.Equal(t, 10, p.Offset)
+}
+
+func TestBindPagination_Sort(t *testing.T) {
+	allowed := server.WithSortFields("name", "created_at")
+
+	p, err := bindPagination(t, "sort=name", allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, "name", p.SortField)
+	assert.Equal(t, server.SortAsc, p.SortDir)
+
+	p, err = bindPagination(t, "sort=-created_at", allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, "created_at", p.SortField)
+	assert.Equal(t, server.SortDesc, p.SortDir)
+}
+
+func TestBindPagination_RejectsInvalidInput(t *testing.T) {
+	allowed := server.WithSortFields("name")
+	for _, query := range []string{"sort=password", "sort=-password", "page=0", "page=abc", "limit=-5", "limit=ten", "page=9223372036854775807"} {
+		_, err := bindPagination(t, query, allowed)
+		var internalErr *server.InternalError
+		if assert.Error(t, err, query) && assert.True(t, errors.As(err, &internalErr), query) {
+			assert.Equal(t, http.StatusBadRequest, internalErr.ToHttpStatusCode(), query)
+		}
+	}
+}
+
+func TestResponseWriter_Paginated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	rw := server.NewResponseWriter(&mock.Mock{})
+
+	r.GET("/items", func(c *gin.Context) {
+		p, err := server.BindPagination(c)
+		if err != nil {
+			rw.Error(c, err)
+			return
+		}
+		rw.Paginated(c, []string{"a", "b"}, 42, p)
+	})
+
+	req, _ := http.NewRequest("GET", "/items?page=2&limit=2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]interface{}{
+		"data":  []interface{}{"a", "b"},
+		"total": float64(42),
+		"page":  float64(2),
+		"limit": float64(2),
+	}, body)
+}
+
+func TestBindPagination_LargestPage(t *testing.T) {
+	// The offset must fit in an int32
+	p, err := bindPagination(t, "page=21474837&limit=100")
+	assert.NoError(t, err)
+	assert.Equal(t, 2147483600, p.Offset)
+
+	_, err = bindPagination(t, "page=21474838&limit=100")
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Proto writes msg as protobuf when the client Accepts application/x-protobuf,
+// and as JSON via protojson otherwise
+func (rw *ResponseWriter) Proto(c *gin.Context, msg proto.Message) {
+	c.Header("Vary", "Accept")
+
+	if acceptsProto(c) {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			rw.InternalServerError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, binding.MIMEPROTOBUF, data)
+		return
+	}
+
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		rw.InternalServerError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, binding.MIMEJSON+"; charset=utf-8", data)
+}
+
+// ProtoError writes err for a client negotiated like Proto. Protobuf clients
+// get a google.rpc.Status, as built by ToGRPCStatus, with the HTTP status of
+// the error; others get the usual JSON ErrorResponse.
+func (rw *ResponseWriter) ProtoError(c *gin.Context, err error) {
+	c.Header("Vary", "Accept")
+
+	if !acceptsProto(c) {
+		rw.Error(c, err)
+		return
+	}
+
+	apiErr, ok := rw.reportError(c, err)
+	if !ok {
+		return
+	}
+	data, marshalErr := proto.Marshal(status.Convert(ToGRPCStatus(apiErr)).Proto())
+	if marshalErr != nil {
+		c.AbortWithStatus(apiErr.Code)
+		return
+	}
+	c.Data(apiErr.Code, binding.MIMEPROTOBUF, data)
+}
+
+// acceptsProto reports whether the client prefers protobuf. JSON is offered
+// first, so clients without an Accept header or with */* get JSON.
+func acceptsProto(c *gin.Context) bool {
+	return c.NegotiateFormat(binding.MIMEJSON, binding.MIMEPROTOBUF) == binding.MIMEPROTOBUF
+}
@@ -0,0 +1,99 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/bignyap/go-utilities/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newProtoRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rw := server.NewResponseWriter(mock.NewMockLogger())
+
+	r.GET("/ts", func(c *gin.Context) {
+		rw.Proto(c, timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		rw.ProtoError(c, server.NewError(server.ErrorNotFound, "widget not found", nil))
+	})
+	return r
+}
+
+func TestResponseWriter_Proto_EncodesProtobufWhenAccepted(t *testing.T) {
+	r := newProtoRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/ts", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	var got timestamppb.Timestamp
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, int64(1704164645), got.GetSeconds())
+}
+
+func TestResponseWriter_Proto_FallsBackToJSON(t *testing.T) {
+	r := newProtoRouter()
+
+	for _, accept := range []string{"", "*/*", "application/json"} {
+		req := httptest.NewRequest(http.MethodGet, "/ts", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Accept %q", accept)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", "Accept %q", accept)
+		assert.JSONEq(t, `"2024-01-02T03:04:05Z"`, w.Body.String(), "Accept %q", accept)
+	}
+}
+
+func TestResponseWriter_ProtoError(t *testing.T) {
+	r := newProtoRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	req.Header.Set("X-Trace-ID", "trace-9")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+
+	var st spb.Status
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &st))
+	assert.Equal(t, int32(codes.NotFound), st.GetCode())
+	assert.Equal(t, "Not found", st.GetMessage())
+	require.Len(t, st.GetDetails(), 1)
+	var info errdetails.ErrorInfo
+	require.NoError(t, st.GetDetails()[0].UnmarshalTo(&info))
+	assert.Equal(t, "trace-9", info.GetMetadata()["trace_id"])
+
+	// JSON clients get the standard error body
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("X-Trace-ID", "trace-9")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"Not found","trace_id":"trace-9"}`, w.Body.String())
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/bignyap/go-utilities/logger/api"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
 )
 
 type ResponseWriter struct {
@@ -16,20 +18,57 @@ func NewResponseWriter(logger api.Logger) *ResponseWriter {
 }
 
 func (rw *ResponseWriter) Success(c *gin.Context, data interface{}) {
-	// c.JSON(http.StatusOK, Response{Data: data})
-	c.JSON(http.StatusOK, data)
+	rw.Negotiate(c, data)
 }
 
 func (rw *ResponseWriter) Created(c *gin.Context, data interface{}) {
-	// c.JSON(http.StatusCreated, Response{Data: data})
-	c.JSON(http.StatusCreated, data)
+	rw.negotiate(c, http.StatusCreated, data)
+}
+
+// Negotiate writes data as MessagePack when the Accept header asks for
+// application/msgpack (or application/x-msgpack), and as JSON otherwise
+func (rw *ResponseWriter) Negotiate(c *gin.Context, data interface{}) {
+	rw.negotiate(c, http.StatusOK, data)
+}
+
+func (rw *ResponseWriter) negotiate(c *gin.Context, code int, data interface{}) {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK) {
+	case binding.MIMEMSGPACK2, binding.MIMEMSGPACK:
+		c.Render(code, render.MsgPack{Data: data})
+	default:
+		c.JSON(code, data)
+	}
 }
 
 func (rw *ResponseWriter) NoContent(c *gin.Context) {
 	c.AbortWithStatus(http.StatusNoContent)
 }
 
+// Error writes err as a JSON error response. If the handler has already
+// written part of the response, the error is only logged and recorded on the
+// gin context, since writing a body now would corrupt the output.
 func (rw *ResponseWriter) Error(c *gin.Context, err error) {
+	rw.writeError(c, err, nil)
+}
+
+// writeError is Error with an optional hook that can add to the body before
+// it is written.
+func (rw *ResponseWriter) writeError(c *gin.Context, err error, enrich func(*gin.Context, *ErrorResponse)) {
+	apiErr, ok := rw.reportError(c, err)
+	if !ok {
+		return
+	}
+
+	resp := ErrorResponse{Error: apiErr.Message, TraceID: apiErr.TraceID}
+	if enrich != nil {
+		enrich(c, &resp)
+	}
+	c.JSON(apiErr.Code, resp)
+}
+
+// reportError logs err and converts it to an ApiError. It returns false if
+// the response was already written, in which case no body may follow.
+func (rw *ResponseWriter) reportError(c *gin.Context, err error) (*ApiError, bool) {
 	apiErr := ToApiError(c, err)
 
 	logger := getLoggerFromContext(c)
@@ -37,13 +76,25 @@ func (rw *ResponseWriter) Error(c *gin.Context, err error) {
 		logger = rw.logger
 	}
 
+	if c.Writer.Written() {
+		logger.WithFields(
+			api.Int("code", apiErr.Code),
+			api.Int("written_status", c.Writer.Status()),
+			api.String("message", apiErr.Message),
+			api.String("trace_id", apiErr.TraceID),
+		).Error(c.Request.Context(), "API error after response was written", err)
+		_ = c.Error(err)
+		c.Abort()
+		return nil, false
+	}
+
 	logger.WithFields(
 		api.Int("code", apiErr.Code),
 		api.String("message", apiErr.Message),
 		api.String("trace_id", apiErr.TraceID),
 	).Error(c.Request.Context(), "API error response", err)
 
-	c.JSON(apiErr.Code, ErrorResponse{Error: apiErr.Message})
+	return apiErr, true
 }
 
 // Shorthand helpers
@@ -59,6 +110,10 @@ func (rw *ResponseWriter) NotFound(c *gin.Context) {
 	rw.Error(c, NewError(ErrorNotFound, "Not found", nil))
 }
 
+func (rw *ResponseWriter) MethodNotAllowed(c *gin.Context) {
+	rw.Error(c, NewError(ErrorMethod, "Method not allowed", nil))
+}
+
 func (rw *ResponseWriter) InternalServerError(c *gin.Context, err error) {
 	rw.Error(c, NewError(ErrorInternal, "Internal server error", err))
 }
@@ -69,5 +124,7 @@ type Response struct {
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error   string                 `json:"error"`
+	TraceID string                 `json:"trace_id,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/bignyap/go-utilities/server"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,3 +49,99 @@ func TestResponseWriter_InternalServerError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), `"error":"Internal server error"`)
 }
+
+func TestResponseWriter_ErrorAfterPartialWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	rw := server.NewResponseWriter(&mock.Mock{})
+	var ginErrors []*gin.Error
+
+	r.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, `{"items":[`)
+		rw.InternalServerError(c, errors.New("stream broke"))
+		ginErrors = c.Errors
+	})
+
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"items":[`, w.Body.String())
+	assert.Len(t, ginErrors, 1)
+}
+
+func TestResponseWriter_ErrorAfterStatusOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	rw := server.NewResponseWriter(&mock.Mock{})
+
+	// Setting a status without writing leaves the response open for the error body
+	r.GET("/status", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		rw.BadRequest(c, "invalid input")
+	})
+
+	req, _ := http.NewRequest("GET", "/status", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"invalid input"}`, w.Body.String())
+}
+
+type negotiatedItem struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+func TestResponseWriter_NegotiatesMsgPack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	rw := server.NewResponseWriter(&mock.Mock{})
+	want := negotiatedItem{ID: 7, Name: "widget", Tags: []string{"a", "b"}}
+
+	r.GET("/item", func(c *gin.Context) {
+		rw.Success(c, want)
+	})
+
+	for _, accept := range []string{"application/msgpack", "application/x-msgpack"} {
+		req, _ := http.NewRequest("GET", "/item", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/msgpack")
+
+		var got negotiatedItem
+		assert.NoError(t, binding.MsgPack.BindBody(w.Body.Bytes(), &got))
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestResponseWriter_NegotiateDefaultsToJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	rw := server.NewResponseWriter(&mock.Mock{})
+	r.GET("/item", func(c *gin.Context) {
+		rw.Negotiate(c, negotiatedItem{ID: 7, Name: "widget"})
+	})
+
+	for _, accept := range []string{"", "*/*", "application/json", "text/html"} {
+		req, _ := http.NewRequest("GET", "/item", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", accept)
+		assert.Contains(t, w.Body.String(), `"name":"widget"`, accept)
+	}
+}
@@ -21,6 +21,9 @@ type HTTPServer struct {
 	respWriter *ResponseWriter
 	handlers   []Handler
 	shutdownFn []func()
+
+	drain          *drainState
+	drainEndpoints *drainEndpoints
 }
 
 type HTTPServerOption func(*HTTPServer)
@@ -74,6 +77,7 @@ func NewHTTPServer(cfg *Config, opts ...HTTPServerOption) *HTTPServer {
 		router:     gin.New(),
 		handlers:   []Handler{},
 		shutdownFn: []func(){},
+		drain:      newDrainState(),
 	}
 
 	// s.router.RedirectTrailingSlash = false
@@ -84,7 +88,15 @@ func NewHTTPServer(cfg *Config, opts ...HTTPServerOption) *HTTPServer {
 	}
 
 	s.ensureDefaults()
+	if err := applyTrustedProxies(s.router, cfg); err != nil {
+		s.logger.Error(context.Background(), "Invalid trusted proxies, trusting none", err)
+		_ = s.router.SetTrustedProxies(nil)
+	}
 	s.middleware.Apply(s.router)
+	s.mountDrainEndpoints()
+	if !cfg.DisableNoRouteHandlers {
+		s.registerNoRouteHandlers()
+	}
 
 	s.httpServer = &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -109,11 +121,33 @@ func (s *HTTPServer) ensureDefaults() {
 	}
 }
 
+// registerNoRouteHandlers answers unmatched paths with 404 and unsupported
+// methods with 405, in the same JSON error format as other errors
+func (s *HTTPServer) registerNoRouteHandlers() {
+	s.router.HandleMethodNotAllowed = true
+	s.router.NoRoute(func(c *gin.Context) {
+		s.respWriter.NotFound(c)
+	})
+	s.router.NoMethod(func(c *gin.Context) {
+		s.respWriter.MethodNotAllowed(c)
+	})
+}
+
 func (s *HTTPServer) Router() *gin.Engine {
 	return s.router
 }
 
+// Start serves until SIGINT or SIGTERM, then shuts down gracefully
 func (s *HTTPServer) Start() error {
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	return s.waitForShutdown()
+}
+
+// Listen sets up the handlers and starts serving in the background without
+// waiting for a signal. Stop the server with Shutdown.
+func (s *HTTPServer) Listen() error {
 	ctx := context.Background()
 	for _, h := range s.handlers {
 		if err := h.Setup(s); err != nil {
@@ -128,19 +162,40 @@ func (s *HTTPServer) Start() error {
 		api.String("version", s.config.Version),
 	).Info(ctx, "Starting server")
 
+	tlsConfig, err := s.config.TLSConfig()
+	if err != nil {
+		s.logger.Error(ctx, "TLS setup failed", err)
+		return err
+	}
+	s.httpServer.TLSConfig = tlsConfig
+
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			// The certificate comes from TLSConfig
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.logger.Error(ctx, "HTTP server failed", err)
 		}
 	}()
 
-	return s.waitForShutdown()
+	return nil
 }
 
 func (s *HTTPServer) waitForShutdown() error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	defer signal.Stop(quit)
+
+	select {
+	case <-quit:
+	case <-s.drain.done:
+		// BeginDrain has already shut the server down
+		return s.drain.err
+	}
 
 	ctx := context.Background()
 	s.logger.Info(ctx, "Shutdown signal received")
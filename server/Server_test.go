@@ -1,10 +1,13 @@
 package server_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/bignyap/go-utilities/server"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,3 +27,96 @@ func TestPingRoute(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "pong")
 }
+
+func newNoRouteTestServer(cfg *server.Config) *server.HTTPServer {
+	cfg.Environment = "test"
+	s := server.NewHTTPServer(cfg, server.WithLogger(mock.NewMockLogger()))
+	s.Router().GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return s
+}
+
+func TestNoRouteHandlers_JSONErrors(t *testing.T) {
+	s := newNoRouteTestServer(server.DefaultConfig(server.ServerHTTP))
+
+	tests := []struct {
+		method  string
+		path    string
+		code    int
+		message string
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound, "Not found"},
+		{http.MethodDelete, "/items", http.StatusMethodNotAllowed, "Method not allowed"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("X-Trace-ID", "trace-123")
+		s.Router().ServeHTTP(w, req)
+
+		assert.Equal(t, tt.code, w.Code, "%s %s", tt.method, tt.path)
+		assert.Equal(t, "trace-123", w.Header().Get("X-Trace-ID"))
+
+		var body server.ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, tt.message, body.Error)
+		assert.Equal(t, "trace-123", body.TraceID)
+	}
+}
+
+func TestNoRouteHandlers_OptOut(t *testing.T) {
+	cfg := server.DefaultConfig(server.ServerHTTP)
+	cfg.DisableNoRouteHandlers = true
+	s := newNoRouteTestServer(cfg)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404 page not found", w.Body.String())
+}
+
+func clientIPFor(t *testing.T, cfg *server.Config, remoteAddr string, headers map[string]string) string {
+	t.Helper()
+	cfg.Environment = "test"
+	s := server.NewHTTPServer(cfg, server.WithLogger(mock.NewMockLogger()))
+	s.Router().GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, server.ClientIP(c)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestClientIP_TrustedProxies(t *testing.T) {
+	cfg := server.DefaultConfig(server.ServerHTTP)
+	cfg.ForwardedByClientIP = true
+	cfg.TrustedProxies = []string{"10.0.0.0/8"}
+
+	// Via the trusted load balancer, the forwarded client address is used
+	assert.Equal(t, "203.0.113.7", clientIPFor(t, cfg, "10.1.2.3:4567",
+		map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+	assert.Equal(t, "203.0.113.7", clientIPFor(t, cfg, "10.1.2.3:4567",
+		map[string]string{"X-Real-IP": "203.0.113.7"}))
+
+	// A client can't prepend a spoofed address to the proxy's entry
+	assert.Equal(t, "203.0.113.7", clientIPFor(t, cfg, "10.1.2.3:4567",
+		map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.7"}))
+
+	// Headers sent straight from an untrusted address are ignored
+	assert.Equal(t, "198.51.100.9", clientIPFor(t, cfg, "198.51.100.9:5555",
+		map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "1.1.1.1"}))
+}
+
+func TestClientIP_IgnoresForwardedHeadersByDefault(t *testing.T) {
+	assert.Equal(t, "10.1.2.3", clientIPFor(t, server.DefaultConfig(server.ServerHTTP), "10.1.2.3:4567",
+		map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+
+	cfg := server.DefaultConfig(server.ServerHTTP)
+	cfg.ForwardedByClientIP = true
+	assert.Equal(t, "10.1.2.3", clientIPFor(t, cfg, "10.1.2.3:4567",
+		map[string]string{"X-Forwarded-For": "203.0.113.7"}), "no proxies are trusted without TrustedProxies")
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequest describes a request that exceeded the slow request threshold
+type SlowRequest struct {
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs float64       `json:"latency_ms"`
+	TraceID   string        `json:"trace_id,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// SlowRequestLog keeps the most recent slow requests in a bounded ring buffer
+type SlowRequestLog struct {
+	threshold time.Duration
+	mu        sync.Mutex
+	entries   []SlowRequest
+	next      int
+	full      bool
+}
+
+// NewSlowRequestLog records requests slower than threshold, keeping the last size of them
+func NewSlowRequestLog(threshold time.Duration, size int) *SlowRequestLog {
+	if size <= 0 {
+		size = 100
+	}
+	return &SlowRequestLog{threshold: threshold, entries: make([]SlowRequest, size)}
+}
+
+// Middleware times each request and records it if it exceeds the threshold
+func (l *SlowRequestLog) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		latency := time.Since(start)
+		if latency < l.threshold {
+			return
+		}
+		l.record(SlowRequest{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			Latency:   latency,
+			LatencyMs: float64(latency.Microseconds()) / 1000.0,
+			TraceID:   errorTraceID(c),
+			Timestamp: start,
+		})
+	}
+}
+
+func (l *SlowRequestLog) record(r SlowRequest) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = r
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Entries returns the retained slow requests, slowest first
+func (l *SlowRequestLog) Entries() []SlowRequest {
+	l.mu.Lock()
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	out := make([]SlowRequest, n)
+	copy(out, l.entries[:n])
+	l.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Latency > out[j].Latency })
+	return out
+}
+
+// Handler serves the retained slow requests as JSON. Mount it on an admin route.
+func (l *SlowRequestLog) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"threshold_ms": l.threshold.Milliseconds(),
+			"requests":     l.Entries(),
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSlowRequestRouter(l *SlowRequestLog) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(l.Middleware())
+	r.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/slow/:id", func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.Set("trace_id", "trace-"+c.Param("id"))
+		c.Status(http.StatusAccepted)
+	})
+	r.GET("/admin/slow-requests", l.Handler())
+	return r
+}
+
+func TestSlowRequestLog_RetainsOnlySlowRequests(t *testing.T) {
+	l := NewSlowRequestLog(20*time.Millisecond, 10)
+	r := newSlowRequestRouter(l)
+
+	for _, path := range []string{"/fast", "/slow/1", "/fast", "/slow/2", "/fast"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := l.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 slow requests, got %d: %+v", len(entries), entries)
+	}
+	for _, e := range entries {
+		if e.Method != http.MethodGet || e.Status != http.StatusAccepted || e.Latency < 20*time.Millisecond {
+			t.Fatalf("unexpected entry %+v", e)
+		}
+		if e.TraceID != "trace-1" && e.TraceID != "trace-2" {
+			t.Fatalf("unexpected trace ID %q", e.TraceID)
+		}
+		if e.Timestamp.IsZero() {
+			t.Fatal("expected a timestamp")
+		}
+	}
+}
+
+func TestSlowRequestLog_CappedAtSize(t *testing.T) {
+	l := NewSlowRequestLog(20*time.Millisecond, 3)
+	r := newSlowRequestRouter(l)
+
+	for i := 1; i <= 5; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/slow/%d", i), nil))
+	}
+
+	entries := l.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected the ring to hold 3 entries, got %d", len(entries))
+	}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		seen[e.Path] = true
+	}
+	for _, path := range []string{"/slow/3", "/slow/4", "/slow/5"} {
+		if !seen[path] {
+			t.Fatalf("expected the most recent requests to be kept, got %v", seen)
+		}
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Latency > entries[i-1].Latency {
+			t.Fatal("expected entries sorted slowest first")
+		}
+	}
+}
+
+func TestSlowRequestLog_Handler(t *testing.T) {
+	l := NewSlowRequestLog(20*time.Millisecond, 5)
+	r := newSlowRequestRouter(l)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow/1", nil))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/slow-requests", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var body struct {
+		ThresholdMs int64         `json:"threshold_ms"`
+		Requests    []SlowRequest `json:"requests"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ThresholdMs != 20 || len(body.Requests) != 1 || body.Requests[0].Path != "/slow/1" || body.Requests[0].LatencyMs < 20 {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for commonName, setting
+// the files' modification time so rotations are detected regardless of
+// filesystem timestamp resolution
+func writeCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// serveTLS accepts connections and completes their handshakes until the test ends
+func serveTLS(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func servedCommonName(t *testing.T, addr string) string {
+	t.Helper()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestConfigTLS_ReloadsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	base := time.Now().Add(-time.Hour)
+	writeCertificate(t, certFile, keyFile, "original", base)
+
+	cfg := DefaultConfig(ServerHTTP)
+	cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSCertReload = certFile, keyFile, true
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTLS(t, tlsConfig)
+
+	if cn := servedCommonName(t, addr); cn != "original" {
+		t.Fatalf("expected the original certificate, got %q", cn)
+	}
+
+	writeCertificate(t, certFile, keyFile, "rotated", base.Add(time.Minute))
+	if cn := servedCommonName(t, addr); cn != "rotated" {
+		t.Fatalf("expected the rotated certificate, got %q", cn)
+	}
+}
+
+func TestCertReloader_KeepsCertificateOnBadRotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	base := time.Now().Add(-time.Hour)
+	writeCertificate(t, certFile, keyFile, "original", base)
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A half-written rotation: the key is replaced but not yet the cert
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := reloader.GetCertificate(nil)
+	if err != nil || cert == nil {
+		t.Fatalf("expected the previous certificate, got %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+	if leaf.Subject.CommonName != "original" {
+		t.Fatalf("expected the original certificate, got %q", leaf.Subject.CommonName)
+	}
+}
+
+func TestConfigTLS_Static(t *testing.T) {
+	cfg := DefaultConfig(ServerHTTP)
+	if tlsConfig, err := cfg.TLSConfig(); err != nil || tlsConfig != nil {
+		t.Fatalf("expected no TLS without cert files, got %v, %v", tlsConfig, err)
+	}
+
+	dir := t.TempDir()
+	cfg.TLSCertFile, cfg.TLSKeyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, cfg.TLSCertFile, cfg.TLSKeyFile, "static", time.Now())
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil || len(tlsConfig.Certificates) != 1 || tlsConfig.GetCertificate != nil {
+		t.Fatalf("expected a static certificate, got %+v, %v", tlsConfig, err)
+	}
+
+	cfg.TLSKeyFile = ""
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected cert without key to be invalid")
+	}
+}
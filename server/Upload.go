@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	storageapi "github.com/bignyap/go-utilities/storage/api"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultUploadField is the multipart field read when UploadOptions.Field is empty
+	DefaultUploadField = "file"
+	// DefaultMaxUploadSize is the file size limit when UploadOptions.MaxSize is zero
+	DefaultMaxUploadSize int64 = 10 << 20
+	// TenantIDKey is the gin context key the default UploadOptions.TenantID reads
+	TenantIDKey = "tenant_id"
+
+	// maxMultipartOverhead allows for boundaries, part headers and small form
+	// fields when rejecting requests by Content-Length
+	maxMultipartOverhead = 1 << 20
+)
+
+// errUploadTooLarge aborts the storage upload once the file passes MaxSize
+var errUploadTooLarge = errors.New("upload exceeds maximum size")
+
+// UploadOptions configures HandleUpload. The zero value accepts any content
+// type up to DefaultMaxUploadSize from the "file" field.
+type UploadOptions struct {
+	// Field is the multipart field holding the file
+	Field string
+	// MaxSize is the largest accepted file in bytes
+	MaxSize int64
+	// AllowedTypes lists accepted content types, e.g. "image/png" or
+	// "image/*". Empty allows every type.
+	AllowedTypes []string
+	// TenantID returns the authenticated tenant, by default the TenantIDKey
+	// value set on the gin context by the auth middleware
+	TenantID func(c *gin.Context) string
+	// ObjectKey names the stored object, by default the base name of the
+	// uploaded file
+	ObjectKey func(c *gin.Context, filename string) string
+	// Storage is passed to StorageService.UploadWithOptions
+	Storage storageapi.UploadOptions
+}
+
+// UploadResult describes a stored upload
+type UploadResult struct {
+	Path        string `json:"path"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// ReceiveUpload streams the opts.Field file of a multipart request to svc
+// under the request's tenant, without buffering it in memory or on disk.
+// The first 512 bytes are sniffed and the stored type is checked against
+// opts.AllowedTypes (see resolveContentType), so a client can't get content
+// past the allowlist by declaring an allowed type. Oversize files fail with
+// ErrorLargePayload and disallowed types with ErrorMediaType.
+func ReceiveUpload(c *gin.Context, svc storageapi.StorageService, opts UploadOptions) (*UploadResult, error) {
+	opts = opts.withDefaults()
+
+	tenantID := opts.TenantID(c)
+	if tenantID == "" {
+		return nil, NewError(ErrorUnauthorized, "Unauthorized", nil)
+	}
+	if c.Request.ContentLength > 0 && c.Request.ContentLength-opts.MaxSize > maxMultipartOverhead {
+		return nil, NewError(ErrorLargePayload, "Payload too large", errUploadTooLarge)
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, NewError(ErrorBadRequest, "expected a multipart/form-data request", err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, NewError(ErrorBadRequest, "missing file field "+opts.Field, nil)
+		}
+		if err != nil {
+			return nil, NewError(ErrorBadRequest, "malformed multipart body", err)
+		}
+		if part.FormName() != opts.Field || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		defer part.Close()
+		return storeUpload(c, svc, opts, tenantID, part.FileName(), part.Header.Get("Content-Type"), part)
+	}
+}
+
+func storeUpload(c *gin.Context, svc storageapi.StorageService, opts UploadOptions, tenantID, filename, contentType string, body io.Reader) (*UploadResult, error) {
+	objectKey := opts.ObjectKey(c, filename)
+	if objectKey == "" {
+		return nil, NewError(ErrorBadRequest, "invalid file name", nil)
+	}
+
+	buffered := bufio.NewReaderSize(body, 512)
+	head, _ := buffered.Peek(512)
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	declared, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		declared = ""
+	}
+	contentType = resolveContentType(declared, sniffed, len(opts.AllowedTypes) > 0)
+	if !contentTypeAllowed(contentType, opts.AllowedTypes) {
+		return nil, NewError(ErrorMediaType, "Unsupported media type", nil)
+	}
+
+	limited := &sizeLimitReader{r: buffered, remaining: opts.MaxSize}
+	storagePath, err := svc.UploadWithOptions(c.Request.Context(), tenantID, objectKey, limited, -1, contentType, opts.Storage)
+	if err != nil {
+		if limited.exceeded {
+			return nil, NewError(ErrorLargePayload, "Payload too large", errUploadTooLarge)
+		}
+		if errors.Is(err, storageapi.ErrInvalidPath) {
+			return nil, NewError(ErrorBadRequest, "invalid file name", err)
+		}
+		return nil, NewError(ErrorInternal, "failed to store upload", err)
+	}
+
+	return &UploadResult{
+		Path:        storagePath,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        opts.MaxSize - limited.remaining,
+	}, nil
+}
+
+// HandleUpload runs ReceiveUpload and writes the UploadResult with 201
+// Created, or the error response
+func (rw *ResponseWriter) HandleUpload(c *gin.Context, svc storageapi.StorageService, opts UploadOptions) {
+	result, err := ReceiveUpload(c, svc, opts)
+	if err != nil {
+		rw.Error(c, err)
+		return
+	}
+	rw.Created(c, result)
+}
+
+func (o UploadOptions) withDefaults() UploadOptions {
+	if o.Field == "" {
+		o.Field = DefaultUploadField
+	}
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultMaxUploadSize
+	}
+	if o.TenantID == nil {
+		o.TenantID = func(c *gin.Context) string { return c.GetString(TenantIDKey) }
+	}
+	if o.ObjectKey == nil {
+		o.ObjectKey = defaultObjectKey
+	}
+	return o
+}
+
+// defaultObjectKey keeps only the base name, so a client can't choose a
+// directory with a name like "../other-tenant/x"
+func defaultObjectKey(_ *gin.Context, filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// genericSniffTypes are what http.DetectContentType reports for content it
+// can't identify, so they don't contradict a declared type
+var genericSniffTypes = map[string]bool{
+	"application/octet-stream": true,
+	"text/plain":               true,
+}
+
+// textTypes are declared types a text/plain sniff is consistent with
+var textTypes = map[string]bool{
+	"application/json": true,
+	"application/xml":  true,
+}
+
+// resolveContentType picks an upload's type: the declared one when the
+// content agrees with it and the sniffed one otherwise. Without an
+// allowlist, content that sniffs as a generic type agrees with any declared
+// type. With one (restricted), a generic sniff only confirms a declared type
+// that is itself generic or text, so text declared as image/png is checked
+// as text/plain.
+func resolveContentType(declared, sniffed string, restricted bool) string {
+	if declared == "" || declared == "application/octet-stream" {
+		return sniffed
+	}
+	if declared == sniffed {
+		return declared
+	}
+	if !genericSniffTypes[sniffed] {
+		return sniffed
+	}
+	if !restricted || genericSniffTypes[declared] || isTextType(declared) {
+		return declared
+	}
+	return sniffed
+}
+
+func isTextType(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") || textTypes[contentType]
+}
+
+func contentTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, pattern := range allowed {
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeLimitReader fails once more than remaining bytes are read
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		l.remaining = 0
+		return 0, errUploadTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
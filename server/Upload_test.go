@@ -0,0 +1,144 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/bignyap/go-utilities/server"
+	"github.com/bignyap/go-utilities/storage/adapters/fs"
+	"github.com/bignyap/go-utilities/storage/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUploadRouter(t *testing.T, opts server.UploadOptions) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	svc, err := fs.NewFSStorageService(config.FSConfig{BaseDir: dir})
+	require.NoError(t, err)
+
+	rw := server.NewResponseWriter(&mock.Mock{})
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set(server.TenantIDKey, "tenant-a")
+		rw.HandleUpload(c, svc, opts)
+	})
+	return r, dir
+}
+
+func postFile(t *testing.T, r *gin.Engine, filename, contentType string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("description", "a test file"))
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleUpload_StoresFile(t *testing.T) {
+	r, dir := newUploadRouter(t, server.UploadOptions{AllowedTypes: []string{"image/*"}})
+
+	w := postFile(t, r, "avatar.png", "image/png", pngData)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var result server.UploadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "tenant-a/avatar.png", result.Path)
+	assert.Equal(t, "image/png", result.ContentType)
+	assert.Equal(t, int64(len(pngData)), result.Size)
+
+	stored, err := os.ReadFile(filepath.Join(dir, "tenant-a", "avatar.png"))
+	require.NoError(t, err)
+	assert.Equal(t, pngData, stored)
+}
+
+// pngData starts with the PNG signature, so it sniffs as image/png
+var pngData = []byte("\x89PNG\r\n\x1a\n fake png data")
+
+func TestHandleUpload_RejectsOversizeFile(t *testing.T) {
+	r, dir := newUploadRouter(t, server.UploadOptions{MaxSize: 16})
+
+	w := postFile(t, r, "big.txt", "text/plain", bytes.Repeat([]byte("x"), 17))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "Payload too large")
+
+	_, err := os.Stat(filepath.Join(dir, "tenant-a", "big.txt"))
+	assert.True(t, os.IsNotExist(err), "expected no partial object, got %v", err)
+
+	w = postFile(t, r, "small.txt", "text/plain", bytes.Repeat([]byte("x"), 16))
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestHandleUpload_RejectsDisallowedType(t *testing.T) {
+	r, _ := newUploadRouter(t, server.UploadOptions{AllowedTypes: []string{"image/png", "application/pdf"}})
+
+	w := postFile(t, r, "script.sh", "text/x-shellscript", []byte("#!/bin/sh"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Contains(t, w.Body.String(), "Unsupported media type")
+
+	// Without a declared type the content is sniffed
+	w = postFile(t, r, "doc", "", []byte("%PDF-1.7 body"))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestHandleUpload_SniffsDeclaredType(t *testing.T) {
+	r, _ := newUploadRouter(t, server.UploadOptions{AllowedTypes: []string{"image/*"}})
+
+	// HTML declared as an allowed image type is caught by sniffing
+	w := postFile(t, r, "avatar.png", "image/png", []byte("<html><script>alert(1)</script></html>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// Plain text doesn't confirm a declared image type under an allowlist
+	w = postFile(t, r, "avatar.png", "image/png", []byte("just some text, not an image"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w = postFile(t, r, "run.png", "image/png", []byte("#!/bin/sh\nrm -rf /\n"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// An allowed type that disagrees with the declared one is stored as sniffed
+	w = postFile(t, r, "photo.jpg", "image/jpeg", pngData)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var result server.UploadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "image/png", result.ContentType)
+}
+
+func TestHandleUpload_GenericSniffKeepsTextTypes(t *testing.T) {
+	r, _ := newUploadRouter(t, server.UploadOptions{AllowedTypes: []string{"text/csv"}})
+
+	w := postFile(t, r, "report.csv", "text/csv", []byte("id,name\n1,alice\n"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var result server.UploadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "text/csv", result.ContentType)
+
+	// Without an allowlist the declared type is kept for unidentified content
+	r, _ = newUploadRouter(t, server.UploadOptions{})
+	w = postFile(t, r, "notes.md", "text/markdown", []byte("# notes"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "text/markdown", result.ContentType)
+}
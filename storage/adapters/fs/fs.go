@@ -0,0 +1,366 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bignyap/go-utilities/storage"
+	"github.com/bignyap/go-utilities/storage/api"
+	"github.com/bignyap/go-utilities/storage/config"
+)
+
+// tempPrefix marks in-progress uploads, which List skips
+const tempPrefix = ".upload-"
+
+// FSStorageService implements StorageService interface on the local
+// filesystem, for development and tests. Objects are stored as
+// BaseDir/tenant_id/object_key. Content types are derived from the file
+// extension, and upload encryption and metadata are not applied.
+type FSStorageService struct {
+	baseDir string
+	baseURL string
+	secret  []byte
+}
+
+// Ensure FSStorageService implements api.StorageService
+var _ api.StorageService = (*FSStorageService)(nil)
+
+// NewFSStorageService creates a filesystem storage service, creating the base
+// directory if it is missing
+func NewFSStorageService(cfg config.FSConfig) (*FSStorageService, error) {
+	if cfg.BaseDir == "" {
+		return nil, fmt.Errorf("filesystem storage requires a base directory")
+	}
+	baseDir, err := filepath.Abs(cfg.BaseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create base directory: %w", err)
+	}
+
+	return &FSStorageService{
+		baseDir: baseDir,
+		baseURL: cfg.BaseURL,
+		secret:  []byte(cfg.SigningSecret),
+	}, nil
+}
+
+// resolve maps a storage path to a file under the base directory. Absolute
+// paths, empty segments and any ".." segment are rejected, so a key can't reach another
+// tenant even when it would stay inside the base directory.
+func (s *FSStorageService) resolve(storagePath string) (string, error) {
+	rel := filepath.FromSlash(storagePath)
+	if !filepath.IsLocal(rel) || strings.Contains(storagePath, `\`) {
+		return "", fmt.Errorf("%w: %q", api.ErrInvalidPath, storagePath)
+	}
+	for _, seg := range strings.Split(storagePath, "/") {
+		if seg == ".." || seg == "" {
+			return "", fmt.Errorf("%w: %q", api.ErrInvalidPath, storagePath)
+		}
+	}
+	return filepath.Join(s.baseDir, rel), nil
+}
+
+// Upload writes a file to disk
+func (s *FSStorageService) Upload(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string) (string, error) {
+	return s.UploadWithOptions(ctx, tenantID, objectKey, data, size, contentType, api.UploadOptions{})
+}
+
+// UploadWithOptions writes a file to disk. The options are validated but
+// encryption, metadata and cache control are not stored.
+func (s *FSStorageService) UploadWithOptions(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string, opts api.UploadOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	// Create storage path: tenant_id/object_key
+	storagePath := fmt.Sprintf("%s/%s", tenantID, objectKey)
+	if err := s.writeFile(storagePath, data); err != nil {
+		return "", fmt.Errorf("failed to upload object: %w", err)
+	}
+	return storagePath, nil
+}
+
+// writeFile writes through a temporary file and renames it into place, so
+// readers never see a partial object
+func (s *FSStorageService) writeFile(storagePath string, data io.Reader) error {
+	file, err := s.resolve(storagePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Download reads a file into memory; use DownloadStream for large files
+func (s *FSStorageService) Download(ctx context.Context, storagePath string) ([]byte, string, error) {
+	body, contentType, err := s.DownloadStream(ctx, storagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// DownloadStream opens a file for reading. The caller must Close it.
+func (s *FSStorageService) DownloadStream(ctx context.Context, storagePath string) (io.ReadCloser, string, error) {
+	return s.DownloadRangeStream(ctx, storagePath, 0, -1)
+}
+
+// DownloadRange reads a byte range of a file
+func (s *FSStorageService) DownloadRange(ctx context.Context, storagePath string, start, end int64) ([]byte, string, error) {
+	body, contentType, err := s.DownloadRangeStream(ctx, storagePath, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object range: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// DownloadRangeStream opens a byte range of a file for reading. The caller
+// must Close it.
+func (s *FSStorageService) DownloadRangeStream(ctx context.Context, storagePath string, start, end int64) (io.ReadCloser, string, error) {
+	if err := api.ValidateRange(start, end); err != nil {
+		return nil, "", err
+	}
+	file, err := s.resolve(storagePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, "", fmt.Errorf("failed to get object info: %w", err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, "", fmt.Errorf("failed to get object: %w", iofs.ErrNotExist)
+	}
+	if start > 0 && start >= info.Size() {
+		f.Close()
+		return nil, "", fmt.Errorf("%w: start=%d is past the end of a %d byte object", api.ErrInvalidRange, start, info.Size())
+	}
+
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		f.Close()
+		return nil, "", fmt.Errorf("failed to seek object: %w", err)
+	}
+	var body io.Reader = f
+	if end >= 0 {
+		body = io.LimitReader(f, end-start+1)
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{body, f}, contentTypeOf(storagePath), nil
+}
+
+// contentTypeOf guesses a content type from the file extension
+func contentTypeOf(storagePath string) string {
+	if ct := mime.TypeByExtension(path.Ext(storagePath)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// GetPresignedURL returns a URL signed with storage.SignDownloadURL that
+// points at BaseURL. Serve it with Handler.
+func (s *FSStorageService) GetPresignedURL(ctx context.Context, storagePath string, expirySeconds int) (string, error) {
+	if s.baseURL == "" || len(s.secret) == 0 {
+		return "", fmt.Errorf("%w: presigned URLs need a base URL and signing secret", api.ErrNotSupported)
+	}
+	if _, err := s.resolve(storagePath); err != nil {
+		return "", err
+	}
+	return storage.SignDownloadURL(s.baseURL, storagePath, time.Duration(expirySeconds)*time.Second, s.secret), nil
+}
+
+// GetPresignedUploadURL is not supported; upload through the service instead
+func (s *FSStorageService) GetPresignedUploadURL(ctx context.Context, tenantID, objectKey string, contentType string, expirySeconds int) (string, error) {
+	return "", fmt.Errorf("%w: presigned uploads on filesystem storage", api.ErrNotSupported)
+}
+
+// Handler serves files for URLs from GetPresignedURL. Mount it at BaseURL.
+func (s *FSStorageService) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storagePath, err := storage.VerifyDownloadURL(r.URL.String(), s.secret)
+		if err != nil {
+			http.Error(w, "invalid or expired link", http.StatusForbidden)
+			return
+		}
+		file, err := s.resolve(storagePath)
+		if err != nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentTypeOf(storagePath))
+		http.ServeContent(w, r, "", info.ModTime(), f)
+	})
+}
+
+// Delete removes a file. Deleting a missing file is not an error.
+func (s *FSStorageService) Delete(ctx context.Context, storagePath string) error {
+	file, err := s.resolve(storagePath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// Copy copies a file. Unlike the object stores the bytes pass through this process.
+func (s *FSStorageService) Copy(ctx context.Context, srcPath, dstPath string) error {
+	src, err := s.resolve(srcPath)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	defer f.Close()
+
+	if err := s.writeFile(dstPath, f); err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
+
+// Move renames a file
+func (s *FSStorageService) Move(ctx context.Context, srcPath, dstPath string) error {
+	src, err := s.resolve(srcPath)
+	if err != nil {
+		return err
+	}
+	dst, err := s.resolve(dstPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+	return nil
+}
+
+// List lists a tenant's files under prefix in key order. The page token is
+// the last storage path of the previous page.
+func (s *FSStorageService) List(ctx context.Context, tenantID, prefix string, pageToken string, limit int) (api.ListResult, error) {
+	if limit <= 0 {
+		limit = api.DefaultListLimit
+	}
+	tenantDir, err := s.resolve(tenantID)
+	if err != nil {
+		return api.ListResult{}, err
+	}
+	tenantPrefix := tenantID + "/"
+
+	var objects []api.ObjectInfo
+	err = filepath.WalkDir(tenantDir, func(file string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, iofs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), tempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(tenantDir, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) || tenantPrefix+key <= pageToken {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, api.ObjectInfo{
+			StoragePath:  tenantPrefix + key,
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return api.ListResult{}, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	// WalkDir orders by path component, object stores by full key
+	sort.Slice(objects, func(i, j int) bool { return objects[i].StoragePath < objects[j].StoragePath })
+
+	result := api.ListResult{Objects: objects}
+	if len(objects) > limit {
+		result.Objects = objects[:limit]
+		result.NextPageToken = objects[limit-1].StoragePath
+	}
+	return result, nil
+}
@@ -0,0 +1,206 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/storage/api"
+	"github.com/bignyap/go-utilities/storage/config"
+)
+
+func newTestService(t *testing.T) *FSStorageService {
+	t.Helper()
+	svc, err := NewFSStorageService(config.FSConfig{
+		BaseDir:       t.TempDir(),
+		BaseURL:       "http://localhost/files",
+		SigningSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func upload(t *testing.T, svc *FSStorageService, tenantID, key, data string) string {
+	t.Helper()
+	path, err := svc.Upload(context.Background(), tenantID, key, strings.NewReader(data), int64(len(data)), "text/plain")
+	if err != nil {
+		t.Fatalf("upload %s/%s: %v", tenantID, key, err)
+	}
+	return path
+}
+
+func TestFS_UploadDownloadDelete(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	path := upload(t, svc, "tenant-1", "docs/report.json", `{"ok":true}`)
+	if path != "tenant-1/docs/report.json" {
+		t.Fatalf("unexpected storage path %q", path)
+	}
+	if _, err := os.Stat(filepath.Join(svc.baseDir, "tenant-1", "docs", "report.json")); err != nil {
+		t.Fatalf("expected the object on disk: %v", err)
+	}
+
+	data, contentType, err := svc.Download(ctx, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"ok":true}` || contentType != "application/json" {
+		t.Fatalf("unexpected download %q (%s)", data, contentType)
+	}
+
+	part, _, err := svc.DownloadRange(ctx, path, 1, 4)
+	if err != nil || string(part) != `"ok"` {
+		t.Fatalf("unexpected range %q: %v", part, err)
+	}
+
+	if err := svc.Delete(ctx, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := svc.Download(ctx, path); !errors.Is(err, iofs.ErrNotExist) {
+		t.Fatalf("expected a not-exist error after delete, got %v", err)
+	}
+	if err := svc.Delete(ctx, path); err != nil {
+		t.Fatalf("expected deleting a missing object to succeed, got %v", err)
+	}
+}
+
+func TestFS_ListPaginates(t *testing.T) {
+	svc := newTestService(t)
+	for _, key := range []string{"a/1.txt", "a-2.txt", "a/b/3.txt", "c.txt", "b.txt"} {
+		upload(t, svc, "tenant-1", key, key)
+	}
+	upload(t, svc, "tenant-2", "a/1.txt", "other tenant")
+
+	var keys []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		res, err := svc.List(context.Background(), "tenant-1", "", token, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, obj := range res.Objects {
+			if obj.StoragePath != "tenant-1/"+obj.Key || obj.Size != int64(len(obj.Key)) {
+				t.Fatalf("unexpected object %+v", obj)
+			}
+			keys = append(keys, obj.Key)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		token = res.NextPageToken
+	}
+	if got := strings.Join(keys, ","); got != "a-2.txt,a/1.txt,a/b/3.txt,b.txt,c.txt" {
+		t.Fatalf("unexpected keys %q", got)
+	}
+
+	res, err := svc.List(context.Background(), "tenant-1", "a/", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Objects) != 2 || res.Objects[0].Key != "a/1.txt" || res.Objects[1].Key != "a/b/3.txt" {
+		t.Fatalf("unexpected prefix listing %+v", res.Objects)
+	}
+
+	res, err = svc.List(context.Background(), "tenant-3", "", "", 0)
+	if err != nil || len(res.Objects) != 0 {
+		t.Fatalf("expected an empty listing for an unknown tenant, got %+v (%v)", res.Objects, err)
+	}
+}
+
+func TestFS_CopyAndMove(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	src := upload(t, svc, "tenant-1", "inbox/a.txt", "hello")
+
+	if err := svc.Copy(ctx, src, "tenant-1/backup/a.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Move(ctx, src, "tenant-1/archive/a.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{"tenant-1/backup/a.txt", "tenant-1/archive/a.txt"} {
+		if data, _, err := svc.Download(ctx, path); err != nil || string(data) != "hello" {
+			t.Fatalf("unexpected content at %s: %q (%v)", path, data, err)
+		}
+	}
+	if _, _, err := svc.Download(ctx, src); !errors.Is(err, iofs.ErrNotExist) {
+		t.Fatalf("expected the moved source to be gone, got %v", err)
+	}
+}
+
+func TestFS_RejectsPathTraversal(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	upload(t, svc, "tenant-2", "secret.txt", "tenant 2 only")
+
+	for _, key := range []string{"../tenant-2/secret.txt", "a/../../escape.txt", "../../etc/passwd", "a//b.txt", `..\escape.txt`} {
+		if _, err := svc.Upload(ctx, "tenant-1", key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, api.ErrInvalidPath) {
+			t.Fatalf("upload %q: expected ErrInvalidPath, got %v", key, err)
+		}
+	}
+	for _, path := range []string{"tenant-1/../tenant-2/secret.txt", "/etc/passwd", "../outside.txt"} {
+		if _, _, err := svc.Download(ctx, path); !errors.Is(err, api.ErrInvalidPath) {
+			t.Fatalf("download %q: expected ErrInvalidPath, got %v", path, err)
+		}
+		if err := svc.Delete(ctx, path); !errors.Is(err, api.ErrInvalidPath) {
+			t.Fatalf("delete %q: expected ErrInvalidPath, got %v", path, err)
+		}
+	}
+	if _, err := svc.List(ctx, "..", "", "", 0); !errors.Is(err, api.ErrInvalidPath) {
+		t.Fatalf("list: expected ErrInvalidPath, got %v", err)
+	}
+	if err := svc.Copy(ctx, "tenant-2/secret.txt", "tenant-1/../../stolen.txt"); !errors.Is(err, api.ErrInvalidPath) {
+		t.Fatalf("copy: expected ErrInvalidPath, got %v", err)
+	}
+}
+
+func TestFS_PresignedURLHandler(t *testing.T) {
+	svc := newTestService(t)
+	path := upload(t, svc, "tenant-1", "notes.txt", "signed content")
+
+	rawURL, err := svc.GetPresignedURL(context.Background(), path, 60)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	srv := httptest.NewServer(svc.Handler())
+	defer srv.Close()
+	target := strings.Replace(rawURL, "http://localhost/files", srv.URL, 1)
+
+	resp, err := http.Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "signed content" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(strings.Replace(target, "notes.txt", "other.txt", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a tampered URL to be rejected, got %d", resp.StatusCode)
+	}
+
+	if _, err := svc.GetPresignedUploadURL(context.Background(), "tenant-1", "a.txt", "", 60); !errors.Is(err, api.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+}
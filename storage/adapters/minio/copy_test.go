@@ -0,0 +1,55 @@
+package minio
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCopy_DuplicatesObjectServerSide(t *testing.T) {
+	// Spaces and '+' exercise the copy source encoding
+	src, dst := "tenant-1/old dir/a+b.txt", "tenant-1/new dir/a+b.txt"
+	svc, bucket := newTestService(t)
+	bucket.PutObject(src, []byte("quarterly numbers"), "text/plain")
+
+	if err := svc.Copy(context.Background(), src, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bucket.Copies() != 1 {
+		t.Fatalf("expected one server-side copy, got %d", bucket.Copies())
+	}
+
+	for _, path := range []string{src, dst} {
+		data, _, err := svc.Download(context.Background(), path)
+		if err != nil {
+			t.Fatalf("download %q: %v", path, err)
+		}
+		if string(data) != "quarterly numbers" {
+			t.Fatalf("unexpected content at %q: %q", path, data)
+		}
+	}
+
+	if err := svc.Copy(context.Background(), "tenant-1/missing.txt", dst); err == nil {
+		t.Fatal("expected an error copying a missing object")
+	}
+}
+
+func TestMove_RemovesSource(t *testing.T) {
+	src, dst := "tenant-1/inbox/report.txt", "tenant-1/archive/report.txt"
+	svc, bucket := newTestService(t)
+	bucket.PutObject(src, []byte("final report"), "text/plain")
+
+	if err := svc.Move(context.Background(), src, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _, err := svc.Download(context.Background(), dst)
+	if err != nil {
+		t.Fatalf("download moved object: %v", err)
+	}
+	if string(data) != "final report" {
+		t.Fatalf("unexpected moved content %q", data)
+	}
+	if _, found := bucket.Object(src); found {
+		t.Fatal("expected the source to be deleted")
+	}
+}
@@ -0,0 +1,87 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bignyap/go-utilities/storage/api"
+)
+
+func TestDownloadStream_YieldsFullObject(t *testing.T) {
+	data := make([]byte, 4<<20)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	svc, bucket := newTestService(t)
+	bucket.PutObject("tenant-1/video.mp4", data, "video/mp4")
+
+	body, contentType, err := svc.DownloadStream(context.Background(), "tenant-1/video.mp4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+	if contentType != "video/mp4" {
+		t.Fatalf("expected video/mp4, got %q", contentType)
+	}
+	got, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("expected %d streamed bytes to match the object, got %d", len(data), len(got))
+	}
+
+	if _, _, err := svc.DownloadStream(context.Background(), "tenant-1/missing.mp4"); err == nil {
+		t.Fatal("expected an error for a missing object")
+	}
+}
+
+func TestDownloadRange_ReturnsExactBytes(t *testing.T) {
+	data := make([]byte, 64<<10)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	svc, bucket := newTestService(t)
+	bucket.PutObject("tenant-1/video.mp4", data, "video/mp4")
+
+	tests := []struct {
+		name       string
+		start, end int64
+		want       []byte
+	}{
+		{name: "mid-object", start: 1000, end: 1999, want: data[1000:2000]},
+		{name: "single byte", start: 42, end: 42, want: data[42:43]},
+		{name: "open-ended", start: int64(len(data) - 500), end: -1, want: data[len(data)-500:]},
+		{name: "from zero to EOF", start: 0, end: -1, want: data},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, contentType, err := svc.DownloadRange(context.Background(), "tenant-1/video.mp4", tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if contentType != "video/mp4" {
+				t.Fatalf("expected video/mp4, got %q", contentType)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("expected %d bytes of the range, got %d", len(tt.want), len(got))
+			}
+		})
+	}
+}
+
+func TestDownloadRange_RejectsInvalidRange(t *testing.T) {
+	svc, bucket := newTestService(t)
+	bucket.PutObject("tenant-1/video.mp4", nil, "video/mp4")
+
+	for _, r := range [][2]int64{{10, 5}, {-1, 5}, {-1, -1}} {
+		_, _, err := svc.DownloadRangeStream(context.Background(), "tenant-1/video.mp4", r[0], r[1])
+		if !errors.Is(err, api.ErrInvalidRange) {
+			t.Fatalf("range %v: expected ErrInvalidRange, got %v", r, err)
+		}
+	}
+}
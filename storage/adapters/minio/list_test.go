@@ -0,0 +1,81 @@
+package minio
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/storage/internal/s3test"
+)
+
+// newListService returns a service over a bucket holding keys, each with
+// its own name as content
+func newListService(t *testing.T, keys ...string) *MinIOStorageService {
+	t.Helper()
+	svc, bucket := newTestService(t)
+	for _, key := range keys {
+		bucket.PutObject(key, []byte(key), "text/plain")
+	}
+	return svc
+}
+
+func TestList_PaginatesWithinTenant(t *testing.T) {
+	svc := newListService(t,
+		"tenant-1/a.txt", "tenant-1/b.txt", "tenant-1/c.txt",
+		"tenant-1/d.txt", "tenant-1/e.txt", "tenant-2/a.txt",
+	)
+
+	var keys []string
+	token := ""
+	pages := 0
+	for {
+		res, err := svc.List(context.Background(), "tenant-1", "", token, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pages++
+		if len(res.Objects) > 2 {
+			t.Fatalf("expected at most 2 objects per page, got %d", len(res.Objects))
+		}
+		for _, obj := range res.Objects {
+			if obj.StoragePath != "tenant-1/"+obj.Key {
+				t.Fatalf("unexpected storage path %q for key %q", obj.StoragePath, obj.Key)
+			}
+			if !obj.LastModified.Equal(s3test.LastModified) {
+				t.Fatalf("unexpected last modified %v", obj.LastModified)
+			}
+			keys = append(keys, obj.Key)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		token = res.NextPageToken
+	}
+
+	if got := strings.Join(keys, ","); got != "a.txt,b.txt,c.txt,d.txt,e.txt" {
+		t.Fatalf("unexpected keys %q", got)
+	}
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+}
+
+func TestList_FiltersByPrefix(t *testing.T) {
+	svc := newListService(t,
+		"tenant-1/docs/a.pdf", "tenant-1/docs/b.pdf", "tenant-1/images/c.png",
+	)
+
+	res, err := svc.List(context.Background(), "tenant-1", "docs/", "", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Objects) != 2 || res.Objects[0].Key != "docs/a.pdf" || res.Objects[1].Key != "docs/b.pdf" {
+		t.Fatalf("unexpected objects %+v", res.Objects)
+	}
+	if res.Objects[0].Size != int64(len("tenant-1/docs/a.pdf")) {
+		t.Fatalf("unexpected size %d", res.Objects[0].Size)
+	}
+	if res.NextPageToken != "" {
+		t.Fatalf("expected no next page, got %q", res.NextPageToken)
+	}
+}
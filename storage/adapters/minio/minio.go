@@ -2,14 +2,19 @@ package minio
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bignyap/go-utilities/storage/api"
 	"github.com/bignyap/go-utilities/storage/config"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 )
 
 // MinIOStorageService implements StorageService interface for MinIO
@@ -31,8 +36,16 @@ func NewMinIOStorageService(cfg config.MinIOConfig) (*MinIOStorageService, error
 		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
 	}
 
-	// Ensure bucket exists
 	ctx := context.Background()
+
+	// Fail fast on bad credentials instead of on the first real operation
+	if cfg.VerifyAccess {
+		if err := verifyAccess(ctx, client, cfg.BucketName); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ensure bucket exists
 	exists, err := client.BucketExists(ctx, cfg.BucketName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
@@ -50,14 +63,68 @@ func NewMinIOStorageService(cfg config.MinIOConfig) (*MinIOStorageService, error
 	}, nil
 }
 
+// verifyAccess lists at most one object to check that the credentials are
+// accepted and allowed to read the bucket. A missing bucket is not treated
+// as an error since the constructor creates it afterwards.
+func verifyAccess(ctx context.Context, client *minio.Client, bucketName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for obj := range client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{MaxKeys: 1}) {
+		if obj.Err != nil {
+			return classifyAccessError(obj.Err)
+		}
+		break
+	}
+	return nil
+}
+
+// classifyAccessError maps a MinIO error to api.ErrAccessDenied or api.ErrUnreachable
+func classifyAccessError(err error) error {
+	resp := minio.ToErrorResponse(err)
+	switch {
+	case resp.Code == "NoSuchBucket":
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		resp.Code == "AccessDenied", resp.Code == "InvalidAccessKeyId", resp.Code == "SignatureDoesNotMatch":
+		return fmt.Errorf("%w: %w", api.ErrAccessDenied, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", api.ErrUnreachable, err)
+	}
+	return fmt.Errorf("failed to verify storage access: %w", err)
+}
+
 // Upload uploads a file to MinIO
 func (s *MinIOStorageService) Upload(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string) (string, error) {
+	return s.UploadWithOptions(ctx, tenantID, objectKey, data, size, contentType, api.UploadOptions{})
+}
+
+// UploadWithOptions uploads a file to MinIO with encryption and metadata
+func (s *MinIOStorageService) UploadWithOptions(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string, opts api.UploadOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
 	// Create storage path: tenant_id/object_key
 	storagePath := fmt.Sprintf("%s/%s", tenantID, objectKey)
 
-	_, err := s.client.PutObject(ctx, s.bucketName, storagePath, data, size, minio.PutObjectOptions{
-		ContentType: contentType,
-	})
+	putOpts := minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: opts.Metadata,
+		CacheControl: opts.CacheControl,
+	}
+	switch opts.SSE {
+	case api.SSES3:
+		putOpts.ServerSideEncryption = encrypt.NewSSE()
+	case api.SSEKMS:
+		// Without an encryption context NewSSEKMS cannot fail
+		putOpts.ServerSideEncryption, _ = encrypt.NewSSEKMS(opts.KMSKeyID, nil)
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucketName, storagePath, data, size, putOpts)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload object: %w", err)
 	}
@@ -65,26 +132,83 @@ func (s *MinIOStorageService) Upload(ctx context.Context, tenantID, objectKey st
 	return storagePath, nil
 }
 
-// Download downloads a file from MinIO
+// Download downloads a file from MinIO into memory; use DownloadStream for large objects
 func (s *MinIOStorageService) Download(ctx context.Context, storagePath string) ([]byte, string, error) {
+	body, contentType, err := s.DownloadStream(ctx, storagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// DownloadStream returns the object body from MinIO. The caller must Close it.
+func (s *MinIOStorageService) DownloadStream(ctx context.Context, storagePath string) (io.ReadCloser, string, error) {
 	obj, err := s.client.GetObject(ctx, s.bucketName, storagePath, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to get object: %w", err)
 	}
-	defer obj.Close()
 
 	// Get object info for content type
 	info, err := obj.Stat()
 	if err != nil {
+		obj.Close()
 		return nil, "", fmt.Errorf("failed to get object info: %w", err)
 	}
 
-	data, err := io.ReadAll(obj)
+	return obj, info.ContentType, nil
+}
+
+// DownloadRange downloads a byte range of a file from MinIO
+func (s *MinIOStorageService) DownloadRange(ctx context.Context, storagePath string, start, end int64) ([]byte, string, error) {
+	body, contentType, err := s.DownloadRangeStream(ctx, storagePath, start, end)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to read object: %w", err)
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object range: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// DownloadRangeStream returns a byte range of the object body from MinIO.
+// The caller must Close it.
+func (s *MinIOStorageService) DownloadRangeStream(ctx context.Context, storagePath string, start, end int64) (io.ReadCloser, string, error) {
+	if err := api.ValidateRange(start, end); err != nil {
+		return nil, "", err
+	}
+
+	opts := minio.GetObjectOptions{}
+	var err error
+	switch {
+	case end >= 0:
+		err = opts.SetRange(start, end)
+	case start > 0:
+		// SetRange treats an end of 0 as open-ended
+		err = opts.SetRange(start, 0)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", api.ErrInvalidRange, err)
+	}
+
+	// Object.Stat drops the Range header, so issue a single ranged GET instead
+	core := minio.Core{Client: s.client}
+	body, info, _, err := core.GetObject(ctx, s.bucketName, storagePath, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object range: %w", err)
 	}
 
-	return data, info.ContentType, nil
+	return body, info.ContentType, nil
 }
 
 // GetPresignedURL generates a presigned URL for downloading
@@ -97,6 +221,83 @@ func (s *MinIOStorageService) GetPresignedURL(ctx context.Context, storagePath s
 	return url.String(), nil
 }
 
+// GetPresignedUploadURL generates a presigned URL for uploading with PUT
+func (s *MinIOStorageService) GetPresignedUploadURL(ctx context.Context, tenantID, objectKey string, contentType string, expirySeconds int) (string, error) {
+	storagePath := fmt.Sprintf("%s/%s", tenantID, objectKey)
+	expiry := time.Duration(expirySeconds) * time.Second
+
+	// PresignedPutObject can't sign headers, so use PresignHeader to bind the content type
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	url, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucketName, storagePath, expiry, nil, header)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+	return url.String(), nil
+}
+
+// List lists a tenant's objects under prefix. The page token is the last
+// storage path of the previous page.
+func (s *MinIOStorageService) List(ctx context.Context, tenantID, prefix string, pageToken string, limit int) (api.ListResult, error) {
+	if limit <= 0 {
+		limit = api.DefaultListLimit
+	}
+	tenantPrefix := tenantID + "/"
+
+	// Stop the listing goroutine once the page is filled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{
+		Prefix:     tenantPrefix + prefix,
+		Recursive:  true,
+		StartAfter: pageToken,
+		MaxKeys:    limit + 1,
+	})
+
+	var result api.ListResult
+	for obj := range objects {
+		if obj.Err != nil {
+			return api.ListResult{}, fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		// One extra object tells whether another page exists
+		if len(result.Objects) == limit {
+			result.NextPageToken = result.Objects[limit-1].StoragePath
+			break
+		}
+		result.Objects = append(result.Objects, api.ObjectInfo{
+			StoragePath:  obj.Key,
+			Key:          strings.TrimPrefix(obj.Key, tenantPrefix),
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+	}
+	return result, nil
+}
+
+// Copy copies an object within the bucket server-side. The client encodes the
+// copy source header itself.
+func (s *MinIOStorageService) Copy(ctx context.Context, srcPath, dstPath string) error {
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucketName, Object: dstPath},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: srcPath},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
+
+// Move copies an object and then deletes the source
+func (s *MinIOStorageService) Move(ctx context.Context, srcPath, dstPath string) error {
+	if err := s.Copy(ctx, srcPath, dstPath); err != nil {
+		return err
+	}
+	return s.Delete(ctx, srcPath)
+}
+
 // Delete deletes a file from MinIO
 func (s *MinIOStorageService) Delete(ctx context.Context, storagePath string) error {
 	err := s.client.RemoveObject(ctx, s.bucketName, storagePath, minio.RemoveObjectOptions{})
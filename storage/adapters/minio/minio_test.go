@@ -0,0 +1,55 @@
+package minio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/storage/api"
+	"github.com/bignyap/go-utilities/storage/config"
+	"github.com/bignyap/go-utilities/storage/internal/s3test"
+)
+
+const accessDeniedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`
+
+// newTestService returns a service backed by an empty in-memory bucket
+func newTestService(t *testing.T) (*MinIOStorageService, *s3test.Bucket) {
+	t.Helper()
+	bucket, url := s3test.NewBucket(t)
+	svc, err := NewMinIOStorageService(config.MinIOConfig{
+		Endpoint:   strings.TrimPrefix(url, "http://"),
+		AccessKey:  "key",
+		SecretKey:  "secret",
+		BucketName: s3test.BucketName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, bucket
+}
+
+func TestNewMinIOStorageService_VerifyAccessDenied(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(accessDeniedXML))
+	}))
+	defer srv.Close()
+
+	_, err := NewMinIOStorageService(config.MinIOConfig{
+		Endpoint:     strings.TrimPrefix(srv.URL, "http://"),
+		AccessKey:    "bad-key",
+		SecretKey:    "bad-secret",
+		BucketName:   "test-bucket",
+		VerifyAccess: true,
+	})
+	if err == nil {
+		t.Fatal("expected an error for rejected credentials")
+	}
+	if !errors.Is(err, api.ErrAccessDenied) {
+		t.Fatalf("expected ErrAccessDenied, got: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package minio
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// resign recomputes a presigned URL's signature for method, so a match proves
+// which method the URL was signed for
+func resign(t *testing.T, rawURL, method string, header http.Header) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	signingTime, err := time.Parse("20060102T150405Z", q.Get("X-Amz-Date"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Del("X-Amz-Signature")
+	u.RawQuery = q.Encode()
+
+	req, _ := http.NewRequest(method, u.String(), nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	signed, _, err := v4.NewSigner().PresignHTTP(context.Background(),
+		aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+		req, "UNSIGNED-PAYLOAD", "s3", "us-east-1", signingTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	su, _ := url.Parse(signed)
+	return su.Query().Get("X-Amz-Signature")
+}
+
+func TestGetPresignedUploadURL(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	rawURL, err := svc.GetPresignedUploadURL(context.Background(), "tenant-1", "uploads/photo.png", "image/png", 900)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+
+	if u.Path != "/test-bucket/tenant-1/uploads/photo.png" {
+		t.Fatalf("unexpected object path %q", u.Path)
+	}
+	if got := q.Get("X-Amz-Expires"); got != "900" {
+		t.Fatalf("expected 900s expiry, got %q", got)
+	}
+	if got := q.Get("X-Amz-SignedHeaders"); got != "content-type;host" {
+		t.Fatalf("expected the content type to be signed, got %q", got)
+	}
+
+	header := http.Header{"Content-Type": []string{"image/png"}}
+	if resign(t, rawURL, http.MethodPut, header) != q.Get("X-Amz-Signature") {
+		t.Fatal("expected the URL to be signed for PUT")
+	}
+	if resign(t, rawURL, http.MethodGet, header) == q.Get("X-Amz-Signature") {
+		t.Fatal("expected the URL not to be valid for GET")
+	}
+}
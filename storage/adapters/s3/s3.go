@@ -3,14 +3,22 @@ package s3
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/bignyap/go-utilities/storage/api"
 	"github.com/bignyap/go-utilities/storage/config"
 )
@@ -60,13 +68,26 @@ func NewS3StorageService(cfg config.S3Config) (*S3StorageService, error) {
 	client := s3.NewFromConfig(awsCfg, s3Opts...)
 	presignClient := s3.NewPresignClient(client)
 
-	// Check if bucket exists (optional - might fail due to permissions)
-	_, err = client.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(cfg.BucketName),
-	})
-	if err != nil {
-		// Log warning but don't fail - bucket might exist but we may not have HeadBucket permission
-		fmt.Printf("Warning: could not verify bucket existence: %v\n", err)
+	if cfg.EnsureBucket {
+		if err := ensureBucket(ctx, client, cfg.BucketName, cfg.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.VerifyAccess {
+		// Fail fast on bad credentials instead of on the first real operation
+		if err := verifyAccess(ctx, client, cfg.BucketName); err != nil {
+			return nil, err
+		}
+	} else if !cfg.EnsureBucket {
+		// Check if bucket exists (optional - might fail due to permissions)
+		_, err = client.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: aws.String(cfg.BucketName),
+		})
+		if err != nil {
+			// Log warning but don't fail - bucket might exist but we may not have HeadBucket permission
+			fmt.Printf("Warning: could not verify bucket existence: %v\n", err)
+		}
 	}
 
 	return &S3StorageService{
@@ -76,8 +97,95 @@ func NewS3StorageService(cfg config.S3Config) (*S3StorageService, error) {
 	}, nil
 }
 
+// ensureBucket creates the bucket if HeadBucket reports it missing.
+// A bucket that already exists and is owned by the caller is treated as success.
+func ensureBucket(ctx context.Context, client *s3.Client, bucketName, region string) error {
+	_, err := client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to check bucket existence: %w", err)
+	}
+
+	input := &s3.CreateBucketInput{
+		Bucket: aws.String(bucketName),
+	}
+	// us-east-1 is the default location and rejects an explicit LocationConstraint
+	if region != "" && region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(region),
+		}
+	}
+
+	_, err = client.CreateBucket(ctx, input)
+	if err != nil {
+		var owned *types.BucketAlreadyOwnedByYou
+		if errors.As(err, &owned) {
+			return nil
+		}
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 response from S3
+func isNotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var respErr interface{ HTTPStatusCode() int }
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
+
+// verifyAccess issues a ListObjectsV2 with max-keys=0 to check that the
+// credentials are accepted and allowed to list the bucket
+func verifyAccess(ctx context.Context, client *s3.Client, bucketName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucketName),
+		MaxKeys: aws.Int32(0),
+	})
+	if err != nil {
+		return classifyAccessError(err)
+	}
+	return nil
+}
+
+// classifyAccessError maps an S3 error to api.ErrAccessDenied or api.ErrUnreachable
+func classifyAccessError(err error) error {
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return fmt.Errorf("%w: %w", api.ErrAccessDenied, err)
+		}
+		return fmt.Errorf("failed to verify storage access: %w", err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", api.ErrUnreachable, err)
+	}
+	return fmt.Errorf("failed to verify storage access: %w", err)
+}
+
 // Upload uploads a file to S3
 func (s *S3StorageService) Upload(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string) (string, error) {
+	return s.UploadWithOptions(ctx, tenantID, objectKey, data, size, contentType, api.UploadOptions{})
+}
+
+// UploadWithOptions uploads a file to S3 with encryption and metadata
+func (s *S3StorageService) UploadWithOptions(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string, opts api.UploadOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
 	// Create storage path: tenant_id/object_key
 	storagePath := fmt.Sprintf("%s/%s", tenantID, objectKey)
 
@@ -87,13 +195,24 @@ func (s *S3StorageService) Upload(ctx context.Context, tenantID, objectKey strin
 		return "", fmt.Errorf("failed to read data: %w", err)
 	}
 
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket:        aws.String(s.bucketName),
 		Key:           aws.String(storagePath),
 		Body:          bytes.NewReader(buf),
 		ContentType:   aws.String(contentType),
 		ContentLength: aws.Int64(size),
-	})
+		Metadata:      opts.Metadata,
+	}
+	if opts.CacheControl != "" {
+		input.CacheControl = aws.String(opts.CacheControl)
+	}
+	if opts.SSE != "" {
+		input.ServerSideEncryption = types.ServerSideEncryption(opts.SSE)
+	}
+	if opts.KMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(opts.KMSKeyID)
+	}
+	_, err = s.client.PutObject(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload object: %w", err)
 	}
@@ -101,8 +220,24 @@ func (s *S3StorageService) Upload(ctx context.Context, tenantID, objectKey strin
 	return storagePath, nil
 }
 
-// Download downloads a file from S3
+// Download downloads a file from S3 into memory; use DownloadStream for large objects
 func (s *S3StorageService) Download(ctx context.Context, storagePath string) ([]byte, string, error) {
+	body, contentType, err := s.DownloadStream(ctx, storagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// DownloadStream returns the object body from S3. The caller must Close it.
+func (s *S3StorageService) DownloadStream(ctx context.Context, storagePath string) (io.ReadCloser, string, error) {
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(storagePath),
@@ -110,21 +245,50 @@ func (s *S3StorageService) Download(ctx context.Context, storagePath string) ([]
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to get object: %w", err)
 	}
-	defer result.Body.Close()
-
-	data, err := io.ReadAll(result.Body)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to read object: %w", err)
-	}
 
 	contentType := ""
 	if result.ContentType != nil {
 		contentType = *result.ContentType
 	}
 
+	return result.Body, contentType, nil
+}
+
+// DownloadRange downloads a byte range of a file from S3
+func (s *S3StorageService) DownloadRange(ctx context.Context, storagePath string, start, end int64) ([]byte, string, error) {
+	body, contentType, err := s.DownloadRangeStream(ctx, storagePath, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object range: %w", err)
+	}
+
 	return data, contentType, nil
 }
 
+// DownloadRangeStream returns a byte range of the object body from S3.
+// The caller must Close it.
+func (s *S3StorageService) DownloadRangeStream(ctx context.Context, storagePath string, start, end int64) (io.ReadCloser, string, error) {
+	if err := api.ValidateRange(start, end); err != nil {
+		return nil, "", err
+	}
+
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(storagePath),
+		Range:  aws.String(api.RangeHeader(start, end)),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object range: %w", err)
+	}
+
+	return result.Body, aws.ToString(result.ContentType), nil
+}
+
 // GetPresignedURL generates a presigned URL for downloading
 func (s *S3StorageService) GetPresignedURL(ctx context.Context, storagePath string, expirySeconds int) (string, error) {
 	result, err := s.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
@@ -137,6 +301,110 @@ func (s *S3StorageService) GetPresignedURL(ctx context.Context, storagePath stri
 	return result.URL, nil
 }
 
+// GetPresignedUploadURL generates a presigned URL for uploading with PUT
+func (s *S3StorageService) GetPresignedUploadURL(ctx context.Context, tenantID, objectKey string, contentType string, expirySeconds int) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(fmt.Sprintf("%s/%s", tenantID, objectKey)),
+	}
+	optFns := []func(*s3.PresignOptions){s3.WithPresignExpires(time.Duration(expirySeconds) * time.Second)}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+		optFns = append(optFns, signContentType(contentType))
+	}
+	result, err := s.presignClient.PresignPutObject(ctx, input, optFns...)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+	return result.URL, nil
+}
+
+// signContentType restores the Content-Type header that PresignPutObject
+// strips, so the presigned URL only accepts uploads of that type
+func signContentType(contentType string) func(*s3.PresignOptions) {
+	return func(o *s3.PresignOptions) {
+		o.ClientOptions = append(o.ClientOptions, func(opts *s3.Options) {
+			opts.APIOptions = append(opts.APIOptions, func(stack *middleware.Stack) error {
+				return stack.Build.Add(middleware.BuildMiddlewareFunc("SignContentType",
+					func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+						if req, ok := in.Request.(*smithyhttp.Request); ok {
+							req.Header.Set("Content-Type", contentType)
+						}
+						return next.HandleBuild(ctx, in)
+					}), middleware.After)
+			})
+		})
+	}
+}
+
+// List lists a tenant's objects under prefix using S3 continuation tokens
+func (s *S3StorageService) List(ctx context.Context, tenantID, prefix string, pageToken string, limit int) (api.ListResult, error) {
+	if limit <= 0 {
+		limit = api.DefaultListLimit
+	}
+	tenantPrefix := tenantID + "/"
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucketName),
+		Prefix:  aws.String(tenantPrefix + prefix),
+		MaxKeys: aws.Int32(int32(limit)),
+	}
+	if pageToken != "" {
+		input.ContinuationToken = aws.String(pageToken)
+	}
+	out, err := s.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return api.ListResult{}, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	result := api.ListResult{Objects: make([]api.ObjectInfo, 0, len(out.Contents))}
+	for _, obj := range out.Contents {
+		key := aws.ToString(obj.Key)
+		result.Objects = append(result.Objects, api.ObjectInfo{
+			StoragePath:  key,
+			Key:          strings.TrimPrefix(key, tenantPrefix),
+			Size:         aws.ToInt64(obj.Size),
+			LastModified: aws.ToTime(obj.LastModified),
+		})
+	}
+	if aws.ToBool(out.IsTruncated) {
+		result.NextPageToken = aws.ToString(out.NextContinuationToken)
+	}
+	return result, nil
+}
+
+// Copy copies an object within the bucket using CopyObject (up to 5 GB)
+func (s *S3StorageService) Copy(ctx context.Context, srcPath, dstPath string) error {
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucketName),
+		Key:        aws.String(dstPath),
+		CopySource: aws.String(copySource(s.bucketName, srcPath)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
+
+// Move copies an object and then deletes the source
+func (s *S3StorageService) Move(ctx context.Context, srcPath, dstPath string) error {
+	if err := s.Copy(ctx, srcPath, dstPath); err != nil {
+		return err
+	}
+	return s.Delete(ctx, srcPath)
+}
+
+// copySource formats the CopySource parameter, which unlike Key is sent
+// as-is in a header and must be URL-encoded by the caller. '+' is encoded
+// too, since S3 would otherwise read it as a space.
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = strings.ReplaceAll(url.QueryEscape(seg), "+", "%20")
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
+
 // Delete deletes a file from S3
 func (s *S3StorageService) Delete(ctx context.Context, storagePath string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -0,0 +1,194 @@
+package s3
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bignyap/go-utilities/storage/api"
+	"github.com/bignyap/go-utilities/storage/config"
+	"github.com/bignyap/go-utilities/storage/internal/s3test"
+)
+
+const accessDeniedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`
+
+// newTestService returns a service backed by an empty in-memory bucket
+func newTestService(t *testing.T) (*S3StorageService, *s3test.Bucket) {
+	t.Helper()
+	bucket, url := s3test.NewBucket(t)
+	svc, err := NewS3StorageService(config.S3Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		BucketName:      s3test.BucketName,
+		Endpoint:        url,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, bucket
+}
+
+func TestNewS3StorageService_VerifyAccessDenied(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(accessDeniedXML))
+	}))
+	defer srv.Close()
+
+	_, err := NewS3StorageService(config.S3Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "bad-key",
+		SecretAccessKey: "bad-secret",
+		BucketName:      "test-bucket",
+		Endpoint:        srv.URL,
+		VerifyAccess:    true,
+	})
+	if err == nil {
+		t.Fatal("expected an error for rejected credentials")
+	}
+	if !errors.Is(err, api.ErrAccessDenied) {
+		t.Fatalf("expected ErrAccessDenied, got: %v", err)
+	}
+}
+
+func TestNewS3StorageService_VerifyAccessOK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult><Name>test-bucket</Name><KeyCount>0</KeyCount><MaxKeys>0</MaxKeys><IsTruncated>false</IsTruncated></ListBucketResult>`))
+	}))
+	defer srv.Close()
+
+	svc, err := NewS3StorageService(config.S3Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		BucketName:      "test-bucket",
+		Endpoint:        srv.URL,
+		VerifyAccess:    true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc == nil {
+		t.Fatal("expected a service")
+	}
+}
+
+// bucketStub answers HeadBucket with headStatus and records CreateBucket requests
+type bucketStub struct {
+	headStatus   int
+	createStatus int
+	createBody   string
+
+	mu      sync.Mutex
+	creates []string
+}
+
+func (b *bucketStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/xml")
+	switch r.Method {
+	case http.MethodHead:
+		w.WriteHeader(b.headStatus)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		b.mu.Lock()
+		b.creates = append(b.creates, string(body))
+		b.mu.Unlock()
+		if b.createStatus != 0 {
+			w.WriteHeader(b.createStatus)
+			_, _ = w.Write([]byte(b.createBody))
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (b *bucketStub) createRequests() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.creates...)
+}
+
+func newEnsureBucketService(t *testing.T, stub *bucketStub, region string) error {
+	t.Helper()
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	_, err := NewS3StorageService(config.S3Config{
+		Region:          region,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		BucketName:      "test-bucket",
+		Endpoint:        srv.URL,
+		EnsureBucket:    true,
+	})
+	return err
+}
+
+func TestEnsureBucket_ExistingBucketNotCreated(t *testing.T) {
+	stub := &bucketStub{headStatus: http.StatusOK}
+	if err := newEnsureBucketService(t, stub, "eu-west-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creates := stub.createRequests(); len(creates) != 0 {
+		t.Fatalf("expected no CreateBucket call, got %d", len(creates))
+	}
+}
+
+func TestEnsureBucket_CreatesWithLocationConstraint(t *testing.T) {
+	stub := &bucketStub{headStatus: http.StatusNotFound}
+	if err := newEnsureBucketService(t, stub, "eu-west-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	creates := stub.createRequests()
+	if len(creates) != 1 {
+		t.Fatalf("expected 1 CreateBucket call, got %d", len(creates))
+	}
+	if !strings.Contains(creates[0], "<LocationConstraint>eu-west-1</LocationConstraint>") {
+		t.Fatalf("expected eu-west-1 location constraint, got body %q", creates[0])
+	}
+}
+
+func TestEnsureBucket_UsEast1OmitsLocationConstraint(t *testing.T) {
+	stub := &bucketStub{headStatus: http.StatusNotFound}
+	if err := newEnsureBucketService(t, stub, "us-east-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	creates := stub.createRequests()
+	if len(creates) != 1 {
+		t.Fatalf("expected 1 CreateBucket call, got %d", len(creates))
+	}
+	if strings.Contains(creates[0], "LocationConstraint") {
+		t.Fatalf("us-east-1 must not send a location constraint, got body %q", creates[0])
+	}
+}
+
+func TestEnsureBucket_AlreadyOwnedByYou(t *testing.T) {
+	stub := &bucketStub{
+		headStatus:   http.StatusNotFound,
+		createStatus: http.StatusConflict,
+		createBody: `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>BucketAlreadyOwnedByYou</Code><Message>Your previous request to create the named bucket succeeded and you already own it.</Message></Error>`,
+	}
+	if err := newEnsureBucketService(t, stub, "eu-west-1"); err != nil {
+		t.Fatalf("expected BucketAlreadyOwnedByYou to be treated as success, got: %v", err)
+	}
+}
+
+func TestEnsureBucket_HeadBucketForbidden(t *testing.T) {
+	stub := &bucketStub{headStatus: http.StatusForbidden}
+	if err := newEnsureBucketService(t, stub, "eu-west-1"); err == nil {
+		t.Fatal("expected an error when HeadBucket is forbidden")
+	}
+	if creates := stub.createRequests(); len(creates) != 0 {
+		t.Fatalf("expected no CreateBucket call, got %d", len(creates))
+	}
+}
@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/storage/api"
+)
+
+func TestUploadWithOptions_PassesEncryptionAndMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    api.UploadOptions
+		headers map[string]string
+	}{
+		{
+			name: "SSE-KMS with key and metadata",
+			opts: api.UploadOptions{
+				SSE:          api.SSEKMS,
+				KMSKeyID:     "arn:aws:kms:us-east-1:123:key/abc",
+				Metadata:     map[string]string{"owner": "billing", "retention": "7y"},
+				CacheControl: "private, max-age=60",
+			},
+			headers: map[string]string{
+				"X-Amz-Server-Side-Encryption":                "aws:kms",
+				"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "arn:aws:kms:us-east-1:123:key/abc",
+				"X-Amz-Meta-Owner":                            "billing",
+				"X-Amz-Meta-Retention":                        "7y",
+				"Cache-Control":                               "private, max-age=60",
+			},
+		},
+		{
+			name: "SSE-S3",
+			opts: api.UploadOptions{SSE: api.SSES3},
+			headers: map[string]string{
+				"X-Amz-Server-Side-Encryption":                "AES256",
+				"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "",
+			},
+		},
+		{
+			name: "defaults",
+			headers: map[string]string{
+				"X-Amz-Server-Side-Encryption": "",
+				"Cache-Control":                "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, bucket := newTestService(t)
+			data := "%PDF-1.7 report"
+
+			path, err := svc.UploadWithOptions(context.Background(), "tenant-1", "reports/q1.pdf",
+				strings.NewReader(data), int64(len(data)), "application/pdf", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			put := bucket.LastPut()
+			if path != "tenant-1/reports/q1.pdf" || put.Path != "/test-bucket/tenant-1/reports/q1.pdf" {
+				t.Fatalf("unexpected storage path %q (request path %q)", path, put.Path)
+			}
+			if got := put.Header.Get("Content-Type"); got != "application/pdf" {
+				t.Fatalf("expected application/pdf, got %q", got)
+			}
+			for name, want := range tt.headers {
+				if got := put.Header.Get(name); got != want {
+					t.Fatalf("header %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadWithOptions_RejectsInvalidEncryption(t *testing.T) {
+	svc, bucket := newTestService(t)
+
+	for _, opts := range []api.UploadOptions{
+		{SSE: "rot13"},
+		{KMSKeyID: "key-without-kms"},
+		{SSE: api.SSES3, KMSKeyID: "key-with-s3"},
+	} {
+		_, err := svc.UploadWithOptions(context.Background(), "tenant-1", "a.txt", strings.NewReader("x"), 1, "text/plain", opts)
+		if !errors.Is(err, api.ErrInvalidUploadOptions) {
+			t.Fatalf("options %+v: expected ErrInvalidUploadOptions, got %v", opts, err)
+		}
+	}
+	if bucket.LastPut() != nil {
+		t.Fatal("expected no upload for invalid options")
+	}
+}
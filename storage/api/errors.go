@@ -0,0 +1,36 @@
+package api
+
+import "errors"
+
+var (
+	// ErrAccessDenied is returned when the storage backend rejects the configured
+	// credentials or the credentials lack permission on the bucket
+	ErrAccessDenied = errors.New("storage access denied")
+
+	// ErrUnreachable is returned when the storage backend cannot be reached
+	ErrUnreachable = errors.New("storage endpoint unreachable")
+)
+
+var (
+	// ErrInvalidSignature is returned when a signed download URL is malformed
+	// or its signature does not match
+	ErrInvalidSignature = errors.New("invalid download URL signature")
+
+	// ErrURLExpired is returned when a signed download URL is past its expiry
+	ErrURLExpired = errors.New("download URL expired")
+
+	// ErrInvalidRange is returned when a byte range has a negative start or
+	// a start past its end
+	ErrInvalidRange = errors.New("invalid byte range")
+
+	// ErrInvalidUploadOptions is returned when UploadOptions has an unknown
+	// SSE mode or a KMS key without SSE-KMS
+	ErrInvalidUploadOptions = errors.New("invalid upload options")
+
+	// ErrInvalidPath is returned when a storage path is absolute or escapes
+	// the tenant directory, e.g. with "../"
+	ErrInvalidPath = errors.New("invalid storage path")
+
+	// ErrNotSupported is returned for operations a backend cannot provide
+	ErrNotSupported = errors.New("operation not supported by storage backend")
+)
@@ -2,26 +2,111 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"time"
 )
 
 // StorageService interface for object storage operations
-// Implementations: MinIO, AWS S3
+// Implementations: MinIO, AWS S3, local filesystem
 type StorageService interface {
 	// Upload uploads a file to storage
 	// Returns the storage path (tenant_id/object_key)
 	Upload(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string) (storagePath string, err error)
 
+	// UploadWithOptions is Upload with server-side encryption, user metadata
+	// and cache control
+	UploadWithOptions(ctx context.Context, tenantID, objectKey string, data io.Reader, size int64, contentType string, opts UploadOptions) (storagePath string, err error)
+
 	// Download downloads a file from storage
 	// Returns the file data and content type
 	Download(ctx context.Context, storagePath string) (data []byte, contentType string, err error)
 
+	// DownloadStream returns the object body without buffering it, for large objects.
+	// The caller must Close the reader.
+	DownloadStream(ctx context.Context, storagePath string) (body io.ReadCloser, contentType string, err error)
+
+	// DownloadRange downloads the inclusive byte range [start, end] of a file.
+	// A negative end reads to the end of the file.
+	DownloadRange(ctx context.Context, storagePath string, start, end int64) (data []byte, contentType string, err error)
+
+	// DownloadRangeStream is the streaming variant of DownloadRange.
+	// The caller must Close the reader.
+	DownloadRangeStream(ctx context.Context, storagePath string, start, end int64) (body io.ReadCloser, contentType string, err error)
+
 	// GetPresignedURL generates a presigned URL for downloading
 	// The URL expires after expirySeconds
 	GetPresignedURL(ctx context.Context, storagePath string, expirySeconds int) (url string, err error)
 
+	// GetPresignedUploadURL generates a presigned PUT URL for uploading to
+	// tenant_id/object_key. When contentType is set it is signed, so the client
+	// must send the same Content-Type header. The URL expires after expirySeconds
+	GetPresignedUploadURL(ctx context.Context, tenantID, objectKey string, contentType string, expirySeconds int) (url string, err error)
+
 	// Delete deletes a file from storage
 	Delete(ctx context.Context, storagePath string) error
+
+	// Copy copies a file server-side, without streaming it through the caller
+	Copy(ctx context.Context, srcPath, dstPath string) error
+
+	// Move copies a file server-side and then deletes the source
+	Move(ctx context.Context, srcPath, dstPath string) error
+
+	// List returns up to limit of a tenant's objects whose keys start with prefix.
+	// Pass the previous result's NextPageToken to fetch the next page.
+	List(ctx context.Context, tenantID, prefix string, pageToken string, limit int) (ListResult, error)
+}
+
+// Server-side encryption modes for UploadOptions.SSE
+const (
+	// SSES3 encrypts with keys managed by the storage backend
+	SSES3 = "AES256"
+	// SSEKMS encrypts with a KMS key, the backend default unless KMSKeyID is set
+	SSEKMS = "aws:kms"
+)
+
+// UploadOptions holds optional upload settings. The zero value uploads
+// without encryption or metadata.
+type UploadOptions struct {
+	// SSE is empty, SSES3 or SSEKMS
+	SSE string
+	// KMSKeyID selects the key for SSEKMS
+	KMSKeyID     string
+	Metadata     map[string]string
+	CacheControl string
+}
+
+// Validate checks the encryption settings
+func (o UploadOptions) Validate() error {
+	switch o.SSE {
+	case "", SSES3:
+		if o.KMSKeyID != "" {
+			return fmt.Errorf("%w: KMS key ID requires SSE %q", ErrInvalidUploadOptions, SSEKMS)
+		}
+	case SSEKMS:
+	default:
+		return fmt.Errorf("%w: unsupported SSE %q", ErrInvalidUploadOptions, o.SSE)
+	}
+	return nil
+}
+
+// DefaultListLimit is used when List is called with a non-positive limit
+const DefaultListLimit = 1000
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	// StoragePath is the full path (tenant_id/object_key) accepted by Download and Delete
+	StoragePath  string
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ListResult is one page of List results
+type ListResult struct {
+	Objects []ObjectInfo
+	// NextPageToken is empty on the last page
+	NextPageToken string
 }
 
 // StorageType represents the type of storage backend
@@ -30,5 +115,6 @@ type StorageType string
 const (
 	StorageTypeMinio StorageType = "minio"
 	StorageTypeS3    StorageType = "s3"
+	StorageTypeFS    StorageType = "fs"
 )
 
@@ -0,0 +1,20 @@
+package api
+
+import "fmt"
+
+// ValidateRange checks an inclusive byte range. A negative end means the
+// range runs to the end of the object.
+func ValidateRange(start, end int64) error {
+	if start < 0 || (end >= 0 && start > end) {
+		return fmt.Errorf("%w: start=%d end=%d", ErrInvalidRange, start, end)
+	}
+	return nil
+}
+
+// RangeHeader formats an inclusive byte range as an HTTP Range header value
+func RangeHeader(start, end int64) string {
+	if end < 0 {
+		return fmt.Sprintf("bytes=%d-", start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end)
+}
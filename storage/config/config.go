@@ -9,11 +9,12 @@ import (
 
 // MinIOConfig holds MinIO connection configuration
 type MinIOConfig struct {
-	Endpoint   string
-	AccessKey  string
-	SecretKey  string
-	BucketName string
-	UseSSL     bool
+	Endpoint     string
+	AccessKey    string
+	SecretKey    string
+	BucketName   string
+	UseSSL       bool
+	VerifyAccess bool // Optional: check credentials/permissions when the service is created
 }
 
 // S3Config holds AWS S3 connection configuration
@@ -23,16 +24,26 @@ type S3Config struct {
 	SecretAccessKey string
 	BucketName      string
 	Endpoint        string // Optional: for S3-compatible services
+	VerifyAccess    bool   // Optional: check credentials/permissions when the service is created
+	EnsureBucket    bool   // Optional: create the bucket when the service is created if it is missing
+}
+
+// FSConfig holds local filesystem storage configuration
+type FSConfig struct {
+	BaseDir       string
+	BaseURL       string // Optional: where the adapter's Handler is mounted, for presigned URLs
+	SigningSecret string // Optional: HMAC secret for presigned URLs
 }
 
 // LoadMinIOConfig loads MinIO configuration from environment variables
 func LoadMinIOConfig() MinIOConfig {
 	return MinIOConfig{
-		Endpoint:   getEnvOrDefault("MINIO_ENDPOINT", "localhost:9000"),
-		AccessKey:  getEnvOrDefault("MINIO_ACCESS_KEY", "minioadmin"),
-		SecretKey:  getEnvOrDefault("MINIO_SECRET_KEY", "minioadmin"),
-		BucketName: getEnvOrDefault("MINIO_BUCKET", "kgb-messaging"),
-		UseSSL:     getEnvOrDefault("MINIO_USE_SSL", "false") == "true",
+		Endpoint:     getEnvOrDefault("MINIO_ENDPOINT", "localhost:9000"),
+		AccessKey:    getEnvOrDefault("MINIO_ACCESS_KEY", "minioadmin"),
+		SecretKey:    getEnvOrDefault("MINIO_SECRET_KEY", "minioadmin"),
+		BucketName:   getEnvOrDefault("MINIO_BUCKET", "kgb-messaging"),
+		UseSSL:       getEnvOrDefault("MINIO_USE_SSL", "false") == "true",
+		VerifyAccess: getEnvOrDefault("MINIO_VERIFY_ACCESS", "false") == "true",
 	}
 }
 
@@ -44,6 +55,17 @@ func LoadS3Config() S3Config {
 		SecretAccessKey: getEnvOrDefault("AWS_SECRET_ACCESS_KEY", ""),
 		BucketName:      getEnvOrDefault("S3_BUCKET", "kgb-messaging"),
 		Endpoint:        getEnvOrDefault("S3_ENDPOINT", ""), // Optional custom endpoint
+		VerifyAccess:    getEnvOrDefault("S3_VERIFY_ACCESS", "false") == "true",
+		EnsureBucket:    getEnvOrDefault("S3_ENSURE_BUCKET", "false") == "true",
+	}
+}
+
+// LoadFSConfig loads filesystem storage configuration from environment variables
+func LoadFSConfig() FSConfig {
+	return FSConfig{
+		BaseDir:       getEnvOrDefault("FS_BASE_DIR", "./data/storage"),
+		BaseURL:       getEnvOrDefault("FS_BASE_URL", ""),
+		SigningSecret: getEnvOrDefault("FS_SIGNING_SECRET", ""),
 	}
 }
 
@@ -59,4 +81,3 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
-
@@ -2,15 +2,24 @@ package factory
 
 import (
 	"fmt"
+	"sync"
 
+	fsadapter "github.com/bignyap/go-utilities/storage/adapters/fs"
 	minioadapter "github.com/bignyap/go-utilities/storage/adapters/minio"
 	s3adapter "github.com/bignyap/go-utilities/storage/adapters/s3"
 	"github.com/bignyap/go-utilities/storage/api"
 	"github.com/bignyap/go-utilities/storage/config"
 )
 
+var (
+	globalService     api.StorageService
+	globalServiceErr  error
+	globalServiceOnce sync.Once
+	globalServiceMu   sync.RWMutex
+)
+
 // NewStorageService creates a storage service based on the STORAGE_TYPE environment variable
-// Supported types: "minio" (default), "s3"
+// Supported types: "minio" (default), "s3", "fs"
 func NewStorageService() (api.StorageService, error) {
 	storageType := config.GetStorageType()
 	return NewStorageServiceWithType(storageType)
@@ -28,8 +37,75 @@ func NewStorageServiceWithType(storageType api.StorageType) (api.StorageService,
 		cfg := config.LoadS3Config()
 		return s3adapter.NewS3StorageService(cfg)
 
+	case api.StorageTypeFS:
+		cfg := config.LoadFSConfig()
+		return fsadapter.NewFSStorageService(cfg)
+
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s (supported: minio, s3, fs)", storageType)
+	}
+}
+
+// NewStorageServiceWithConfig creates a storage service of a specific type with explicit configuration.
+// cfg must be the config type matching storageType: config.MinIOConfig, config.S3Config or config.FSConfig
+func NewStorageServiceWithConfig(storageType api.StorageType, cfg interface{}) (api.StorageService, error) {
+	switch storageType {
+	case api.StorageTypeMinio:
+		minioCfg, ok := cfg.(config.MinIOConfig)
+		if !ok {
+			return nil, fmt.Errorf("invalid config type for minio storage: %T", cfg)
+		}
+		return minioadapter.NewMinIOStorageService(minioCfg)
+
+	case api.StorageTypeS3:
+		s3Cfg, ok := cfg.(config.S3Config)
+		if !ok {
+			return nil, fmt.Errorf("invalid config type for s3 storage: %T", cfg)
+		}
+		return s3adapter.NewS3StorageService(s3Cfg)
+
+	case api.StorageTypeFS:
+		fsCfg, ok := cfg.(config.FSConfig)
+		if !ok {
+			return nil, fmt.Errorf("invalid config type for fs storage: %T", cfg)
+		}
+		return fsadapter.NewFSStorageService(fsCfg)
+
 	default:
-		return nil, fmt.Errorf("unsupported storage type: %s (supported: minio, s3)", storageType)
+		return nil, fmt.Errorf("unsupported storage type: %s (supported: minio, s3, fs)", storageType)
+	}
+}
+
+// GetGlobalService returns the global storage service, creating it from the environment on first use.
+// There is no fallback backend, so a creation error is returned on every call until Reset
+func GetGlobalService() (api.StorageService, error) {
+	globalServiceOnce.Do(func() {
+		service, err := NewStorageService()
+		globalServiceMu.Lock()
+		globalService, globalServiceErr = service, err
+		globalServiceMu.Unlock()
+	})
+
+	globalServiceMu.RLock()
+	defer globalServiceMu.RUnlock()
+	return globalService, globalServiceErr
+}
+
+// SetGlobalService replaces the global storage service with the provided instance
+func SetGlobalService(service api.StorageService) {
+	if service != nil {
+		// Mark the lazy initialisation as done so it cannot overwrite service
+		globalServiceOnce.Do(func() {})
+		globalServiceMu.Lock()
+		globalService, globalServiceErr = service, nil
+		globalServiceMu.Unlock()
 	}
 }
 
+// Reset resets the global service to nil, forcing recreation on next call
+func Reset() {
+	globalServiceMu.Lock()
+	globalService, globalServiceErr = nil, nil
+	globalServiceMu.Unlock()
+	globalServiceOnce = sync.Once{}
+}
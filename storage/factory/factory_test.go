@@ -0,0 +1,91 @@
+package factory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	minioadapter "github.com/bignyap/go-utilities/storage/adapters/minio"
+	"github.com/bignyap/go-utilities/storage/api"
+	"github.com/bignyap/go-utilities/storage/config"
+)
+
+// newFakeMinIO serves just enough of the S3 API for the MinIO constructor's bucket check
+func newFakeMinIO(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["location"]; ok {
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestNewStorageService_MinIOFromEnv(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "minio")
+	t.Setenv("MINIO_ENDPOINT", newFakeMinIO(t))
+
+	svc, err := NewStorageService()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := svc.(*minioadapter.MinIOStorageService); !ok {
+		t.Fatalf("expected a MinIO service, got %T", svc)
+	}
+}
+
+func TestNewStorageService_UnknownType(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "tape")
+
+	_, err := NewStorageService()
+	if err == nil || !strings.Contains(err.Error(), "unsupported storage type: tape") {
+		t.Fatalf("expected an unsupported type error, got %v", err)
+	}
+}
+
+func TestNewStorageServiceWithConfig(t *testing.T) {
+	svc, err := NewStorageServiceWithConfig(api.StorageTypeMinio, config.MinIOConfig{
+		Endpoint:   newFakeMinIO(t),
+		BucketName: "test-bucket",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := svc.(*minioadapter.MinIOStorageService); !ok {
+		t.Fatalf("expected a MinIO service, got %T", svc)
+	}
+
+	if _, err := NewStorageServiceWithConfig(api.StorageTypeMinio, config.S3Config{}); err == nil {
+		t.Fatal("expected an error for a mismatched config type")
+	}
+	if _, err := NewStorageServiceWithConfig("tape", nil); err == nil {
+		t.Fatal("expected an error for an unknown storage type")
+	}
+}
+
+func TestGetGlobalService(t *testing.T) {
+	t.Cleanup(Reset)
+
+	t.Setenv("STORAGE_TYPE", "tape")
+	Reset()
+	if _, err := GetGlobalService(); err == nil {
+		t.Fatal("expected the global service to report the creation error")
+	}
+
+	t.Setenv("STORAGE_TYPE", "fs")
+	t.Setenv("FS_BASE_DIR", t.TempDir())
+	Reset()
+	first, err := GetGlobalService()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := GetGlobalService()
+	if first != second {
+		t.Fatal("expected the global service to be created once")
+	}
+}
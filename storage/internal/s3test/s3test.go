@@ -0,0 +1,224 @@
+// Package s3test provides an in-memory S3 server shared by the S3 and MinIO
+// adapter tests. It speaks enough of the path-style S3 API for the adapters:
+// bucket location and existence checks, object PUT (including server-side
+// copies), GET and HEAD with byte ranges, DELETE and ListObjectsV2.
+package s3test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BucketName is the only bucket the server knows
+const BucketName = "test-bucket"
+
+// LastModified is reported for every object
+var LastModified = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Put is a recorded object upload
+type Put struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Bucket is an in-memory bucket served over HTTP
+type Bucket struct {
+	mu      sync.Mutex
+	objects map[string]object
+	copies  int
+	lastPut *Put
+}
+
+// NewBucket starts a server for an empty bucket that is closed when the test
+// ends, and returns the bucket along with the server URL
+func NewBucket(t testing.TB) (*Bucket, string) {
+	t.Helper()
+	b := &Bucket{objects: make(map[string]object)}
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return b, srv.URL
+}
+
+// PutObject stores data under key
+func (b *Bucket) PutObject(key string, data []byte, contentType string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = object{data: data, contentType: contentType}
+}
+
+// Object returns the data stored under key
+func (b *Bucket) Object(key string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	obj, ok := b.objects[key]
+	return obj.data, ok
+}
+
+// Copies returns the number of server-side copies made
+func (b *Bucket) Copies() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.copies
+}
+
+// LastPut returns the most recent upload, or nil if there was none
+func (b *Bucket) LastPut() *Put {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastPut
+}
+
+func (b *Bucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := r.URL.Query()
+	if q.Has("location") {
+		writeXML(w, http.StatusOK, `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+BucketName+"/")
+	if !ok {
+		if r.URL.Path != "/"+BucketName {
+			writeXML(w, http.StatusNotFound, `<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist.</Message></Error>`)
+			return
+		}
+		key = ""
+	}
+	if key == "" {
+		if q.Get("list-type") == "2" {
+			b.list(w, q)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			b.copy(w, key, src)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		b.objects[key] = object{data: body, contentType: r.Header.Get("Content-Type")}
+		b.lastPut = &Put{Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, found := b.objects[key]
+		if !found {
+			writeNoSuchKey(w)
+			return
+		}
+		contentType := obj.contentType
+		if contentType == "" {
+			contentType = "binary/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", `"etag"`)
+		// ServeContent answers Range requests with 206 and Content-Range
+		http.ServeContent(w, r, "", LastModified, bytes.NewReader(obj.data))
+	case http.MethodDelete:
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (b *Bucket) copy(w http.ResponseWriter, key, src string) {
+	src, _ = url.PathUnescape(src)
+	obj, found := b.objects[strings.TrimPrefix(strings.TrimPrefix(src, "/"), BucketName+"/")]
+	if !found {
+		writeNoSuchKey(w)
+		return
+	}
+	b.copies++
+	b.objects[key] = object{data: slices.Clone(obj.data), contentType: obj.contentType}
+	writeXML(w, http.StatusOK, `<CopyObjectResult><ETag>"etag"</ETag><LastModified>2024-01-01T00:00:00.000Z</LastModified></CopyObjectResult>`)
+}
+
+type listContents struct {
+	Key          string
+	Size         int64
+	LastModified string
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string
+	Prefix                string
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	NextContinuationToken string `xml:",omitempty"`
+	Contents              []listContents
+}
+
+// list answers ListObjectsV2 in key order. Continuation tokens are the last
+// key of the previous page.
+func (b *Bucket) list(w http.ResponseWriter, q url.Values) {
+	prefix := q.Get("prefix")
+	after := q.Get("start-after")
+	if token := q.Get("continuation-token"); token != "" {
+		after = token
+	}
+	maxKeys := 1000
+	if v, err := strconv.Atoi(q.Get("max-keys")); err == nil && v > 0 {
+		maxKeys = v
+	}
+
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	res := listBucketResult{Name: BucketName, Prefix: prefix, MaxKeys: maxKeys}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= after {
+			continue
+		}
+		if len(res.Contents) == maxKeys {
+			res.IsTruncated = true
+			res.NextContinuationToken = res.Contents[maxKeys-1].Key
+			break
+		}
+		res.Contents = append(res.Contents, listContents{
+			Key:          key,
+			Size:         int64(len(b.objects[key].data)),
+			LastModified: LastModified.Format("2006-01-02T15:04:05.000Z"),
+		})
+	}
+	res.KeyCount = len(res.Contents)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func writeNoSuchKey(w http.ResponseWriter) {
+	writeXML(w, http.StatusNotFound, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+}
+
+func writeXML(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+body)
+}
@@ -0,0 +1,81 @@
+// Package storage provides helpers shared by the storage backends
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bignyap/go-utilities/storage/api"
+)
+
+// Query parameters carried by signed download URLs
+const (
+	ParamPath      = "path"
+	ParamExpires   = "expires"
+	ParamSignature = "signature"
+)
+
+// SignDownloadURL returns baseURL with the storage path, an expiry timestamp
+// and an HMAC-SHA256 signature over both. Unlike GetPresignedURL the URL
+// points at the service, which checks it with VerifyDownloadURL and streams
+// the object itself, so the bucket is never exposed.
+func SignDownloadURL(baseURL, storagePath string, expiry time.Duration, secret []byte) string {
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+
+	query := url.Values{}
+	query.Set(ParamPath, storagePath)
+	query.Set(ParamExpires, expires)
+	query.Set(ParamSignature, signature(storagePath, expires, secret))
+
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
+	return baseURL + sep + query.Encode()
+}
+
+// VerifyDownloadURL checks a URL produced by SignDownloadURL and returns the
+// storage path it grants access to
+func VerifyDownloadURL(rawURL string, secret []byte) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", api.ErrInvalidSignature, err)
+	}
+	query := u.Query()
+	storagePath := query.Get(ParamPath)
+	expires := query.Get(ParamExpires)
+	if storagePath == "" || expires == "" {
+		return "", fmt.Errorf("%w: missing path or expiry", api.ErrInvalidSignature)
+	}
+
+	got, err := hex.DecodeString(query.Get(ParamSignature))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", api.ErrInvalidSignature, err)
+	}
+	want, _ := hex.DecodeString(signature(storagePath, expires, secret))
+	if !hmac.Equal(got, want) {
+		return "", api.ErrInvalidSignature
+	}
+
+	// The expiry is covered by the signature, so it is only parsed once trusted
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", api.ErrInvalidSignature, err)
+	}
+	if time.Now().Unix() > unix {
+		return "", api.ErrURLExpired
+	}
+	return storagePath, nil
+}
+
+func signature(storagePath, expires string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(storagePath + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
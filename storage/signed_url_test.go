@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/storage/api"
+)
+
+var testSecret = []byte("test-secret")
+
+func TestSignDownloadURL_Verifies(t *testing.T) {
+	signed := SignDownloadURL("https://api.example.com/files/download", "tenant-1/report.pdf", time.Minute, testSecret)
+	if !strings.HasPrefix(signed, "https://api.example.com/files/download?") {
+		t.Fatalf("unexpected URL %q", signed)
+	}
+
+	path, err := VerifyDownloadURL(signed, testSecret)
+	if err != nil {
+		t.Fatalf("expected valid URL, got %v", err)
+	}
+	if path != "tenant-1/report.pdf" {
+		t.Fatalf("unexpected storage path %q", path)
+	}
+}
+
+func TestSignDownloadURL_KeepsExistingQuery(t *testing.T) {
+	signed := SignDownloadURL("https://api.example.com/download?inline=1", "tenant-1/a.png", time.Minute, testSecret)
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("inline") != "1" {
+		t.Fatalf("expected existing query to be kept, got %q", signed)
+	}
+	if _, err := VerifyDownloadURL(signed, testSecret); err != nil {
+		t.Fatalf("expected valid URL, got %v", err)
+	}
+}
+
+func TestVerifyDownloadURL_Expired(t *testing.T) {
+	signed := SignDownloadURL("https://api.example.com/download", "tenant-1/report.pdf", -time.Minute, testSecret)
+	if _, err := VerifyDownloadURL(signed, testSecret); !errors.Is(err, api.ErrURLExpired) {
+		t.Fatalf("expected ErrURLExpired, got %v", err)
+	}
+}
+
+func TestVerifyDownloadURL_Tampered(t *testing.T) {
+	signed := SignDownloadURL("https://api.example.com/download", "tenant-1/report.pdf", time.Minute, testSecret)
+	u, _ := url.Parse(signed)
+
+	tamper := func(key, value string) string {
+		q := u.Query()
+		q.Set(key, value)
+		c := *u
+		c.RawQuery = q.Encode()
+		return c.String()
+	}
+
+	tests := map[string]string{
+		"other path":        tamper(ParamPath, "tenant-2/report.pdf"),
+		"extended expiry":   tamper(ParamExpires, "9999999999"),
+		"altered signature": tamper(ParamSignature, strings.Repeat("0", 64)),
+		"bad signature":     tamper(ParamSignature, "not-hex"),
+		"missing path":      tamper(ParamPath, ""),
+	}
+	for name, raw := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := VerifyDownloadURL(raw, testSecret); !errors.Is(err, api.ErrInvalidSignature) {
+				t.Fatalf("expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+
+	if _, err := VerifyDownloadURL(signed, []byte("other-secret")); !errors.Is(err, api.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for a different secret, got %v", err)
+	}
+}
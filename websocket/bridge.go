@@ -0,0 +1,177 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bignyap/go-utilities/logger/api"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// BroadcastBackend relays hub broadcasts between instances of a service
+type BroadcastBackend interface {
+	// Publish sends payload to every subscribed instance, including this one
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe calls handler for each published payload until ctx is done
+	Subscribe(ctx context.Context, handler func(payload []byte)) error
+}
+
+// WithBroadcastBackend relays Send*/Broadcast* calls through backend so
+// clients connected to other instances receive them too. Call StartBackend
+// to begin delivering messages published by other instances.
+func WithBroadcastBackend(backend BroadcastBackend) HubOption {
+	return func(h *Hub) {
+		h.backend = backend
+	}
+}
+
+// WithInstanceID sets the ID used to ignore this hub's own relayed messages
+// (default: a random UUID)
+func WithInstanceID(id string) HubOption {
+	return func(h *Hub) {
+		h.instanceID = id
+	}
+}
+
+// InstanceID returns the ID this hub stamps on relayed messages
+func (h *Hub) InstanceID() string {
+	return h.instanceID
+}
+
+type relayTarget string
+
+const (
+	relayUser        relayTarget = "user"
+	relayGroup       relayTarget = "group"
+	relayGroupExcept relayTarget = "group_except"
+	relayTenant      relayTarget = "tenant"
+	relayAll         relayTarget = "all"
+)
+
+// relayEnvelope is the wire format for relayed broadcasts
+type relayEnvelope struct {
+	InstanceID string      `json:"instance_id"`
+	Target     relayTarget `json:"target"`
+	ID         string      `json:"id,omitempty"`
+	ExcludeID  string      `json:"exclude_id,omitempty"`
+	Binary     bool        `json:"binary,omitempty"`
+	Data       []byte      `json:"data"`
+}
+
+// relay publishes a broadcast to other instances. Failures are logged, since
+// local delivery has already happened.
+func (h *Hub) relay(target relayTarget, id, excludeID string, message outboundMessage) {
+	if h.backend == nil {
+		return
+	}
+	payload, err := json.Marshal(relayEnvelope{
+		InstanceID: h.instanceID,
+		Target:     target,
+		ID:         id,
+		ExcludeID:  excludeID,
+		Binary:     message.messageType == websocket.BinaryMessage,
+		Data:       message.data,
+	})
+	if err != nil {
+		h.logger.Error(context.Background(), "Failed to encode relayed message", err)
+		return
+	}
+	if err := h.backend.Publish(context.Background(), payload); err != nil {
+		h.logger.Error(context.Background(), "Failed to relay message", err,
+			api.String("target", string(target)),
+		)
+	}
+}
+
+// StartBackend subscribes to the broadcast backend and delivers messages
+// published by other instances to local clients until ctx is done
+func (h *Hub) StartBackend(ctx context.Context) error {
+	if h.backend == nil {
+		return fmt.Errorf("websocket: no broadcast backend configured")
+	}
+	return h.backend.Subscribe(ctx, h.deliverRelayed)
+}
+
+func (h *Hub) deliverRelayed(payload []byte) {
+	var env relayEnvelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		h.logger.Error(context.Background(), "Failed to decode relayed message", err)
+		return
+	}
+	// Local clients were already served when the message was sent
+	if env.InstanceID == h.instanceID {
+		return
+	}
+
+	message := textMessage(env.Data)
+	if env.Binary {
+		message = binaryMessage(env.Data)
+	}
+	switch env.Target {
+	case relayUser:
+		h.sendToUser(env.ID, message)
+	case relayGroup:
+		h.sendToGroup(env.ID, message)
+	case relayGroupExcept:
+		h.sendToGroupExcept(env.ID, env.ExcludeID, message)
+	case relayTenant:
+		h.sendToTenant(env.ID, message)
+	case relayAll:
+		h.broadcastAll(message)
+	}
+}
+
+func newInstanceID() string {
+	return uuid.NewString()
+}
+
+// ++++++++++++++++++    REDIS BACKEND   +++++++++++++++++++++
+
+// RedisBackend relays broadcasts over a Redis pub/sub channel, e.g. using a
+// client from redisclient.New
+type RedisBackend struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// Ensure RedisBackend implements BroadcastBackend
+var _ BroadcastBackend = (*RedisBackend)(nil)
+
+// NewRedisBackend creates a backend publishing to channel
+func NewRedisBackend(client redis.UniversalClient, channel string) *RedisBackend {
+	return &RedisBackend{client: client, channel: channel}
+}
+
+func (b *RedisBackend) Publish(ctx context.Context, payload []byte) error {
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe blocks until the subscription is confirmed, then delivers
+// messages in the background until ctx is done
+func (b *RedisBackend) Subscribe(ctx context.Context, handler func(payload []byte)) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", b.channel, err)
+	}
+
+	go func() {
+		defer sub.Close()
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler([]byte(msg.Payload))
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/gorilla/websocket"
+)
+
+// memoryBackend fans published payloads out to every subscriber, standing in
+// for a shared Redis channel
+type memoryBackend struct {
+	mu          sync.Mutex
+	subscribers []func(payload []byte)
+}
+
+func (b *memoryBackend) Publish(_ context.Context, payload []byte) error {
+	b.mu.Lock()
+	subs := append([]func([]byte){}, b.subscribers...)
+	b.mu.Unlock()
+	for _, sub := range subs {
+		sub(payload)
+	}
+	return nil
+}
+
+func (b *memoryBackend) Subscribe(_ context.Context, handler func(payload []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handler)
+	return nil
+}
+
+// newBridgedHubs starts two hubs sharing one backend
+func newBridgedHubs(t *testing.T) (*Hub, *Hub) {
+	t.Helper()
+	backend := &memoryBackend{}
+	a := NewHub(mock.NewMockLogger(), WithBroadcastBackend(backend), WithInstanceID("a"))
+	b := NewHub(mock.NewMockLogger(), WithBroadcastBackend(backend), WithInstanceID("b"))
+	for _, h := range []*Hub{a, b} {
+		go h.Run()
+		if err := h.StartBackend(context.Background()); err != nil {
+			t.Fatalf("StartBackend: %v", err)
+		}
+	}
+	return a, b
+}
+
+func register(t *testing.T, h *Hub, c *Client) {
+	t.Helper()
+	if res := h.RegisterWithResult(c); res.Err != nil {
+		t.Fatalf("register: %v", res.Err)
+	}
+}
+
+func receive(t *testing.T, c *Client) outboundMessage {
+	t.Helper()
+	select {
+	case msg := <-c.send:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("client %s received nothing", c.ID)
+		return outboundMessage{}
+	}
+}
+
+func expectNothing(t *testing.T, c *Client) {
+	t.Helper()
+	select {
+	case msg := <-c.send:
+		t.Fatalf("client %s got unexpected message %q", c.ID, msg.data)
+	default:
+	}
+}
+
+func TestBridge_SendToUserReachesOtherInstance(t *testing.T) {
+	a, b := newBridgedHubs(t)
+	local := newTestClient("c1", "u1", "t1")
+	remote := newTestClient("c2", "u1", "t1")
+	register(t, a, local)
+	register(t, b, remote)
+
+	if n := a.SendToUser("u1", []byte("hello")); n != 1 {
+		t.Fatalf("expected 1 local delivery, got %d", n)
+	}
+
+	if msg := receive(t, remote); string(msg.data) != "hello" || msg.messageType != websocket.TextMessage {
+		t.Fatalf("unexpected relayed message %+v", msg)
+	}
+	// The sending instance ignores its own relayed copy
+	receive(t, local)
+	expectNothing(t, local)
+}
+
+func TestBridge_PreservesTargetAndFrameType(t *testing.T) {
+	a, b := newBridgedHubs(t)
+	member := newTestClient("c1", "u1", "t1")
+	excluded := newTestClient("c2", "u2", "t1")
+	otherTenant := newTestClient("c3", "u3", "t2")
+	for _, c := range []*Client{member, excluded, otherTenant} {
+		register(t, b, c)
+	}
+	if err := b.JoinGroup("room", member); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.JoinGroup("room", excluded); err != nil {
+		t.Fatal(err)
+	}
+
+	a.SendToGroupExceptBinary("room", "u2", []byte{0x01})
+	if msg := receive(t, member); msg.messageType != websocket.BinaryMessage || msg.data[0] != 0x01 {
+		t.Fatalf("unexpected relayed message %+v", msg)
+	}
+	expectNothing(t, excluded)
+	expectNothing(t, otherTenant)
+
+	a.SendToTenant("t2", []byte("tenant"))
+	if msg := receive(t, otherTenant); string(msg.data) != "tenant" {
+		t.Fatalf("unexpected relayed message %q", msg.data)
+	}
+	expectNothing(t, member)
+
+	a.BroadcastAll([]byte("all"))
+	for _, c := range []*Client{member, excluded, otherTenant} {
+		if msg := receive(t, c); string(msg.data) != "all" {
+			t.Fatalf("unexpected relayed message %q", msg.data)
+		}
+	}
+}
+
+func TestBridge_WithoutBackend(t *testing.T) {
+	h := NewHub(mock.NewMockLogger())
+	if h.InstanceID() == "" {
+		t.Fatal("expected a generated instance ID")
+	}
+	if err := h.StartBackend(context.Background()); err == nil {
+		t.Fatal("expected error when no backend is configured")
+	}
+}
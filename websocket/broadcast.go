@@ -7,27 +7,56 @@ import (
 	"github.com/bignyap/go-utilities/logger/api"
 )
 
-// SendToUser sends a message to all connections of a specific user
+// SendToUser sends a message to all connections of a specific user.
+// With a broadcast backend the message is also relayed to other instances;
+// the returned count covers local connections only.
 func (h *Hub) SendToUser(userID string, message []byte) int {
+	return h.sendToUserAndRelay(userID, textMessage(message))
+}
+
+// SendToUserBinary sends a binary frame to all connections of a specific user
+func (h *Hub) SendToUserBinary(userID string, message []byte) int {
+	return h.sendToUserAndRelay(userID, binaryMessage(message))
+}
+
+func (h *Hub) sendToUserAndRelay(userID string, message outboundMessage) int {
+	count := h.sendToUser(userID, message)
+	h.relay(relayUser, userID, "", message)
+	return count
+}
+
+func (h *Hub) sendToUser(userID string, message outboundMessage) int {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	userClients, ok := h.clients[userID]
+	recipients := make([]*Client, 0, len(userClients))
+	for _, client := range userClients {
+		recipients = append(recipients, client)
+	}
+	h.mu.RUnlock()
 
 	ctx := context.Background()
-	count := 0
-	if userClients, ok := h.clients[userID]; ok {
-		for _, client := range userClients {
-			if client.Send(message) {
-				count++
-			}
-		}
-		h.logger.Debug(ctx, "Message sent to user",
-			api.String("user_id", userID),
-			api.Int("client_count", count),
-		)
-	} else {
+	if !ok {
 		h.logger.Debug(ctx, "No clients found for user",
 			api.String("user_id", userID),
 		)
+		return 0
+	}
+	count := deliver(recipients, message)
+	h.logger.Debug(ctx, "Message sent to user",
+		api.String("user_id", userID),
+		api.Int("client_count", count),
+	)
+	return count
+}
+
+// deliver enqueues message for each client. It runs without the hub lock,
+// since a client using BlockWithTimeout may block for up to its timeout.
+func deliver(clients []*Client, message outboundMessage) int {
+	count := 0
+	for _, client := range clients {
+		if client.enqueue(message) {
+			count++
+		}
 	}
 	return count
 }
@@ -43,26 +72,36 @@ func (h *Hub) SendToUserJSON(userID string, v interface{}) (int, error) {
 
 // SendToGroup sends a message to all clients in a group
 func (h *Hub) SendToGroup(groupID string, message []byte) int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	return h.sendToGroupAndRelay(groupID, textMessage(message))
+}
 
-	count := 0
-	sentClients := make(map[string]struct{})
+// SendToGroupBinary sends a binary frame to all clients in a group
+func (h *Hub) SendToGroupBinary(groupID string, message []byte) int {
+	return h.sendToGroupAndRelay(groupID, binaryMessage(message))
+}
 
-	if groupUsers, ok := h.groups[groupID]; ok {
-		for _, userClients := range groupUsers {
-			for clientID, client := range userClients {
-				if _, sent := sentClients[clientID]; sent {
-					continue
-				}
-				if client.Send(message) {
-					sentClients[clientID] = struct{}{}
-					count++
-				}
+func (h *Hub) sendToGroupAndRelay(groupID string, message outboundMessage) int {
+	count := h.sendToGroup(groupID, message)
+	h.relay(relayGroup, groupID, "", message)
+	return count
+}
+
+func (h *Hub) sendToGroup(groupID string, message outboundMessage) int {
+	h.mu.RLock()
+	var recipients []*Client
+	seen := make(map[string]struct{})
+	for _, userClients := range h.groups[groupID] {
+		for clientID, client := range userClients {
+			if _, ok := seen[clientID]; ok {
+				continue
 			}
+			seen[clientID] = struct{}{}
+			recipients = append(recipients, client)
 		}
 	}
-	return count
+	h.mu.RUnlock()
+
+	return deliver(recipients, message)
 }
 
 // SendToGroupJSON marshals and sends a JSON message to a group
@@ -76,48 +115,71 @@ func (h *Hub) SendToGroupJSON(groupID string, v interface{}) (int, error) {
 
 // SendToGroupExcept sends a message to all clients in a group except specified user
 func (h *Hub) SendToGroupExcept(groupID string, excludeUserID string, message []byte) int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	return h.sendToGroupExceptAndRelay(groupID, excludeUserID, textMessage(message))
+}
 
-	count := 0
-	if groupUsers, ok := h.groups[groupID]; ok {
-		for userID, userClients := range groupUsers {
-			if userID == excludeUserID {
-				continue
-			}
-			for _, client := range userClients {
-				if client.Send(message) {
-					count++
-				}
-			}
+// SendToGroupExceptBinary sends a binary frame to all clients in a group except specified user
+func (h *Hub) SendToGroupExceptBinary(groupID string, excludeUserID string, message []byte) int {
+	return h.sendToGroupExceptAndRelay(groupID, excludeUserID, binaryMessage(message))
+}
+
+func (h *Hub) sendToGroupExceptAndRelay(groupID string, excludeUserID string, message outboundMessage) int {
+	count := h.sendToGroupExcept(groupID, excludeUserID, message)
+	h.relay(relayGroupExcept, groupID, excludeUserID, message)
+	return count
+}
+
+func (h *Hub) sendToGroupExcept(groupID string, excludeUserID string, message outboundMessage) int {
+	h.mu.RLock()
+	var recipients []*Client
+	for userID, userClients := range h.groups[groupID] {
+		if userID == excludeUserID {
+			continue
+		}
+		for _, client := range userClients {
+			recipients = append(recipients, client)
 		}
 	}
-	return count
+	h.mu.RUnlock()
+
+	return deliver(recipients, message)
 }
 
 // SendToTenant sends a message to all clients in a tenant
 func (h *Hub) SendToTenant(tenantID string, message []byte) int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	return h.sendToTenantAndRelay(tenantID, textMessage(message))
+}
 
-	count := 0
-	sentClients := make(map[string]struct{})
+// SendToTenantBinary sends a binary frame to all clients in a tenant
+func (h *Hub) SendToTenantBinary(tenantID string, message []byte) int {
+	return h.sendToTenantAndRelay(tenantID, binaryMessage(message))
+}
+
+func (h *Hub) sendToTenantAndRelay(tenantID string, message outboundMessage) int {
+	count := h.sendToTenant(tenantID, message)
+	h.relay(relayTenant, tenantID, "", message)
+	return count
+}
 
+func (h *Hub) sendToTenant(tenantID string, message outboundMessage) int {
+	h.mu.RLock()
+	var recipients []*Client
+	seen := make(map[string]struct{})
 	for _, userClients := range h.clients {
 		for clientID, client := range userClients {
 			if client.TenantID != tenantID {
 				continue
 			}
-			if _, sent := sentClients[clientID]; sent {
+			if _, ok := seen[clientID]; ok {
 				continue
 			}
-			if client.Send(message) {
-				sentClients[clientID] = struct{}{}
-				count++
-			}
+			seen[clientID] = struct{}{}
+			recipients = append(recipients, client)
 		}
 	}
-	return count
+	h.mu.RUnlock()
+
+	return deliver(recipients, message)
 }
 
 // SendToTenantJSON marshals and sends a JSON message to a tenant
@@ -131,18 +193,31 @@ func (h *Hub) SendToTenantJSON(tenantID string, v interface{}) (int, error) {
 
 // BroadcastAll sends a message to all connected clients
 func (h *Hub) BroadcastAll(message []byte) int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	return h.broadcastAllAndRelay(textMessage(message))
+}
 
-	count := 0
+// BroadcastAllBinary sends a binary frame to all connected clients
+func (h *Hub) BroadcastAllBinary(message []byte) int {
+	return h.broadcastAllAndRelay(binaryMessage(message))
+}
+
+func (h *Hub) broadcastAllAndRelay(message outboundMessage) int {
+	count := h.broadcastAll(message)
+	h.relay(relayAll, "", "", message)
+	return count
+}
+
+func (h *Hub) broadcastAll(message outboundMessage) int {
+	h.mu.RLock()
+	var recipients []*Client
 	for _, userClients := range h.clients {
 		for _, client := range userClients {
-			if client.Send(message) {
-				count++
-			}
+			recipients = append(recipients, client)
 		}
 	}
-	return count
+	h.mu.RUnlock()
+
+	return deliver(recipients, message)
 }
 
 // GetClient returns a client by userID and clientID
@@ -237,17 +312,22 @@ func (h *Hub) GetConnectedUserIDs(tenantID string) []string {
 // DisconnectUser closes all connections for a user
 func (h *Hub) DisconnectUser(userID string) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
+	var disconnected []*Client
 	if userClients, ok := h.clients[userID]; ok {
 		for _, client := range userClients {
 			client.Close()
+			disconnected = append(disconnected, client)
 		}
 		delete(h.clients, userID)
 	}
 
 	// Remove from all groups
+	var left []Event
 	for groupID, groupUsers := range h.groups {
+		for _, client := range groupUsers[userID] {
+			left = append(left, clientEvent(client, groupID))
+		}
 		delete(groupUsers, userID)
 		if len(groupUsers) == 0 {
 			delete(h.groups, groupID)
@@ -257,4 +337,12 @@ func (h *Hub) DisconnectUser(userID string) {
 	h.logger.Info(context.Background(), "Disconnected all clients for user",
 		api.String("user_id", userID),
 	)
+	h.mu.Unlock()
+
+	for _, event := range left {
+		h.emit(h.onLeaveGroup, event)
+	}
+	for _, client := range disconnected {
+		h.emit(h.onDisconnect, clientEvent(client, ""))
+	}
 }
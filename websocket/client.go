@@ -3,10 +3,14 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bignyap/go-utilities/logger/api"
 	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
 )
 
 // MessageHandler is a callback for handling incoming messages
@@ -15,6 +19,20 @@ type MessageHandler func(client *Client, message []byte)
 // DisconnectHandler is a callback for handling client disconnection
 type DisconnectHandler func(client *Client)
 
+// outboundMessage is a queued frame with its WebSocket message type
+type outboundMessage struct {
+	messageType int
+	data        []byte
+}
+
+func textMessage(data []byte) outboundMessage {
+	return outboundMessage{messageType: websocket.TextMessage, data: data}
+}
+
+func binaryMessage(data []byte) outboundMessage {
+	return outboundMessage{messageType: websocket.BinaryMessage, data: data}
+}
+
 // Client represents a WebSocket client connection
 type Client struct {
 	// ID is the unique identifier for this client connection
@@ -28,14 +46,22 @@ type Client struct {
 	// Metadata stores custom key-value data for application use
 	Metadata map[string]interface{}
 
-	conn     *websocket.Conn
-	send     chan []byte
+	conn *websocket.Conn
+	// send is never closed, so enqueue can't panic racing Close; done
+	// signals shutdown instead
+	send     chan outboundMessage
+	done     chan struct{}
 	hub      HubInterface
 	logger   api.Logger
 	config   Config
 	isClosed bool
 	mu       sync.Mutex
 
+	// lastActivity is the unix-nano time of the last read from the peer
+	lastActivity atomic.Int64
+	// limiter enforces Config.MessageRate, nil when unlimited
+	limiter *rate.Limiter
+
 	// Handlers
 	messageHandler    MessageHandler
 	disconnectHandler DisconnectHandler
@@ -91,13 +117,24 @@ func NewClient(
 		UserID:   userID,
 		TenantID: tenantID,
 		conn:     conn,
-		send:     make(chan []byte, config.SendBufferSize),
+		send:     make(chan outboundMessage, config.SendBufferSize),
+		done:     make(chan struct{}),
 		hub:      hub,
 		logger:   logger.WithComponent("ws-client"),
 		config:   config,
 		Metadata: make(map[string]interface{}),
 	}
 
+	if config.MessageRate > 0 {
+		burst := config.MessageBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(config.MessageRate))
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(config.MessageRate), burst)
+	}
+
+	c.touch()
+
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -105,25 +142,69 @@ func NewClient(
 	return c
 }
 
-// Send sends a message to the client (non-blocking)
+// touch records activity from the peer
+func (c *Client) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns the time of the last read from the peer
+func (c *Client) LastActivity() time.Time {
+	return time.Unix(0, c.lastActivity.Load())
+}
+
+// Send sends a message to the client. When the send buffer is full the
+// outcome depends on Config.BackpressurePolicy; under BlockWithTimeout the
+// call may block for up to Config.BlockTimeout.
 func (c *Client) Send(message []byte) bool {
-	c.mu.Lock()
-	if c.isClosed {
-		c.mu.Unlock()
+	return c.enqueue(textMessage(message))
+}
+
+// SendBinary sends a binary frame to the client, with the same buffering
+// semantics as Send
+func (c *Client) SendBinary(message []byte) bool {
+	return c.enqueue(binaryMessage(message))
+}
+
+func (c *Client) enqueue(message outboundMessage) bool {
+	select {
+	case <-c.done:
 		return false
+	default:
 	}
-	c.mu.Unlock()
 
 	select {
 	case c.send <- message:
 		return true
+	case <-c.done:
+		return false
 	default:
-		c.logger.Warn(context.Background(), "Client send buffer full",
+	}
+
+	switch c.config.BackpressurePolicy {
+	case DisconnectClient:
+		c.logger.Warn(context.Background(), "Client send buffer full, disconnecting",
 			api.String("client_id", c.ID),
 			api.String("user_id", c.UserID),
 		)
+		c.Close()
 		return false
+	case BlockWithTimeout:
+		timer := time.NewTimer(c.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case c.send <- message:
+			return true
+		case <-c.done:
+			return false
+		case <-timer.C:
+		}
 	}
+
+	c.logger.Warn(context.Background(), "Client send buffer full",
+		api.String("client_id", c.ID),
+		api.String("user_id", c.UserID),
+	)
+	return false
 }
 
 // SendJSON marshals and sends a JSON message to the client
@@ -159,8 +240,10 @@ func (c *Client) Close() {
 		return
 	}
 	c.isClosed = true
-	close(c.send)
+	close(c.done)
 	c.mu.Unlock()
 
-	c.conn.Close()
+	if c.conn != nil {
+		c.conn.Close()
+	}
 }
@@ -0,0 +1,275 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/gorilla/websocket"
+)
+
+func newBackpressureClient(policy BackpressurePolicy, blockTimeout time.Duration) *Client {
+	c := newTestClient("c1", "u1", "t1")
+	c.config.BackpressurePolicy = policy
+	c.config.BlockTimeout = blockTimeout
+	c.send = make(chan outboundMessage, 1)
+	return c
+}
+
+func TestClientSend_DropMessage(t *testing.T) {
+	c := newBackpressureClient(DropMessage, 0)
+
+	if !c.Send([]byte("first")) {
+		t.Fatal("first send should fit in the buffer")
+	}
+	if c.Send([]byte("second")) {
+		t.Fatal("send to a full buffer should be dropped")
+	}
+	if c.isClosed {
+		t.Fatal("drop policy must not close the client")
+	}
+}
+
+func TestClientSend_DisconnectClient(t *testing.T) {
+	c := newBackpressureClient(DisconnectClient, 0)
+
+	c.Send([]byte("first"))
+	if c.Send([]byte("second")) {
+		t.Fatal("send to a full buffer should fail")
+	}
+	if !c.isClosed {
+		t.Fatal("disconnect policy should close the client")
+	}
+	if c.Send([]byte("third")) {
+		t.Fatal("send to a closed client should fail")
+	}
+}
+
+func TestClientSend_BlockWithTimeout(t *testing.T) {
+	c := newBackpressureClient(BlockWithTimeout, time.Second)
+	c.Send([]byte("first"))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-c.send
+	}()
+
+	if !c.Send([]byte("second")) {
+		t.Fatal("send should succeed once buffer space frees up within the timeout")
+	}
+	if got := string((<-c.send).data); got != "second" {
+		t.Fatalf("unexpected buffered message %q", got)
+	}
+}
+
+func TestClientSend_BlockWithTimeoutExpires(t *testing.T) {
+	c := newBackpressureClient(BlockWithTimeout, 20*time.Millisecond)
+	c.Send([]byte("first"))
+
+	start := time.Now()
+	if c.Send([]byte("second")) {
+		t.Fatal("send should be dropped after the timeout")
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("send returned after %v, before the block timeout", elapsed)
+	}
+}
+
+func TestClient_WritePumpFrameTypes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, DefaultConfig(), AllowAllOrigins())
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		client := NewClient("c1", "u1", "t1", conn, nil, mock.NewMockLogger(), DefaultConfig())
+		go client.WritePump()
+		client.SendBinary([]byte{0x1f, 0x8b, 0x00})
+		client.Send([]byte(`{"type":"text"}`))
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if messageType != websocket.BinaryMessage || string(data) != "\x1f\x8b\x00" {
+		t.Fatalf("expected binary frame, got type %d data %q", messageType, data)
+	}
+
+	messageType, data, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if messageType != websocket.TextMessage || string(data) != `{"type":"text"}` {
+		t.Fatalf("expected text frame, got type %d data %q", messageType, data)
+	}
+}
+
+// dialLimitedClient serves a client with config and returns the peer's
+// connection, a channel of handled messages and one closed on disconnect
+func dialLimitedClient(t *testing.T, config Config) (*websocket.Conn, chan []byte, chan struct{}) {
+	t.Helper()
+	handled := make(chan []byte, 100)
+	disconnected := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, config, AllowAllOrigins())
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		client := NewClient("c1", "u1", "t1", conn, nil, mock.NewMockLogger(), config,
+			WithMessageHandler(func(_ *Client, message []byte) { handled <- message }),
+			WithDisconnectHandler(func(*Client) { close(disconnected) }))
+		client.Start()
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, handled, disconnected
+}
+
+// expectClose reads from conn until the server's close frame arrives
+func expectClose(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, code) {
+				t.Fatalf("expected close code %d, got %v", code, err)
+			}
+			return
+		}
+	}
+}
+
+func TestClient_RateLimitClosesFloodingClient(t *testing.T) {
+	config := DefaultConfig()
+	config.MessageRate = 5
+	config.MessageBurst = 3
+	conn, handled, disconnected := dialLimitedClient(t, config)
+
+	for i := 0; i < 10; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("flood")); err != nil {
+			break
+		}
+	}
+	expectClose(t, conn, websocket.ClosePolicyViolation)
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("expected the disconnect handler to run")
+	}
+	if len(handled) != 3 {
+		t.Fatalf("expected only the burst of 3 messages to be handled, got %d", len(handled))
+	}
+}
+
+func TestClient_RateLimitAllowsNormalRate(t *testing.T) {
+	config := DefaultConfig()
+	config.MessageRate = 50
+	config.MessageBurst = 1
+	conn, handled, disconnected := dialLimitedClient(t, config)
+
+	for i := 0; i < 5; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("ok")); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		select {
+		case <-handled:
+		case <-time.After(time.Second):
+			t.Fatalf("message %d was not handled", i)
+		}
+		time.Sleep(40 * time.Millisecond)
+	}
+
+	select {
+	case <-disconnected:
+		t.Fatal("client sending within the rate should stay connected")
+	default:
+	}
+}
+
+func TestClient_OversizeMessageCloses(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxMessageSize = 16
+	conn, handled, disconnected := dialLimitedClient(t, config)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 64))); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	expectClose(t, conn, websocket.CloseMessageTooBig)
+
+	<-disconnected
+	if len(handled) != 0 {
+		t.Fatal("expected the oversize message not to be handled")
+	}
+}
+
+func TestClientSend_CloseWhileBlocked(t *testing.T) {
+	c := newBackpressureClient(BlockWithTimeout, 5*time.Second)
+	c.Send([]byte("first"))
+
+	result := make(chan bool, 1)
+	go func() { result <- c.Send([]byte("second")) }()
+	time.Sleep(20 * time.Millisecond)
+	c.Close()
+
+	select {
+	case ok := <-result:
+		if ok {
+			t.Fatal("send to a closed client should fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close should wake a blocked send")
+	}
+	if c.Send([]byte("third")) {
+		t.Fatal("send after Close should fail")
+	}
+}
+
+func TestHub_BlockedBroadcastDoesNotHoldLock(t *testing.T) {
+	hub := NewHub(mock.NewMockLogger())
+	go hub.Run()
+	defer hub.Shutdown(context.Background())
+
+	slow := newBackpressureClient(BlockWithTimeout, time.Second)
+	hub.Register(slow)
+	slow.Send([]byte("fill"))
+	for !hub.HasActiveConnection("u1") {
+		time.Sleep(time.Millisecond)
+	}
+
+	go hub.SendToUser("u1", []byte("blocked"))
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		hub.Register(newTestClient("c2", "u2", "t1"))
+		for !hub.HasActiveConnection("u2") {
+			time.Sleep(time.Millisecond)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("register should not wait for a blocked send")
+	}
+}
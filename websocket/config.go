@@ -2,6 +2,18 @@ package websocket
 
 import "time"
 
+// BackpressurePolicy controls what Client.Send does when the send buffer is full
+type BackpressurePolicy int
+
+const (
+	// DropMessage drops the message and reports failure (default)
+	DropMessage BackpressurePolicy = iota
+	// DisconnectClient closes the slow client
+	DisconnectClient
+	// BlockWithTimeout waits up to Config.BlockTimeout for buffer space before dropping
+	BlockWithTimeout
+)
+
 // Config holds WebSocket configuration
 type Config struct {
 	// WriteWait is the time allowed to write a message to the peer
@@ -10,7 +22,8 @@ type Config struct {
 	PongWait time.Duration
 	// PingPeriod is the period to send pings to the peer (must be less than PongWait)
 	PingPeriod time.Duration
-	// MaxMessageSize is the maximum message size allowed from peer
+	// MaxMessageSize is the maximum message size allowed from peer. Larger
+	// messages close the connection with CloseMessageTooBig (1009).
 	MaxMessageSize int64
 	// SendBufferSize is the size of the send channel buffer
 	SendBufferSize int
@@ -18,6 +31,17 @@ type Config struct {
 	ReadBufferSize int
 	// WriteBufferSize is the WebSocket write buffer size
 	WriteBufferSize int
+	// BackpressurePolicy decides how Send handles a full send buffer
+	BackpressurePolicy BackpressurePolicy
+	// BlockTimeout is how long Send may block under BlockWithTimeout
+	BlockTimeout time.Duration
+	// MessageRate is the number of messages per second a client may send.
+	// Clients exceeding it are closed with ClosePolicyViolation (1008).
+	// Zero disables inbound rate limiting.
+	MessageRate float64
+	// MessageBurst is how many messages may arrive at once above MessageRate
+	// (default MessageRate rounded up)
+	MessageBurst int
 }
 
 // DefaultConfig returns default WebSocket configuration
@@ -30,6 +54,7 @@ func DefaultConfig() Config {
 		SendBufferSize:  256,
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
+		BlockTimeout:    time.Second,
 	}
 }
 
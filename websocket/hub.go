@@ -2,7 +2,9 @@ package websocket
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/bignyap/go-utilities/logger/api"
 )
@@ -24,53 +26,267 @@ type Hub struct {
 	groups map[string]map[string]map[string]*Client
 
 	// Channels for thread-safe operations
-	register   chan *Client
+	register   chan registration
 	unregister chan *Client
 
 	// Mutex for direct access operations
 	mu sync.RWMutex
 
 	logger api.Logger
+
+	// Presence/subscription callbacks
+	onConnect    EventHandler
+	onDisconnect EventHandler
+	onJoinGroup  EventHandler
+	onLeaveGroup EventHandler
+
+	// Connection limits (0 means unlimited)
+	maxConnectionsPerUser int
+	maxGroupMembers       int
+
+	// Cross-instance relay (nil backend means local delivery only)
+	backend    BroadcastBackend
+	instanceID string
+
+	// done is closed by Shutdown to stop Run
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var (
+	// ErrUserConnectionLimit is returned when a user already has the maximum number of connections
+	ErrUserConnectionLimit = errors.New("websocket: per-user connection limit reached")
+	// ErrGroupFull is returned when a group already has the maximum number of members
+	ErrGroupFull = errors.New("websocket: group is full")
+	// ErrHubClosed is returned when registering with a hub that has been shut down
+	ErrHubClosed = errors.New("websocket: hub is shut down")
+)
+
+// RegisterResult reports the outcome of a client registration
+type RegisterResult struct {
+	Client *Client
+	// Err is nil on success, or the reason the client was rejected
+	Err error
+}
+
+// registration is a queued register request; result is nil for fire-and-forget registrations
+type registration struct {
+	client *Client
+	result chan RegisterResult
+}
+
+// Event describes a client connecting, disconnecting, joining or leaving a group
+type Event struct {
+	UserID   string
+	ClientID string
+	TenantID string
+	// GroupID is only set for group events
+	GroupID string
+}
+
+// EventHandler is a callback for hub presence/subscription events.
+// Handlers are invoked without the hub lock held, so they may call back into the hub.
+type EventHandler func(event Event)
+
+// HubOption is a functional option for configuring a Hub
+type HubOption func(*Hub)
+
+// WithOnConnect sets the handler invoked after a client is registered
+func WithOnConnect(handler EventHandler) HubOption {
+	return func(h *Hub) {
+		h.onConnect = handler
+	}
+}
+
+// WithOnDisconnect sets the handler invoked after a client is unregistered
+func WithOnDisconnect(handler EventHandler) HubOption {
+	return func(h *Hub) {
+		h.onDisconnect = handler
+	}
+}
+
+// WithOnJoinGroup sets the handler invoked after a client joins a group
+func WithOnJoinGroup(handler EventHandler) HubOption {
+	return func(h *Hub) {
+		h.onJoinGroup = handler
+	}
+}
+
+// WithOnLeaveGroup sets the handler invoked after a client leaves a group
+func WithOnLeaveGroup(handler EventHandler) HubOption {
+	return func(h *Hub) {
+		h.onLeaveGroup = handler
+	}
+}
+
+// WithMaxConnectionsPerUser limits the number of concurrent connections a single user may hold.
+// Registrations beyond the limit are rejected and the client is closed.
+func WithMaxConnectionsPerUser(n int) HubOption {
+	return func(h *Hub) {
+		h.maxConnectionsPerUser = n
+	}
+}
+
+// WithMaxGroupMembers limits the number of client connections a single group may hold
+func WithMaxGroupMembers(n int) HubOption {
+	return func(h *Hub) {
+		h.maxGroupMembers = n
+	}
 }
 
 // NewHub creates a new WebSocket hub
-func NewHub(logger api.Logger) *Hub {
-	return &Hub{
+func NewHub(logger api.Logger, opts ...HubOption) *Hub {
+	h := &Hub{
 		clients:    make(map[string]map[string]*Client),
 		groups:     make(map[string]map[string]map[string]*Client),
-		register:   make(chan *Client),
+		register:   make(chan registration),
 		unregister: make(chan *Client),
+		done:       make(chan struct{}),
 		logger:     logger.WithComponent("ws-hub"),
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.instanceID == "" {
+		h.instanceID = newInstanceID()
+	}
+
+	return h
 }
 
-// Run starts the hub's main event loop
+// emit invokes handler with the event if it is set.
+// Must be called without holding h.mu.
+func (h *Hub) emit(handler EventHandler, event Event) {
+	if handler != nil {
+		handler(event)
+	}
+}
+
+func clientEvent(client *Client, groupID string) Event {
+	return Event{
+		UserID:   client.UserID,
+		ClientID: client.ID,
+		TenantID: client.TenantID,
+		GroupID:  groupID,
+	}
+}
+
+// Run starts the hub's main event loop. It returns after Shutdown.
 func (h *Hub) Run() {
 	for {
 		select {
-		case client := <-h.register:
-			h.registerClient(client)
+		case <-h.done:
+			return
+		case reg := <-h.register:
+			result := h.registerClient(reg.client)
+			if reg.result != nil {
+				reg.result <- result
+			}
 		case client := <-h.unregister:
 			h.unregisterClient(client)
 		}
 	}
 }
 
-// Register adds a client to the hub
+// Register adds a client to the hub.
+// A client rejected by a connection limit is closed; use RegisterWithResult to learn why.
 func (h *Hub) Register(client *Client) {
-	h.register <- client
+	select {
+	case h.register <- registration{client: client}:
+	case <-h.done:
+		client.Close()
+	}
+}
+
+// RegisterWithResult adds a client to the hub and waits for the outcome
+func (h *Hub) RegisterWithResult(client *Client) RegisterResult {
+	result := make(chan RegisterResult, 1)
+	select {
+	case h.register <- registration{client: client, result: result}:
+		return <-result
+	case <-h.done:
+		client.Close()
+		return RegisterResult{Client: client, Err: ErrHubClosed}
+	}
 }
 
 // Unregister removes a client from the hub
 func (h *Hub) Unregister(client *Client) {
-	h.unregister <- client
+	select {
+	case h.unregister <- client:
+	case <-h.done:
+		// Shutdown already closed and removed every client
+		client.Close()
+	}
 }
 
-func (h *Hub) registerClient(client *Client) {
+// Shutdown stops Run, rejects further registrations and closes every
+// connected client, firing the leave and disconnect callbacks. It returns
+// ctx.Err() if ctx is done before all callbacks have run.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.stopOnce.Do(func() { close(h.done) })
+
+	h.mu.Lock()
+	var left []Event
+	for groupID, groupUsers := range h.groups {
+		for _, userClients := range groupUsers {
+			for _, client := range userClients {
+				left = append(left, clientEvent(client, groupID))
+			}
+		}
+	}
+	var disconnected []*Client
+	for _, userClients := range h.clients {
+		for _, client := range userClients {
+			client.Close()
+			disconnected = append(disconnected, client)
+		}
+	}
+	h.clients = make(map[string]map[string]*Client)
+	h.groups = make(map[string]map[string]map[string]*Client)
+
+	h.logger.Info(ctx, "Hub shut down",
+		api.Int("clients", len(disconnected)),
+	)
+	h.mu.Unlock()
+
+	for _, event := range left {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h.emit(h.onLeaveGroup, event)
+	}
+	for _, client := range disconnected {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h.emit(h.onDisconnect, clientEvent(client, ""))
+	}
+	return nil
+}
+
+func (h *Hub) registerClient(client *Client) RegisterResult {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	ctx := context.Background()
+	if h.maxConnectionsPerUser > 0 && len(h.clients[client.UserID]) >= h.maxConnectionsPerUser {
+		if _, exists := h.clients[client.UserID][client.ID]; !exists {
+			h.logger.Warn(ctx, "Client rejected: per-user connection limit reached",
+				api.String("client_id", client.ID),
+				api.String("user_id", client.UserID),
+				api.Int("limit", h.maxConnectionsPerUser),
+			)
+			h.mu.Unlock()
+
+			client.Close()
+			return RegisterResult{Client: client, Err: ErrUserConnectionLimit}
+		}
+	}
+
+	if client.lastActivity.Load() == 0 {
+		client.touch()
+	}
 	if _, ok := h.clients[client.UserID]; !ok {
 		h.clients[client.UserID] = make(map[string]*Client)
 	}
@@ -81,16 +297,21 @@ func (h *Hub) registerClient(client *Client) {
 		api.String("user_id", client.UserID),
 		api.String("tenant_id", client.TenantID),
 	)
+	h.mu.Unlock()
+
+	h.emit(h.onConnect, clientEvent(client, ""))
+	return RegisterResult{Client: client}
 }
 
 func (h *Hub) unregisterClient(client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	ctx := context.Background()
+	registered := false
 	// Remove from user clients
 	if userClients, ok := h.clients[client.UserID]; ok {
 		if _, exists := userClients[client.ID]; exists {
+			registered = true
 			client.Close()
 			delete(userClients, client.ID)
 			if len(userClients) == 0 {
@@ -100,8 +321,12 @@ func (h *Hub) unregisterClient(client *Client) {
 	}
 
 	// Remove from all groups
+	var leftGroups []string
 	for groupID, groupUsers := range h.groups {
 		if userClients, ok := groupUsers[client.UserID]; ok {
+			if _, exists := userClients[client.ID]; exists {
+				leftGroups = append(leftGroups, groupID)
+			}
 			delete(userClients, client.ID)
 			if len(userClients) == 0 {
 				delete(groupUsers, client.UserID)
@@ -116,14 +341,76 @@ func (h *Hub) unregisterClient(client *Client) {
 		api.String("client_id", client.ID),
 		api.String("user_id", client.UserID),
 	)
+	h.mu.Unlock()
+
+	for _, groupID := range leftGroups {
+		h.emit(h.onLeaveGroup, clientEvent(client, groupID))
+	}
+	if registered {
+		h.emit(h.onDisconnect, clientEvent(client, ""))
+	}
+}
+
+// StartReaper starts a background sweep that disconnects clients with no
+// activity for longer than idleTimeout. It stops when ctx is canceled.
+func (h *Hub) StartReaper(ctx context.Context, interval, idleTimeout time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				h.reapIdle(now, idleTimeout)
+			}
+		}
+	}()
+}
+
+// reapIdle disconnects clients whose last activity is older than idleTimeout
+// and returns the number of clients reaped
+func (h *Hub) reapIdle(now time.Time, idleTimeout time.Duration) int {
+	h.mu.RLock()
+	var idle []*Client
+	for _, userClients := range h.clients {
+		for _, client := range userClients {
+			if now.Sub(client.LastActivity()) > idleTimeout {
+				idle = append(idle, client)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range idle {
+		h.logger.Info(context.Background(), "Reaping idle client",
+			api.String("client_id", client.ID),
+			api.String("user_id", client.UserID),
+			api.String("last_activity", client.LastActivity().Format(time.RFC3339)),
+		)
+		h.unregisterClient(client)
+	}
+	return len(idle)
 }
 
-// JoinGroup adds a client to a group (room, call, etc.)
-func (h *Hub) JoinGroup(groupID string, client *Client) {
+// JoinGroup adds a client to a group (room, call, etc.).
+// It returns ErrGroupFull if the group has reached its member limit.
+func (h *Hub) JoinGroup(groupID string, client *Client) error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	ctx := context.Background()
+	if h.maxGroupMembers > 0 && !h.inGroup(groupID, client) && h.groupSize(groupID) >= h.maxGroupMembers {
+		h.logger.Warn(ctx, "Client rejected: group is full",
+			api.String("client_id", client.ID),
+			api.String("user_id", client.UserID),
+			api.String("group_id", groupID),
+			api.Int("limit", h.maxGroupMembers),
+		)
+		h.mu.Unlock()
+		return ErrGroupFull
+	}
+
 	if _, ok := h.groups[groupID]; !ok {
 		h.groups[groupID] = make(map[string]map[string]*Client)
 	}
@@ -137,16 +424,36 @@ func (h *Hub) JoinGroup(groupID string, client *Client) {
 		api.String("user_id", client.UserID),
 		api.String("group_id", groupID),
 	)
+	h.mu.Unlock()
+
+	h.emit(h.onJoinGroup, clientEvent(client, groupID))
+	return nil
+}
+
+// inGroup reports whether client is already a member of groupID. Caller must hold h.mu.
+func (h *Hub) inGroup(groupID string, client *Client) bool {
+	_, ok := h.groups[groupID][client.UserID][client.ID]
+	return ok
+}
+
+// groupSize returns the number of client connections in groupID. Caller must hold h.mu.
+func (h *Hub) groupSize(groupID string) int {
+	n := 0
+	for _, userClients := range h.groups[groupID] {
+		n += len(userClients)
+	}
+	return n
 }
 
 // LeaveGroup removes a client from a group
 func (h *Hub) LeaveGroup(groupID string, client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	ctx := context.Background()
+	left := false
 	if groupUsers, ok := h.groups[groupID]; ok {
 		if userClients, ok := groupUsers[client.UserID]; ok {
+			_, left = userClients[client.ID]
 			delete(userClients, client.ID)
 			if len(userClients) == 0 {
 				delete(groupUsers, client.UserID)
@@ -162,4 +469,9 @@ func (h *Hub) LeaveGroup(groupID string, client *Client) {
 		api.String("user_id", client.UserID),
 		api.String("group_id", groupID),
 	)
+	h.mu.Unlock()
+
+	if left {
+		h.emit(h.onLeaveGroup, clientEvent(client, groupID))
+	}
 }
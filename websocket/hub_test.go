@@ -0,0 +1,274 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/gorilla/websocket"
+)
+
+// newTestClient builds a client without a network connection for hub bookkeeping tests
+func newTestClient(id, userID, tenantID string) *Client {
+	return &Client{
+		ID:       id,
+		UserID:   userID,
+		TenantID: tenantID,
+		send:     make(chan outboundMessage, DefaultConfig().SendBufferSize),
+		done:     make(chan struct{}),
+		logger:   mock.NewMockLogger(),
+		config:   DefaultConfig(),
+		Metadata: make(map[string]interface{}),
+	}
+}
+
+// isClosed reports whether Close has been called on c
+func isClosed(c *Client) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestHub_OnConnectEvent(t *testing.T) {
+	events := make(chan Event, 1)
+	hub := NewHub(mock.NewMockLogger(), WithOnConnect(func(e Event) {
+		events <- e
+	}))
+	go hub.Run()
+
+	hub.Register(newTestClient("c1", "u1", "t1"))
+
+	select {
+	case e := <-events:
+		if e.UserID != "u1" || e.ClientID != "c1" || e.TenantID != "t1" || e.GroupID != "" {
+			t.Fatalf("unexpected connect event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnConnect was not invoked")
+	}
+}
+
+func TestHub_OnJoinGroupEvent(t *testing.T) {
+	var got []Event
+	hub := NewHub(mock.NewMockLogger(),
+		WithOnJoinGroup(func(e Event) { got = append(got, e) }),
+		WithOnLeaveGroup(func(e Event) { got = append(got, e) }),
+	)
+	client := newTestClient("c1", "u1", "t1")
+	hub.registerClient(client)
+
+	hub.JoinGroup("room-1", client)
+	hub.LeaveGroup("room-1", client)
+	// Leaving a group the client isn't in must not fire an event
+	hub.LeaveGroup("room-2", client)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(got), got)
+	}
+	want := Event{UserID: "u1", ClientID: "c1", TenantID: "t1", GroupID: "room-1"}
+	if got[0] != want || got[1] != want {
+		t.Fatalf("unexpected group events: %+v", got)
+	}
+}
+
+func TestHub_EventHandlerCanCallBackIntoHub(t *testing.T) {
+	done := make(chan []string, 1)
+	var hub *Hub
+	hub = NewHub(mock.NewMockLogger(), WithOnJoinGroup(func(e Event) {
+		// Would deadlock if the hub lock were still held
+		done <- hub.GetGroupUserIDs(e.GroupID)
+	}))
+	client := newTestClient("c1", "u1", "t1")
+	hub.registerClient(client)
+
+	go hub.JoinGroup("room-1", client)
+
+	select {
+	case users := <-done:
+		if len(users) != 1 || users[0] != "u1" {
+			t.Fatalf("unexpected group users: %v", users)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event handler deadlocked")
+	}
+}
+
+func TestHub_MaxConnectionsPerUser(t *testing.T) {
+	hub := NewHub(mock.NewMockLogger(), WithMaxConnectionsPerUser(2))
+	go hub.Run()
+
+	for _, id := range []string{"c1", "c2"} {
+		if res := hub.RegisterWithResult(newTestClient(id, "u1", "t1")); res.Err != nil {
+			t.Fatalf("registering %s: unexpected error %v", id, res.Err)
+		}
+	}
+
+	rejected := newTestClient("c3", "u1", "t1")
+	res := hub.RegisterWithResult(rejected)
+	if !errors.Is(res.Err, ErrUserConnectionLimit) {
+		t.Fatalf("expected ErrUserConnectionLimit, got %v", res.Err)
+	}
+	if !isClosed(rejected) {
+		t.Fatal("rejected client should be closed")
+	}
+	if got := len(hub.GetUserClients("u1")); got != 2 {
+		t.Fatalf("expected 2 clients for u1, got %d", got)
+	}
+
+	// Other users are unaffected
+	if res := hub.RegisterWithResult(newTestClient("c4", "u2", "t1")); res.Err != nil {
+		t.Fatalf("registering another user: unexpected error %v", res.Err)
+	}
+}
+
+func TestHub_MaxGroupMembers(t *testing.T) {
+	hub := NewHub(mock.NewMockLogger(), WithMaxGroupMembers(2))
+	c1 := newTestClient("c1", "u1", "t1")
+	c2 := newTestClient("c2", "u2", "t1")
+	c3 := newTestClient("c3", "u3", "t1")
+	for _, c := range []*Client{c1, c2, c3} {
+		hub.registerClient(c)
+	}
+
+	if err := hub.JoinGroup("room-1", c1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hub.JoinGroup("room-1", c2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hub.JoinGroup("room-1", c3); !errors.Is(err, ErrGroupFull) {
+		t.Fatalf("expected ErrGroupFull, got %v", err)
+	}
+	// Re-joining as an existing member is not refused
+	if err := hub.JoinGroup("room-1", c1); err != nil {
+		t.Fatalf("rejoin: unexpected error: %v", err)
+	}
+
+	hub.LeaveGroup("room-1", c2)
+	if err := hub.JoinGroup("room-1", c3); err != nil {
+		t.Fatalf("join after leave: unexpected error: %v", err)
+	}
+}
+
+func TestHub_ReapIdle(t *testing.T) {
+	hub := NewHub(mock.NewMockLogger())
+	idle := newTestClient("c1", "u1", "t1")
+	active := newTestClient("c2", "u2", "t1")
+	hub.registerClient(idle)
+	hub.registerClient(active)
+	hub.JoinGroup("room-1", idle)
+
+	now := time.Now()
+	idle.lastActivity.Store(now.Add(-time.Minute).UnixNano())
+	active.lastActivity.Store(now.UnixNano())
+
+	if n := hub.reapIdle(now, 30*time.Second); n != 1 {
+		t.Fatalf("expected 1 client reaped, got %d", n)
+	}
+	if hub.HasActiveConnection("u1") {
+		t.Fatal("idle client should have been disconnected")
+	}
+	if !isClosed(idle) {
+		t.Fatal("idle client should be closed")
+	}
+	if len(hub.GetGroupClients("room-1")) != 0 {
+		t.Fatal("idle client should have been removed from its groups")
+	}
+	if !hub.HasActiveConnection("u2") {
+		t.Fatal("active client should survive the sweep")
+	}
+}
+
+func TestHub_StartReaperStopsOnCancel(t *testing.T) {
+	disconnected := make(chan Event, 1)
+	hub := NewHub(mock.NewMockLogger(), WithOnDisconnect(func(e Event) {
+		disconnected <- e
+	}))
+	client := newTestClient("c1", "u1", "t1")
+	hub.registerClient(client)
+	client.lastActivity.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hub.StartReaper(ctx, 10*time.Millisecond, time.Minute)
+
+	select {
+	case e := <-disconnected:
+		if e.ClientID != "c1" {
+			t.Fatalf("unexpected disconnect event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("idle client was not reaped")
+	}
+	cancel()
+	// Let the reaper goroutine observe the cancellation
+	time.Sleep(20 * time.Millisecond)
+
+	// A client that goes idle after cancel must not be reaped
+	late := newTestClient("c2", "u2", "t1")
+	hub.registerClient(late)
+	late.lastActivity.Store(time.Now().Add(-time.Hour).UnixNano())
+	time.Sleep(50 * time.Millisecond)
+	if !hub.HasActiveConnection("u2") {
+		t.Fatal("reaper kept running after context cancel")
+	}
+}
+
+func TestHub_SendToUserBinary(t *testing.T) {
+	hub := NewHub(mock.NewMockLogger())
+	client := newTestClient("c1", "u1", "t1")
+	hub.registerClient(client)
+
+	if n := hub.SendToUserBinary("u1", []byte{0x01}); n != 1 {
+		t.Fatalf("expected 1 recipient, got %d", n)
+	}
+	if msg := <-client.send; msg.messageType != websocket.BinaryMessage {
+		t.Fatalf("expected binary message type, got %d", msg.messageType)
+	}
+}
+
+func TestHub_Shutdown(t *testing.T) {
+	var disconnects int
+	hub := NewHub(mock.NewMockLogger(), WithOnDisconnect(func(Event) { disconnects++ }))
+	runDone := make(chan struct{})
+	go func() {
+		hub.Run()
+		close(runDone)
+	}()
+
+	client := newTestClient("c1", "u1", "t1")
+	if res := hub.RegisterWithResult(client); res.Err != nil {
+		t.Fatalf("register: %v", res.Err)
+	}
+	hub.JoinGroup("room-1", client)
+
+	if err := hub.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case <-runDone:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Shutdown")
+	}
+
+	if !client.isClosed {
+		t.Fatal("client should be closed")
+	}
+	if disconnects != 1 {
+		t.Fatalf("expected 1 disconnect event, got %d", disconnects)
+	}
+	if hub.HasActiveConnection("u1") || hub.groupSize("room-1") != 0 {
+		t.Fatal("hub should hold no clients after Shutdown")
+	}
+
+	late := newTestClient("c2", "u2", "t1")
+	if res := hub.RegisterWithResult(late); !errors.Is(res.Err, ErrHubClosed) {
+		t.Fatalf("expected ErrHubClosed, got %v", res.Err)
+	}
+	// Must not block once Run has returned
+	hub.Unregister(client)
+}
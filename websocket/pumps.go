@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/bignyap/go-utilities/logger/api"
@@ -9,7 +10,8 @@ import (
 )
 
 // ReadPump pumps messages from the WebSocket connection to the message handler
-// This should be run in a goroutine
+// This should be run in a goroutine. Clients sending messages over
+// Config.MaxMessageSize or faster than Config.MessageRate are disconnected.
 func (c *Client) ReadPump() {
 	defer func() {
 		if c.disconnectHandler != nil {
@@ -24,6 +26,7 @@ func (c *Client) ReadPump() {
 	c.conn.SetReadLimit(c.config.MaxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
 	c.conn.SetPongHandler(func(string) error {
+		c.touch()
 		c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
 		return nil
 	})
@@ -32,6 +35,15 @@ func (c *Client) ReadPump() {
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				// gorilla has already sent CloseMessageTooBig
+				c.logger.Warn(ctx, "WebSocket message too large, disconnecting",
+					api.String("client_id", c.ID),
+					api.String("user_id", c.UserID),
+					api.Int64("max_message_size", c.config.MaxMessageSize),
+				)
+				break
+			}
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				c.logger.Error(ctx, "WebSocket read error", err,
 					api.String("client_id", c.ID),
@@ -40,6 +52,18 @@ func (c *Client) ReadPump() {
 			}
 			break
 		}
+		c.touch()
+
+		if c.limiter != nil && !c.limiter.Allow() {
+			c.logger.Warn(ctx, "WebSocket message rate exceeded, disconnecting",
+				api.String("client_id", c.ID),
+				api.String("user_id", c.UserID),
+			)
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "message rate exceeded"),
+				time.Now().Add(c.config.WriteWait))
+			break
+		}
 
 		if c.messageHandler != nil {
 			c.messageHandler(c, message)
@@ -58,24 +82,25 @@ func (c *Client) WritePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
+		case <-c.done:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+
+		case message := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
-			if !ok {
-				// Channel was closed
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
 
 			// Send each message as a separate WebSocket frame
 			// This ensures each JSON message is received individually by the client
-			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			if err := c.conn.WriteMessage(message.messageType, message.data); err != nil {
 				return
 			}
 
 			// Send any queued messages as separate frames
 			n := len(c.send)
 			for i := 0; i < n; i++ {
-				if err := c.conn.WriteMessage(websocket.TextMessage, <-c.send); err != nil {
+				queued := <-c.send
+				if err := c.conn.WriteMessage(queued.messageType, queued.data); err != nil {
 					return
 				}
 			}
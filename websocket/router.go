@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/bignyap/go-utilities/logger/api"
+)
+
+// ErrorMessageType is the envelope type of replies to frames that cannot be decoded
+const ErrorMessageType = "error"
+
+// Envelope is the frame exchanged with a Router. Requests carry a Type, an
+// optional correlation ID and a Payload; replies echo the Type and ID with
+// either the handler's result as Payload or an Error.
+type Envelope struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// RouteHandler handles one message type. Its result is sent back as the
+// reply payload.
+type RouteHandler func(client *Client, payload json.RawMessage) (any, error)
+
+// Router dispatches envelopes to handlers registered by message type
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[string]RouteHandler
+	logger   api.Logger
+}
+
+// NewRouter creates an empty Router
+func NewRouter(logger api.Logger) *Router {
+	return &Router{
+		handlers: make(map[string]RouteHandler),
+		logger:   logger.WithComponent("ws-router"),
+	}
+}
+
+// Handle registers handler for msgType, replacing any previous handler
+func (r *Router) Handle(msgType string, handler RouteHandler) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[msgType] = handler
+	return r
+}
+
+// MessageHandler returns the router as a client MessageHandler, for use
+// with WithMessageHandler. Handlers run on the client's read pump, so a
+// slow handler delays that client's next message.
+func (r *Router) MessageHandler() MessageHandler {
+	return r.Dispatch
+}
+
+// Dispatch decodes message and runs the handler for its type. A reply is
+// sent when the request has an ID, and errors are always reported, so
+// fire-and-forget messages only hear back when they fail.
+func (r *Router) Dispatch(client *Client, message []byte) {
+	var req Envelope
+	if err := json.Unmarshal(message, &req); err != nil || req.Type == "" {
+		r.reply(client, Envelope{Type: ErrorMessageType, Error: "invalid message envelope"})
+		return
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[req.Type]
+	r.mu.RUnlock()
+	if !ok {
+		r.reply(client, Envelope{Type: req.Type, ID: req.ID, Error: fmt.Sprintf("unknown message type %q", req.Type)})
+		return
+	}
+
+	result, err := handler(client, req.Payload)
+	if err != nil {
+		r.reply(client, Envelope{Type: req.Type, ID: req.ID, Error: err.Error()})
+		return
+	}
+	if req.ID == "" {
+		return
+	}
+
+	resp := Envelope{Type: req.Type, ID: req.ID}
+	if result != nil {
+		payload, err := json.Marshal(result)
+		if err != nil {
+			r.reply(client, Envelope{Type: req.Type, ID: req.ID, Error: "failed to encode response"})
+			return
+		}
+		resp.Payload = payload
+	}
+	r.reply(client, resp)
+}
+
+func (r *Router) reply(client *Client, env Envelope) {
+	if err := client.SendJSON(env); err != nil {
+		r.logger.Error(context.Background(), "Failed to send reply", err,
+			api.String("client_id", client.ID),
+			api.String("type", env.Type),
+		)
+	}
+}
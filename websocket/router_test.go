@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/gorilla/websocket"
+)
+
+// dialRouter serves a client whose messages go through router and returns the peer's connection
+func dialRouter(t *testing.T, router *Router) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, DefaultConfig(), AllowAllOrigins())
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		client := NewClient("c1", "u1", "t1", conn, nil, mock.NewMockLogger(), DefaultConfig(),
+			WithMessageHandler(router.MessageHandler()))
+		client.Start()
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func roundTrip(t *testing.T, conn *websocket.Conn, request string) Envelope {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(request)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var reply Envelope
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return reply
+}
+
+func TestRouter_RepliesWithCorrelationID(t *testing.T) {
+	router := NewRouter(mock.NewMockLogger()).
+		Handle("echo", func(client *Client, payload json.RawMessage) (any, error) {
+			var req struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, err
+			}
+			return map[string]string{"text": req.Text, "user": client.UserID}, nil
+		})
+	conn := dialRouter(t, router)
+
+	reply := roundTrip(t, conn, `{"type":"echo","id":"req-42","payload":{"text":"hello"}}`)
+	if reply.Type != "echo" || reply.ID != "req-42" || reply.Error != "" {
+		t.Fatalf("unexpected reply envelope: %+v", reply)
+	}
+	if string(reply.Payload) != `{"text":"hello","user":"u1"}` {
+		t.Fatalf("unexpected reply payload: %s", reply.Payload)
+	}
+}
+
+func TestRouter_ErrorFrames(t *testing.T) {
+	router := NewRouter(mock.NewMockLogger()).
+		Handle("fail", func(*Client, json.RawMessage) (any, error) {
+			return nil, errors.New("not allowed")
+		})
+	conn := dialRouter(t, router)
+
+	if reply := roundTrip(t, conn, `{"type":"fail","id":"1"}`); reply.ID != "1" || reply.Error != "not allowed" {
+		t.Fatalf("expected a handler error frame, got %+v", reply)
+	}
+	if reply := roundTrip(t, conn, `{"type":"missing","id":"2"}`); reply.ID != "2" || !strings.Contains(reply.Error, "unknown message type") {
+		t.Fatalf("expected an unknown type error frame, got %+v", reply)
+	}
+	if reply := roundTrip(t, conn, `not json`); reply.Type != ErrorMessageType || reply.Error == "" {
+		t.Fatalf("expected an envelope error frame, got %+v", reply)
+	}
+}
+
+func TestRouter_FireAndForgetHasNoReply(t *testing.T) {
+	handled := make(chan struct{}, 1)
+	router := NewRouter(mock.NewMockLogger()).
+		Handle("ping", func(*Client, json.RawMessage) (any, error) {
+			handled <- struct{}{}
+			return "pong", nil
+		})
+	conn := dialRouter(t, router)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ping"}`)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	<-handled
+
+	// A correlated request afterwards gets the first reply on the wire
+	if reply := roundTrip(t, conn, `{"type":"ping","id":"p1"}`); reply.ID != "p1" || string(reply.Payload) != `"pong"` {
+		t.Fatalf("expected only the correlated reply, got %+v", reply)
+	}
+}
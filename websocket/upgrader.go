@@ -1,8 +1,14 @@
 package websocket
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/bignyap/go-utilities/jwt"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -50,3 +56,189 @@ func Upgrade(w http.ResponseWriter, r *http.Request, config Config, checkOrigin
 	return upgrader.Upgrade(w, r, nil)
 }
 
+// ErrOriginNotAllowed and ErrUnauthorized are wrapped in an *UpgradeError
+// when UpgradeClient rejects a request
+var (
+	ErrOriginNotAllowed = errors.New("websocket: origin not allowed")
+	ErrUnauthorized     = errors.New("websocket: unauthorized")
+)
+
+// UpgradeError is returned by UpgradeClient when a request is rejected
+// before the upgrade. Nothing has been written to the response, so the
+// caller should reply with Status (401 or 403).
+type UpgradeError struct {
+	Status int
+	Err    error
+}
+
+func (e *UpgradeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UpgradeError) Unwrap() error {
+	return e.Err
+}
+
+// TokenVerifier validates a token and returns its claims
+type TokenVerifier func(token string) (map[string]interface{}, error)
+
+// UpgradeOption configures UpgradeClient
+type UpgradeOption func(*upgradeOptions)
+
+type upgradeOptions struct {
+	config      Config
+	checkOrigin OriginChecker
+	verify      TokenVerifier
+	tokenParam  string
+	tokenCookie string
+	tenantClaim string
+	clientOpts  []ClientOption
+}
+
+// WithUpgradeConfig sets the client configuration (default DefaultConfig())
+func WithUpgradeConfig(config Config) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.config = config
+	}
+}
+
+// WithOriginCheck sets the origin checker. Without one, only same-origin
+// requests (or those without an Origin header) are accepted.
+func WithOriginCheck(checkOrigin OriginChecker) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.checkOrigin = checkOrigin
+	}
+}
+
+// WithAllowedOrigins accepts only the given origins
+func WithAllowedOrigins(origins ...string) UpgradeOption {
+	return WithOriginCheck(AllowOrigins(origins...))
+}
+
+// WithJWTAuth requires a token verified by jwt.ParseAndVerifyJWT
+func WithJWTAuth() UpgradeOption {
+	return WithTokenVerifier(func(token string) (map[string]interface{}, error) {
+		return jwt.ParseAndVerifyJWT(token)
+	})
+}
+
+// WithTokenVerifier requires a token accepted by verify. The client's UserID
+// is taken from the "sub" claim and its TenantID from the tenant claim.
+func WithTokenVerifier(verify TokenVerifier) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.verify = verify
+	}
+}
+
+// WithTokenSource sets the query parameter and cookie the token is read
+// from (default "token" for both). A Bearer Authorization header is also
+// accepted for non-browser clients.
+func WithTokenSource(queryParam, cookieName string) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.tokenParam = queryParam
+		o.tokenCookie = cookieName
+	}
+}
+
+// WithTenantClaim sets the claim holding the tenant ID (default "tenant_id")
+func WithTenantClaim(claim string) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.tenantClaim = claim
+	}
+}
+
+// WithClientOptions applies opts to the upgraded client
+func WithClientOptions(opts ...ClientOption) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.clientOpts = append(o.clientOpts, opts...)
+	}
+}
+
+// UpgradeClient checks the request's origin and, with token auth configured,
+// its token, then upgrades the connection, registers a new client with hub
+// and starts its pumps. Origin and auth failures return an *UpgradeError
+// without writing a response. Without token auth the client is anonymous
+// and its UserID is its client ID.
+func UpgradeClient(w http.ResponseWriter, r *http.Request, hub *Hub, opts ...UpgradeOption) (*Client, error) {
+	o := upgradeOptions{
+		config:      DefaultConfig(),
+		checkOrigin: sameOrigin,
+		tokenParam:  "token",
+		tokenCookie: "token",
+		tenantClaim: "tenant_id",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !o.checkOrigin(r) {
+		return nil, &UpgradeError{Status: http.StatusForbidden, Err: ErrOriginNotAllowed}
+	}
+
+	id := uuid.NewString()
+	userID, tenantID, token := id, "", ""
+	if o.verify != nil {
+		token = o.requestToken(r)
+		if token == "" {
+			return nil, &UpgradeError{Status: http.StatusUnauthorized, Err: ErrUnauthorized}
+		}
+		claims, err := o.verify(token)
+		if err != nil {
+			return nil, &UpgradeError{Status: http.StatusUnauthorized, Err: fmt.Errorf("%w: %v", ErrUnauthorized, err)}
+		}
+		sub, _ := claims["sub"].(string)
+		if sub == "" {
+			return nil, &UpgradeError{Status: http.StatusUnauthorized, Err: fmt.Errorf("%w: token has no subject", ErrUnauthorized)}
+		}
+		userID = sub
+		tenantID, _ = claims[o.tenantClaim].(string)
+	}
+
+	// The origin was checked above, before anything was written
+	upgrader := NewUpgrader(o.config, AllowAllOrigins())
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts := append([]ClientOption{WithToken(token)}, o.clientOpts...)
+	client := NewClient(id, userID, tenantID, conn, hub, hub.logger, o.config, clientOpts...)
+	if result := hub.RegisterWithResult(client); result.Err != nil {
+		return nil, result.Err
+	}
+	client.Start()
+	return client, nil
+}
+
+// requestToken reads the token from the query, then the cookie, then a
+// Bearer Authorization header
+func (o *upgradeOptions) requestToken(r *http.Request) string {
+	if o.tokenParam != "" {
+		if token := r.URL.Query().Get(o.tokenParam); token != "" {
+			return token
+		}
+	}
+	if o.tokenCookie != "" {
+		if cookie, err := r.Cookie(o.tokenCookie); err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+	if token, err := jwt.ExtractToken(r); err == nil {
+		return token
+	}
+	return ""
+}
+
+// sameOrigin accepts requests without an Origin header or whose origin
+// host matches the request host, like gorilla's default check
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
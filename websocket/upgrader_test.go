@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bignyap/go-utilities/logger/adapters/mock"
+	"github.com/gorilla/websocket"
+)
+
+// newUpgradeServer serves UpgradeClient, replying with the UpgradeError
+// status on rejection and sending each upgraded client to clients
+func newUpgradeServer(t *testing.T, hub *Hub, opts ...UpgradeOption) (string, <-chan *Client) {
+	t.Helper()
+	clients := make(chan *Client, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, err := UpgradeClient(w, r, hub, opts...)
+		var upgradeErr *UpgradeError
+		if errors.As(err, &upgradeErr) {
+			http.Error(w, upgradeErr.Error(), upgradeErr.Status)
+			return
+		}
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		clients <- client
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), clients
+}
+
+func newRunningHub(t *testing.T) *Hub {
+	t.Helper()
+	hub := NewHub(mock.NewMockLogger())
+	go hub.Run()
+	t.Cleanup(func() { _ = hub.Shutdown(context.Background()) })
+	return hub
+}
+
+func TestUpgradeClient_RejectsDisallowedOrigin(t *testing.T) {
+	url, _ := newUpgradeServer(t, newRunningHub(t), WithAllowedOrigins("https://app.example.com"))
+
+	header := http.Header{"Origin": []string{"https://evil.example.com"}}
+	_, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err == nil {
+		t.Fatal("expected the dial to fail for a disallowed origin")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %v", resp)
+	}
+}
+
+func TestUpgradeClient_DefaultsToSameOrigin(t *testing.T) {
+	url, clients := newUpgradeServer(t, newRunningHub(t))
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://evil.example.com"}})
+	if err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a cross-origin request to be rejected, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	client := <-clients
+	if client.UserID != client.ID {
+		t.Fatalf("expected an anonymous client keyed by its ID, got user %q", client.UserID)
+	}
+}
+
+func TestUpgradeClient_TokenPopulatesClient(t *testing.T) {
+	hub := newRunningHub(t)
+	verify := func(token string) (map[string]interface{}, error) {
+		if token != "valid-token" {
+			return nil, errors.New("bad signature")
+		}
+		return map[string]interface{}{"sub": "user-1", "tenant_id": "tenant-1"}, nil
+	}
+	url, clients := newUpgradeServer(t, hub, WithTokenVerifier(verify))
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?token=forged", nil)
+	if err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an invalid token to be rejected with 401, got %v", err)
+	}
+	_, resp, err = websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected a missing token to be rejected with 401, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=valid-token", nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	client := <-clients
+	if client.UserID != "user-1" || client.TenantID != "tenant-1" || client.Token != "valid-token" || client.ID == "" {
+		t.Fatalf("unexpected client identity: %+v", client)
+	}
+	if !hub.HasActiveConnection("user-1") {
+		t.Fatal("expected the client to be registered with the hub")
+	}
+}
+
+func TestUpgradeClient_TokenFromCookie(t *testing.T) {
+	verify := func(token string) (map[string]interface{}, error) {
+		return map[string]interface{}{"sub": "user-" + token}, nil
+	}
+	url, clients := newUpgradeServer(t, newRunningHub(t), WithTokenVerifier(verify), WithTokenSource("", "session"))
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Cookie": []string{"session=abc"}})
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if client := <-clients; client.UserID != "user-abc" {
+		t.Fatalf("expected the cookie token to be verified, got user %q", client.UserID)
+	}
+}